@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Bump is the part of a version that is incremented.
+type Bump string
+
+const (
+	// BumpPatch increments the patch version.
+	BumpPatch Bump = "patch"
+	// BumpMinor increments the minor version.
+	BumpMinor Bump = "minor"
+	// BumpMajor increments the major version.
+	BumpMajor Bump = "major"
+	// BumpPrerelease increments the prerelease version.
+	BumpPrerelease Bump = "prerelease"
+)
+
+// parseBump returns the bump mode.
+func parseBump(s string) (Bump, error) {
+	switch b := Bump(s); b {
+	case BumpPatch, BumpMinor, BumpMajor, BumpPrerelease:
+		return b, nil
+	default:
+		return "", fmt.Errorf("invalid bump %q, must be patch, minor, major or prerelease", s)
+	}
+}
+
+// bump increments the version. A prerelease bump increments the number of
+// an existing prerelease, e.g. 1.2.3-rc.1 to 1.2.3-rc.2, or starts a prerelease
+// with the identifier of the next patch, e.g. 1.2.3 to 1.2.4-rc.0.
+func bump(version string, b Bump, preid string) (string, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	var next semver.Version
+
+	switch b {
+	case BumpPatch:
+		next = v.IncPatch()
+	case BumpMinor:
+		next = v.IncMinor()
+	case BumpMajor:
+		next = v.IncMajor()
+	case BumpPrerelease:
+		next, err = incPrerelease(v, preid)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("invalid bump %q", b)
+	}
+
+	return next.String(), nil
+}
+
+func incPrerelease(v *semver.Version, preid string) (semver.Version, error) {
+	pre := v.Prerelease()
+	if pre == "" {
+		return v.IncPatch().SetPrerelease(preid + ".0")
+	}
+
+	parts := strings.Split(pre, ".")
+	last := len(parts) - 1
+
+	n, err := strconv.Atoi(parts[last])
+	if err != nil {
+		parts = append(parts, "0")
+	} else {
+		parts[last] = strconv.Itoa(n + 1)
+	}
+
+	return v.SetPrerelease(strings.Join(parts, "."))
+}
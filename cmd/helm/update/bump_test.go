@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		version string
+		bump    Bump
+		want    string
+	}{
+		{version: "1.2.3", bump: BumpPatch, want: "1.2.4"},
+		{version: "v1.2.3", bump: BumpPatch, want: "1.2.4"},
+		{version: "1.2.3", bump: BumpMinor, want: "1.3.0"},
+		{version: "1.2.3", bump: BumpMajor, want: "2.0.0"},
+		{version: "1.2.3-rc.1", bump: BumpPatch, want: "1.2.3"},
+		{version: "1.2.3", bump: BumpPrerelease, want: "1.2.4-rc.0"},
+		{version: "1.2.3-rc.1", bump: BumpPrerelease, want: "1.2.3-rc.2"},
+		{version: "1.2.3-beta", bump: BumpPrerelease, want: "1.2.3-beta.0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version+"/"+string(tc.bump), func(t *testing.T) {
+			got, err := bump(tc.version, tc.bump, "rc")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestBumpInvalid(t *testing.T) {
+	_, err := bump("latest", BumpPatch, "rc")
+	require.Error(t, err)
+
+	_, err = parseBump("micro")
+	require.Error(t, err)
+}
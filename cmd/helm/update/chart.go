@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	chartFile  = "Chart.yaml"
+	valuesFile = "values.yaml"
+)
+
+// file is a YAML file that is edited in place.
+type file struct {
+	path string
+	orig []byte
+	doc  *document
+}
+
+// readFile reads the YAML file.
+func readFile(path string) (*file, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := parseDocument(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &file{path: path, orig: b, doc: doc}, nil
+}
+
+// changed returns true if the file has been changed.
+func (f *file) changed() bool {
+	return !bytes.Equal(f.orig, f.doc.Bytes())
+}
+
+// dependency is a dependency of a chart.
+type dependency struct {
+	index   int
+	name    string
+	version string
+}
+
+// chart is a chart with its Chart.yaml and values.yaml.
+type chart struct {
+	name    string
+	version string
+	deps    []dependency
+
+	chart  *file
+	values *file
+}
+
+// loadChart loads the chart from the directory.
+func loadChart(dir string) (*chart, error) {
+	f, err := readFile(filepath.Join(dir, chartFile))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &chart{chart: f}
+
+	if c.name, err = f.doc.Get("name"); err != nil {
+		return nil, fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	if c.version, err = f.doc.Get("version"); err != nil {
+		return nil, fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	for i := 0; ; i++ {
+		name, err := f.doc.Get(fmt.Sprintf("dependencies[%d].name", i))
+		if errors.Is(err, ErrPathNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.path, err)
+		}
+
+		version, err := f.doc.Get(fmt.Sprintf("dependencies[%d].version", i))
+		if err != nil && !errors.Is(err, ErrPathNotFound) {
+			return nil, fmt.Errorf("%s: %w", f.path, err)
+		}
+
+		c.deps = append(c.deps, dependency{index: i, name: name, version: version})
+	}
+
+	c.values, err = readFile(filepath.Join(dir, valuesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+
+	return c, err
+}
+
+// setVersion sets the version of the chart.
+func (c *chart) setVersion(version string) error {
+	if _, err := c.chart.doc.Set("version", version); err != nil {
+		return fmt.Errorf("%s: %w", c.chart.path, err)
+	}
+	c.version = version
+
+	return nil
+}
+
+// setAppVersion sets the app version of the chart and adds it if it is missing.
+func (c *chart) setAppVersion(version string) error {
+	_, err := c.chart.doc.Set("appVersion", version)
+	if errors.Is(err, ErrPathNotFound) {
+		err = c.chart.doc.Append("appVersion", version)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.chart.path, err)
+	}
+
+	return nil
+}
+
+// setDependency sets the version of the dependency, unless it is a range that contains the version.
+// It returns true if the version has been changed.
+func (c *chart) setDependency(dep dependency, version string) (bool, error) {
+	if _, err := semver.StrictNewVersion(dep.version); err != nil {
+		if constraint, err := semver.NewConstraint(dep.version); err == nil {
+			if v, err := semver.NewVersion(version); err == nil && constraint.Check(v) {
+				return false, nil
+			}
+		}
+	}
+
+	changed, err := c.chart.doc.Set(fmt.Sprintf("dependencies[%d].version", dep.index), version)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", c.chart.path, err)
+	}
+
+	return changed, nil
+}
+
+// findCharts returns the charts in the directory and its subdirectories.
+// The subcharts in the charts directory of a chart are skipped.
+func findCharts(root string) ([]*chart, error) {
+	var charts []*chart
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		if path != root && d.Name()[0] == '.' {
+			return filepath.SkipDir
+		}
+
+		if d.Name() == "charts" {
+			if _, err := os.Stat(filepath.Join(filepath.Dir(path), chartFile)); err == nil {
+				return filepath.SkipDir
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(path, chartFile)); err != nil {
+			return nil
+		}
+
+		c, err := loadChart(path)
+		if err != nil {
+			return err
+		}
+		charts = append(charts, c)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return charts, nil
+}
@@ -2,20 +2,27 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"os"
-	"strings"
+	"path/filepath"
 
 	"github.com/zeiss/pkg/logx"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/spf13/pflag"
-	"golang.org/x/mod/semver"
-	"helm.sh/helm/pkg/chartutil"
 )
 
 type flags struct {
-	File    string
-	Version string
+	File       string
+	Dir        string
+	Version    string
+	AppVersion string
+	Bump       string
+	Preid      string
+	Charts     []string
+	Values     []string
+	DryRun     bool
 }
 
 func main() {
@@ -27,31 +34,91 @@ func main() {
 		log.Fatal(err)
 	}
 
-	f := &flags{}
+	f := &flags{Dir: ".", Preid: "rc"}
 
-	pflag.StringVar(&f.File, "file", f.File, "chart")
-	pflag.StringVar(&f.Version, "version", f.Version, "version")
+	pflag.StringVar(&f.File, "file", f.File, "chart file to update, instead of all charts in --dir")
+	pflag.StringVar(&f.Dir, "dir", f.Dir, "directory to search for charts")
+	pflag.StringVar(&f.Version, "version", f.Version, "version of the charts, also used as app version")
+	pflag.StringVar(&f.AppVersion, "app-version", f.AppVersion, "app version of the charts")
+	pflag.StringVar(&f.Bump, "bump", f.Bump, "bump the versions of the charts (patch, minor, major or prerelease)")
+	pflag.StringVar(&f.Preid, "preid", f.Preid, "identifier of new prerelease versions")
+	pflag.StringSliceVar(&f.Charts, "chart", f.Charts, "name of a chart to update (default all charts)")
+	pflag.StringArrayVar(&f.Values, "set", f.Values, "set PATH=VALUE in values.yaml, e.g. image.tag=1.2.3")
+	pflag.BoolVar(&f.DryRun, "dry-run", f.DryRun, "print a diff of the changes instead of writing them")
 	pflag.Parse()
 
-	f.Version = semver.Canonical(f.Version)
+	if err := run(f); err != nil {
+		log.Fatal(err)
+	}
+}
 
-	ok := semver.IsValid(f.Version)
-	if !ok {
-		log.Fatal(errors.New("updater: no valid version"))
+func run(f *flags) error {
+	opts := options{
+		Version:    f.Version,
+		AppVersion: f.AppVersion,
+		Preid:      f.Preid,
+		Charts:     f.Charts,
+		Values:     f.Values,
 	}
 
-	f.Version = strings.TrimPrefix(f.Version, "v")
+	if f.Version != "" && f.Bump != "" {
+		return errors.New("updater: --version and --bump are mutually exclusive")
+	}
 
-	meta, err := chartutil.LoadChartfile(f.File)
-	if err != nil {
-		log.Fatal(err)
+	if f.Version == "" && f.Bump == "" && f.AppVersion == "" && len(f.Values) == 0 {
+		return errors.New("updater: nothing to update, set --version, --bump, --app-version or --set")
+	}
+
+	if f.Version != "" {
+		v, err := semver.NewVersion(f.Version)
+		if err != nil {
+			return fmt.Errorf("updater: no valid version: %w", err)
+		}
+		opts.Version = v.String()
+	}
+
+	if f.Bump != "" {
+		b, err := parseBump(f.Bump)
+		if err != nil {
+			return err
+		}
+		opts.Bump = b
 	}
 
-	meta.AppVersion = f.Version
-	meta.Version = f.Version
+	var charts []*chart
+	if f.File != "" {
+		c, err := loadChart(filepath.Dir(f.File))
+		if err != nil {
+			return err
+		}
+		charts = append(charts, c)
+	} else {
+		var err error
+		if charts, err = findCharts(f.Dir); err != nil {
+			return err
+		}
+	}
 
-	err = chartutil.SaveChartfile(f.File, meta)
+	if len(charts) == 0 {
+		return errors.New("updater: no charts found")
+	}
+
+	files, err := update(charts, opts)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+
+	for _, file := range files {
+		if f.DryRun {
+			err = writeDiff(os.Stdout, file)
+		} else {
+			err = writeFile(file)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// options are the options of an update.
+type options struct {
+	// Version is the new version of the charts.
+	Version string
+	// AppVersion is the new app version of the charts.
+	AppVersion string
+	// Bump increments the versions of the charts, if no version is set.
+	Bump Bump
+	// Preid is the identifier of new prereleases.
+	Preid string
+	// Charts are the names of the charts to update, all charts if empty.
+	Charts []string
+	// Values are the PATH=VALUE pairs to set in the values.yaml of the charts.
+	Values []string
+}
+
+// update updates the charts and returns the changed files.
+// Charts that depend on an updated chart get the new version of the dependency
+// and are bumped themselves, with a patch if an explicit version is set.
+func update(charts []*chart, opts options) ([]*file, error) {
+	selected, err := selectCharts(charts, opts.Charts)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make(map[*chart]bool, len(charts))
+	queue := make([]*chart, 0, len(charts))
+
+	if opts.Version != "" || opts.Bump != "" || opts.AppVersion != "" {
+		for _, c := range selected {
+			if err := setVersion(c, opts); err != nil {
+				return nil, err
+			}
+
+			updated[c] = true
+			queue = append(queue, c)
+		}
+	}
+
+	cascade := opts.Bump
+	if cascade == "" {
+		cascade = BumpPatch
+	}
+
+	for len(queue) > 0 {
+		dep := queue[0]
+		queue = queue[1:]
+
+		for _, c := range charts {
+			for _, d := range c.deps {
+				if d.name != dep.name {
+					continue
+				}
+
+				changed, err := c.setDependency(d, dep.version)
+				if err != nil {
+					return nil, err
+				}
+
+				if !changed || updated[c] {
+					continue
+				}
+
+				version, err := bump(c.version, cascade, opts.Preid)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", c.name, err)
+				}
+
+				if err := c.setVersion(version); err != nil {
+					return nil, err
+				}
+
+				updated[c] = true
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	if err := setValues(selected, opts.Values); err != nil {
+		return nil, err
+	}
+
+	var files []*file
+	for _, c := range charts {
+		for _, f := range []*file{c.chart, c.values} {
+			if f != nil && f.changed() {
+				files = append(files, f)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// setVersion sets the version and the app version of a selected chart.
+// The app version follows an explicit version if it is not set.
+func setVersion(c *chart, opts options) error {
+	version := opts.Version
+	if opts.Bump != "" {
+		var err error
+		if version, err = bump(c.version, opts.Bump, opts.Preid); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+	}
+
+	if version != "" {
+		if err := c.setVersion(version); err != nil {
+			return err
+		}
+	}
+
+	appVersion := opts.AppVersion
+	if appVersion == "" {
+		appVersion = opts.Version
+	}
+
+	if appVersion == "" {
+		return nil
+	}
+
+	return c.setAppVersion(appVersion)
+}
+
+// selectCharts returns the charts with the names, or all charts if there are no names.
+func selectCharts(charts []*chart, names []string) ([]*chart, error) {
+	if len(names) == 0 {
+		return charts, nil
+	}
+
+	selected := make([]*chart, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(charts, func(c *chart) bool { return c.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown chart %q", name)
+		}
+
+		selected = append(selected, charts[i])
+	}
+
+	return selected, nil
+}
+
+// setValues sets the PATH=VALUE pairs in the values of the charts.
+// Charts without the path are skipped, but every path has to exist in one chart.
+func setValues(charts []*chart, values []string) error {
+	for _, kv := range values {
+		path, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("invalid value %q, must be PATH=VALUE", kv)
+		}
+
+		found := false
+		for _, c := range charts {
+			if c.values == nil {
+				continue
+			}
+
+			_, err := c.values.doc.Set(path, value)
+			if errors.Is(err, ErrPathNotFound) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", c.values.path, err)
+			}
+
+			found = true
+		}
+
+		if !found {
+			return fmt.Errorf("%w in any values: %s", ErrPathNotFound, path)
+		}
+	}
+
+	return nil
+}
+
+// writeDiff writes the unified diff of the changes of the file.
+func writeDiff(w io.Writer, f *file) error {
+	return difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(f.orig)),
+		B:        difflib.SplitLines(string(f.doc.Bytes())),
+		FromFile: "a/" + f.path,
+		ToFile:   "b/" + f.path,
+		Context:  3,
+	})
+}
+
+// writeFile writes the changes of the file and keeps its permissions.
+func writeFile(f *file) error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(f.path, f.doc.Bytes(), info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	log.Printf("updater: updated %s", f.path)
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCharts writes the files relative to a temporary directory and returns it.
+func writeCharts(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	return dir
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return string(b)
+}
+
+var testCharts = map[string]string{
+	"charts/lib/Chart.yaml": "apiVersion: v2\nname: lib\nversion: 0.1.0\n",
+	"charts/app/Chart.yaml": `apiVersion: v2
+name: app
+# The chart version.
+version: 1.0.0
+appVersion: "1.0.0"
+dependencies:
+  - name: lib
+    version: 0.1.0 # pinned
+    repository: file://../lib
+  - name: redis
+    version: ~18.0.0
+    repository: https://charts.bitnami.com/bitnami
+`,
+	"charts/app/values.yaml":           "image:\n  repository: app\n  # The tag of the image.\n  tag: \"1.0.0\"\n",
+	"charts/app/charts/lib/Chart.yaml": "apiVersion: v2\nname: lib\nversion: 0.0.1\n",
+	"charts/umbrella/Chart.yaml": `apiVersion: v2
+name: umbrella
+version: 2.0.0
+dependencies:
+  - name: app
+    version: ">=1.0.0 <2.0.0"
+    repository: file://../app
+  - name: lib
+    version: 0.1.0
+    repository: file://../lib
+`,
+}
+
+func TestFindCharts(t *testing.T) {
+	dir := writeCharts(t, testCharts)
+
+	charts, err := findCharts(dir)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(charts))
+	for _, c := range charts {
+		names = append(names, c.name)
+	}
+	assert.ElementsMatch(t, []string{"lib", "app", "umbrella"}, names, "subcharts are skipped")
+}
+
+func TestUpdateCascadesDependencies(t *testing.T) {
+	dir := writeCharts(t, testCharts)
+
+	err := run(&flags{Dir: dir, Bump: "patch", Charts: []string{"lib"}, Preid: "rc"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "apiVersion: v2\nname: lib\nversion: 0.1.1\n", readString(t, filepath.Join(dir, "charts/lib/Chart.yaml")))
+
+	app := readString(t, filepath.Join(dir, "charts/app/Chart.yaml"))
+	assert.Contains(t, app, "# The chart version.\nversion: 1.0.1\n")
+	assert.Contains(t, app, "appVersion: \"1.0.0\"\n", "app version is kept when bumping")
+	assert.Contains(t, app, "    version: 0.1.1 # pinned\n")
+	assert.Contains(t, app, "    version: ~18.0.0\n")
+
+	umbrella := readString(t, filepath.Join(dir, "charts/umbrella/Chart.yaml"))
+	assert.Contains(t, umbrella, "version: 2.0.1\n")
+	assert.Contains(t, umbrella, "    version: \">=1.0.0 <2.0.0\"\n", "ranges that contain the version are kept")
+	assert.Contains(t, umbrella, "  - name: lib\n    version: 0.1.1\n")
+
+	assert.Equal(t, testCharts["charts/app/charts/lib/Chart.yaml"], readString(t, filepath.Join(dir, "charts/app/charts/lib/Chart.yaml")))
+}
+
+func TestUpdateVersionAndValues(t *testing.T) {
+	dir := writeCharts(t, testCharts)
+
+	err := run(&flags{Dir: dir, Version: "v2.0", Charts: []string{"app"}, Values: []string{"image.tag=2.0.0"}})
+	require.NoError(t, err)
+
+	app := readString(t, filepath.Join(dir, "charts/app/Chart.yaml"))
+	assert.Contains(t, app, "version: 2.0.0\nappVersion: \"2.0.0\"\n")
+
+	values := readString(t, filepath.Join(dir, "charts/app/values.yaml"))
+	assert.Equal(t, "image:\n  repository: app\n  # The tag of the image.\n  tag: \"2.0.0\"\n", values)
+
+	umbrella := readString(t, filepath.Join(dir, "charts/umbrella/Chart.yaml"))
+	assert.Contains(t, umbrella, "    version: \"2.0.0\"\n", "ranges that do not contain the version are replaced")
+	assert.Contains(t, umbrella, "\nversion: 2.0.1\n", "dependents are bumped by a patch")
+}
+
+func TestUpdateDryRun(t *testing.T) {
+	dir := writeCharts(t, testCharts)
+
+	charts, err := findCharts(dir)
+	require.NoError(t, err)
+
+	files, err := update(charts, options{Bump: BumpMinor, Charts: []string{"umbrella"}})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeDiff(&buf, files[0]))
+	assert.Contains(t, buf.String(), "-version: 2.0.0\n+version: 2.1.0\n")
+
+	assert.Equal(t, testCharts["charts/umbrella/Chart.yaml"], readString(t, filepath.Join(dir, "charts/umbrella/Chart.yaml")))
+}
+
+func TestUpdateErrors(t *testing.T) {
+	dir := writeCharts(t, testCharts)
+
+	require.ErrorContains(t, run(&flags{Dir: dir}), "nothing to update")
+	require.ErrorContains(t, run(&flags{Dir: dir, Version: "1.0.0", Bump: "patch"}), "mutually exclusive")
+	require.ErrorContains(t, run(&flags{Dir: dir, Version: "latest"}), "no valid version")
+	require.ErrorContains(t, run(&flags{Dir: dir, Bump: "patch", Charts: []string{"unknown"}}), "unknown chart")
+	require.ErrorIs(t, run(&flags{Dir: dir, Values: []string{"image.digest=x"}}), ErrPathNotFound)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPathNotFound is returned when a path does not exist in a document.
+var ErrPathNotFound = errors.New("path not found")
+
+// document is a YAML document that is edited in place,
+// so that comments, ordering and formatting are preserved.
+type document struct {
+	src  []byte
+	root *yaml.Node
+}
+
+// parseDocument parses the YAML document.
+func parseDocument(src []byte) (*document, error) {
+	d := &document{src: src}
+	if err := d.parse(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *document) parse() error {
+	var n yaml.Node
+	if err := yaml.Unmarshal(d.src, &n); err != nil {
+		return err
+	}
+
+	d.root = &n
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		d.root = n.Content[0]
+	}
+
+	return nil
+}
+
+// Bytes returns the source of the document.
+func (d *document) Bytes() []byte {
+	return d.src
+}
+
+// Get returns the scalar at the path.
+func (d *document) Get(path string) (string, error) {
+	n, err := d.lookup(path)
+	if err != nil {
+		return "", err
+	}
+
+	return n.Value, nil
+}
+
+// Set replaces the scalar at the path with the value and keeps its quoting style.
+// It returns true if the value has been changed.
+func (d *document) Set(path, value string) (bool, error) {
+	n, err := d.lookup(path)
+	if err != nil {
+		return false, err
+	}
+
+	if n.Value == value && n.Tag != "!!null" {
+		return false, nil
+	}
+
+	start, end, err := d.span(n)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+
+	repl, err := formatScalar(n, value)
+	if err != nil {
+		return false, err
+	}
+
+	// an empty value has no token to replace
+	if start == end {
+		repl = " " + repl
+	}
+
+	src := make([]byte, 0, len(d.src)-(end-start)+len(repl))
+	src = append(src, d.src[:start]...)
+	src = append(src, repl...)
+	src = append(src, d.src[end:]...)
+	d.src = src
+
+	return true, d.parse()
+}
+
+// Append adds the key with the string value to the end of the top-level mapping.
+func (d *document) Append(key, value string) error {
+	if d.root.Kind != yaml.MappingNode {
+		return errors.New("document is not a mapping")
+	}
+
+	if child(d.root, key) != nil {
+		return fmt.Errorf("key %s already exists", key)
+	}
+
+	v, err := formatScalar(&yaml.Node{Tag: "!!str"}, value)
+	if err != nil {
+		return err
+	}
+
+	src := bytes.Clone(d.src)
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		src = append(src, '\n')
+	}
+	d.src = append(src, key+": "+v+"\n"...)
+
+	return d.parse()
+}
+
+// lookup returns the scalar node at the path, e.g. image.tag or dependencies[0].version.
+func (d *document) lookup(path string) (*yaml.Node, error) {
+	keys, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	n := d.root
+	for _, key := range keys {
+		n = child(n, key)
+		if n == nil {
+			return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
+		}
+	}
+
+	if n.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("%s: not a scalar value", path)
+	}
+
+	return n, nil
+}
+
+// child returns the value of the key in a mapping, or the item at the index in a sequence.
+func child(n *yaml.Node, key string) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				return n.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(key)
+		if err == nil && i >= 0 && i < len(n.Content) {
+			return n.Content[i]
+		}
+	}
+
+	return nil
+}
+
+// splitPath splits a path like .image.tag or images[1].tag into its keys.
+func splitPath(path string) ([]string, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if p == "" {
+		return nil, fmt.Errorf("invalid path %q", path)
+	}
+
+	var keys []string
+	for _, part := range strings.Split(p, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name == "" && rest == "" {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+		if name != "" {
+			keys = append(keys, name)
+		}
+
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			if !ok || idx == "" {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			keys = append(keys, idx)
+
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+
+	return keys, nil
+}
+
+// span returns the byte offsets of the token of the scalar node in the source.
+func (d *document) span(n *yaml.Node) (int, int, error) {
+	start, err := d.offset(n.Line, n.Column)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rest := d.src[start:]
+
+	switch {
+	case n.Style&yaml.DoubleQuotedStyle != 0:
+		for i := 1; i < len(rest); i++ {
+			switch rest[i] {
+			case '\\':
+				i++
+			case '"':
+				return start, start + i + 1, nil
+			}
+		}
+	case n.Style&yaml.SingleQuotedStyle != 0:
+		for i := 1; i < len(rest); i++ {
+			if rest[i] != '\'' {
+				continue
+			}
+			if i+1 < len(rest) && rest[i+1] == '\'' {
+				i++
+				continue
+			}
+
+			return start, start + i + 1, nil
+		}
+	case n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		return 0, 0, errors.New("block scalars are not supported")
+	case n.Tag == "!!null" && n.Value == "":
+		return start, start, nil
+	case bytes.HasPrefix(rest, []byte(n.Value)):
+		return start, start + len(n.Value), nil
+	}
+
+	return 0, 0, errors.New("unsupported scalar")
+}
+
+// offset returns the byte offset of the line and the column in characters, both starting at one.
+func (d *document) offset(line, column int) (int, error) {
+	off := 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(d.src[off:], '\n')
+		if i < 0 {
+			return 0, fmt.Errorf("line %d out of range", line)
+		}
+		off += i + 1
+	}
+
+	for c := 1; c < column && off < len(d.src); c++ {
+		_, size := utf8.DecodeRune(d.src[off:])
+		off += size
+	}
+
+	return off, nil
+}
+
+// formatScalar formats the value in the quoting style of the node.
+// Plain values are quoted if they would not be read as the same type.
+func formatScalar(n *yaml.Node, value string) (string, error) {
+	tag := n.Tag
+	if tag == "!!null" || resolveTag(value) != tag {
+		tag = "!!str"
+	}
+
+	out, err := yaml.Marshal(&yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   tag,
+		Style: n.Style & (yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle),
+		Value: value,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// resolveTag returns the tag a plain value is read as.
+func resolveTag(value string) string {
+	var n yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &n); err != nil || len(n.Content) == 0 {
+		return "!!str"
+	}
+
+	return n.Content[0].Tag
+}
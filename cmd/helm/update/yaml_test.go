@@ -0,0 +1,163 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path string
+		keys []string
+		err  bool
+	}{
+		{path: "image.tag", keys: []string{"image", "tag"}},
+		{path: ".image.tag", keys: []string{"image", "tag"}},
+		{path: "$.image.tag", keys: []string{"image", "tag"}},
+		{path: "images[1].tag", keys: []string{"images", "1", "tag"}},
+		{path: "matrix[0][2]", keys: []string{"matrix", "0", "2"}},
+		{path: "", err: true},
+		{path: "image..tag", err: true},
+		{path: "images[].tag", err: true},
+		{path: "images[1.tag", err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			keys, err := splitPath(tc.path)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.keys, keys)
+		})
+	}
+}
+
+func TestDocumentSet(t *testing.T) {
+	src := `# Default values.
+replicaCount: 1
+
+image:
+  repository: nginx # the image
+  # Overrides the image tag.
+  tag: "1.0.0"
+  digest:
+  pullPolicy: 'IfNotPresent'
+
+sidecars:
+  - name: proxy
+    tag: v1 # pinned
+`
+
+	tests := []struct {
+		name  string
+		path  string
+		value string
+		want  string
+	}{
+		{
+			name:  "double quoted",
+			path:  "image.tag",
+			value: "1.1.0",
+			want:  "  tag: \"1.1.0\"\n",
+		},
+		{
+			name:  "single quoted",
+			path:  "image.pullPolicy",
+			value: "Always",
+			want:  "  pullPolicy: 'Always'\n",
+		},
+		{
+			name:  "plain with comment",
+			path:  "sidecars[0].tag",
+			value: "v2",
+			want:  "    tag: v2 # pinned\n",
+		},
+		{
+			name:  "plain quoted if it is no string",
+			path:  "image.repository",
+			value: "1.10",
+			want:  "  repository: \"1.10\" # the image\n",
+		},
+		{
+			name:  "plain number",
+			path:  "replicaCount",
+			value: "3",
+			want:  "replicaCount: 3\n",
+		},
+		{
+			name:  "empty",
+			path:  "image.digest",
+			value: "sha256:abc",
+			want:  "  digest: sha256:abc\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := parseDocument([]byte(src))
+			require.NoError(t, err)
+
+			changed, err := d.Set(tc.path, tc.value)
+			require.NoError(t, err)
+			assert.True(t, changed)
+			assert.Contains(t, string(d.Bytes()), tc.want)
+
+			got, err := d.Get(tc.path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.value, got)
+
+			// everything but the changed line stays the same
+			assert.Len(t, d.Bytes(), len(src)-len(lineOf(src, tc.path))+len(tc.want))
+		})
+	}
+}
+
+// lineOf returns the line of the value at the path in the source.
+func lineOf(src, path string) string {
+	d, _ := parseDocument([]byte(src))
+	n, _ := d.lookup(path)
+
+	start, _ := d.offset(n.Line, 1)
+	end, _ := d.offset(n.Line+1, 1)
+
+	return src[start:end]
+}
+
+func TestDocumentSetUnchanged(t *testing.T) {
+	d, err := parseDocument([]byte("tag: v1\n"))
+	require.NoError(t, err)
+
+	changed, err := d.Set("tag", "v1")
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestDocumentSetErrors(t *testing.T) {
+	d, err := parseDocument([]byte("image:\n  tag: v1\nnotes: |\n  text\n"))
+	require.NoError(t, err)
+
+	_, err = d.Set("image.digest", "x")
+	require.ErrorIs(t, err, ErrPathNotFound)
+
+	_, err = d.Set("image", "x")
+	require.ErrorContains(t, err, "not a scalar")
+
+	_, err = d.Set("notes", "x")
+	require.ErrorContains(t, err, "block scalars")
+}
+
+func TestDocumentAppend(t *testing.T) {
+	d, err := parseDocument([]byte("name: app\nversion: 1.0.0"))
+	require.NoError(t, err)
+
+	require.NoError(t, d.Append("appVersion", "1.0.0"))
+	assert.Equal(t, "name: app\nversion: 1.0.0\nappVersion: 1.0.0\n", string(d.Bytes()))
+
+	require.Error(t, d.Append("name", "other"))
+}
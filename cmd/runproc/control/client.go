@@ -0,0 +1,105 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// ErrNotRunning is returned when no instance is listening on the socket.
+var ErrNotRunning = errors.New("runproc is not running")
+
+// Client is a client of the control socket.
+type Client struct {
+	path string
+}
+
+// NewClient returns a new client for the socket at path.
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// Processes returns the state of the processes.
+func (c *Client) Processes(ctx context.Context) ([]Process, error) {
+	var processes []Process
+
+	err := c.call(ctx, Request{Method: MethodPs}, func(resp Response) error {
+		processes = resp.Processes
+		return nil
+	})
+
+	return processes, err
+}
+
+// Restart restarts the process, or starts it again if it has exited.
+func (c *Client) Restart(ctx context.Context, name string) error {
+	return c.call(ctx, Request{Method: MethodRestart, Name: name}, nil)
+}
+
+// Stop stops the process gracefully.
+func (c *Client) Stop(ctx context.Context, name string) error {
+	return c.call(ctx, Request{Method: MethodStop, Name: name}, nil)
+}
+
+// Kill kills the process.
+func (c *Client) Kill(ctx context.Context, name string) error {
+	return c.call(ctx, Request{Method: MethodKill, Name: name}, nil)
+}
+
+// Logs calls fn with the recent logs of the process, or all processes if name is empty.
+// The logs are streamed until the context is canceled when follow is set.
+func (c *Client) Logs(ctx context.Context, name string, tail int, follow bool, fn func(LogEntry) error) error {
+	req := Request{Method: MethodLogs, Name: name, Tail: tail, Follow: follow}
+
+	return c.call(ctx, req, func(resp Response) error {
+		if resp.Log == nil {
+			return nil
+		}
+
+		return fn(*resp.Log)
+	})
+}
+
+// call sends the request and calls fn with the responses until the connection is closed.
+func (c *Client) call(ctx context.Context, req Request, fn func(Response) error) error {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotRunning, err)
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(conn)
+
+	for {
+		var resp Response
+		if err := dec.Decode(&resp); err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+
+		if fn != nil {
+			if err := fn(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package control is the control socket of a running runproc instance.
+//
+// The protocol is a JSON request per connection that is answered
+// with one or more JSON responses, separated by newlines.
+package control
+
+import (
+	"time"
+
+	"github.com/zeiss/pkg/cmd/runproc/task"
+)
+
+// DefaultSocket is the default path of the control socket.
+const DefaultSocket = ".runproc.sock"
+
+// The methods of the control protocol.
+const (
+	MethodPs      = "ps"
+	MethodRestart = "restart"
+	MethodStop    = "stop"
+	MethodKill    = "kill"
+	MethodLogs    = "logs"
+)
+
+// Request is a request to the control socket.
+type Request struct {
+	// Method is the method to call.
+	Method string `json:"method"`
+	// Name is the name of the process, all processes for logs if empty.
+	Name string `json:"name,omitempty"`
+	// Follow streams new lines of the logs.
+	Follow bool `json:"follow,omitempty"`
+	// Tail is the number of lines of the logs to return, all if zero.
+	Tail int `json:"tail,omitempty"`
+}
+
+// Response is a response of the control socket.
+type Response struct {
+	// Error is the error of the request.
+	Error string `json:"error,omitempty"`
+	// Processes are the processes of a ps request.
+	Processes []Process `json:"processes,omitempty"`
+	// Log is an entry of a logs request.
+	Log *LogEntry `json:"log,omitempty"`
+}
+
+// Process is the state of a process.
+type Process struct {
+	Name     string    `json:"name"`
+	State    string    `json:"state"`
+	PID      int       `json:"pid,omitempty"`
+	Started  time.Time `json:"started,omitzero"`
+	Restarts int       `json:"restarts"`
+	ExitCode int       `json:"exit_code"`
+}
+
+// NewProcess returns the process of the snapshot.
+func NewProcess(info task.ProcessInfo) Process {
+	return Process{
+		Name:     info.Name,
+		State:    info.State.String(),
+		PID:      info.PID,
+		Started:  info.Started,
+		Restarts: info.Restarts,
+		ExitCode: info.ExitCode,
+	}
+}
+
+// LogEntry is a line of output or a lifecycle event of a process.
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Process string    `json:"process"`
+	// Stream is the stream of a line of output.
+	Stream task.Stream `json:"stream,omitempty"`
+	// Event is the type of a lifecycle event.
+	Event task.EventType `json:"event,omitempty"`
+	Text  string         `json:"text"`
+}
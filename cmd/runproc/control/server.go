@@ -0,0 +1,277 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/zeiss/pkg/cmd/runproc/task"
+)
+
+// ErrAlreadyRunning is returned when another instance is listening on the socket.
+var ErrAlreadyRunning = errors.New("runproc is already running")
+
+// MaxLogLines is the number of log lines that are kept per process.
+const MaxLogLines = 1000
+
+// followBuffer is the number of log entries that are buffered for a follower,
+// a follower that does not keep up is disconnected.
+const followBuffer = 1024
+
+// Controller controls the processes.
+type Controller interface {
+	Processes() []task.ProcessInfo
+	RestartProcess(name string) error
+	StopProcess(name string) error
+	KillProcess(name string) error
+}
+
+var _ task.Output = (*Server)(nil)
+
+// Server serves the control socket. It is an output of the runner
+// to keep the recent logs of the processes.
+type Server struct {
+	path string
+
+	mu        sync.Mutex
+	logs      map[string][]LogEntry
+	followers map[chan LogEntry]string
+}
+
+// NewServer returns a new server for the socket at path.
+func NewServer(path string) *Server {
+	return &Server{
+		path:      path,
+		logs:      make(map[string][]LogEntry),
+		followers: make(map[chan LogEntry]string),
+	}
+}
+
+// Line implements task.Output.
+func (s *Server) Line(l task.Line) {
+	s.append(LogEntry{Time: l.Time, Process: l.Process, Stream: l.Stream, Text: l.Text})
+}
+
+// Event implements task.Output.
+func (s *Server) Event(e task.Event) {
+	s.append(LogEntry{Time: e.Time, Process: e.Process, Event: e.Type, Text: e.Message()})
+
+	if e.Type != task.EventError && e.Err != nil {
+		s.append(LogEntry{Time: e.Time, Process: e.Process, Event: task.EventError, Text: e.Err.Error()})
+	}
+}
+
+func (s *Server) append(e LogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logs := append(s.logs[e.Process], e)
+	if len(logs) > MaxLogLines {
+		logs = append(logs[:0:0], logs[len(logs)-MaxLogLines:]...)
+	}
+	s.logs[e.Process] = logs
+
+	for ch, name := range s.followers {
+		if name != "" && name != e.Process {
+			continue
+		}
+
+		select {
+		case ch <- e:
+		default:
+			// the follower is too slow
+			delete(s.followers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Serve serves the control socket until the context is canceled.
+// A stale socket of an instance that is not running anymore is removed.
+func (s *Server) Serve(ctx context.Context, ctrl Controller) error {
+	if conn, err := net.Dial("unix", s.path); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%w: %s", ErrAlreadyRunning, s.path)
+	}
+
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var lc net.ListenConfig
+
+	ln, err := lc.Listen(ctx, "unix", s.path)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+
+			s.handle(ctx, conn, ctrl)
+		}()
+	}
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn, ctrl Controller) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	enc := json.NewEncoder(conn)
+
+	var req Request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		_ = enc.Encode(Response{Error: err.Error()})
+		return
+	}
+
+	var err error
+
+	switch req.Method {
+	case MethodPs:
+		resp := Response{Processes: []Process{}}
+		for _, info := range ctrl.Processes() {
+			resp.Processes = append(resp.Processes, NewProcess(info))
+		}
+
+		_ = enc.Encode(resp)
+		return
+	case MethodRestart:
+		err = ctrl.RestartProcess(req.Name)
+	case MethodStop:
+		err = ctrl.StopProcess(req.Name)
+	case MethodKill:
+		err = ctrl.KillProcess(req.Name)
+	case MethodLogs:
+		err = s.streamLogs(ctx, enc, ctrl, req)
+	default:
+		err = fmt.Errorf("unknown method: %q", req.Method)
+	}
+
+	if err != nil {
+		_ = enc.Encode(Response{Error: err.Error()})
+		return
+	}
+
+	if req.Method != MethodLogs {
+		_ = enc.Encode(Response{})
+	}
+}
+
+func (s *Server) streamLogs(ctx context.Context, enc *json.Encoder, ctrl Controller, req Request) error {
+	if req.Name != "" && !knownProcess(ctrl, req.Name) {
+		return fmt.Errorf("%w: %s", task.ErrUnknownProcess, req.Name)
+	}
+
+	logs, ch := s.subscribe(req.Name, req.Tail, req.Follow)
+
+	for i := range logs {
+		if err := enc.Encode(Response{Log: &logs[i]}); err != nil {
+			return nil //nolint:nilerr
+		}
+	}
+
+	if ch == nil {
+		return nil
+	}
+	defer s.unsubscribe(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-ch:
+			if !ok {
+				return errors.New("follower disconnected, it did not keep up with the logs")
+			}
+
+			if err := enc.Encode(Response{Log: &e}); err != nil {
+				return nil //nolint:nilerr
+			}
+		}
+	}
+}
+
+// subscribe returns the recent logs of the process, or all processes if name is empty,
+// and a channel that receives new logs when follow is set.
+func (s *Server) subscribe(name string, tail int, follow bool) ([]LogEntry, chan LogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var logs []LogEntry
+	if name != "" {
+		logs = append(logs, s.logs[name]...)
+	} else {
+		for _, l := range s.logs {
+			logs = append(logs, l...)
+		}
+
+		slices.SortStableFunc(logs, func(a, b LogEntry) int {
+			return a.Time.Compare(b.Time)
+		})
+	}
+
+	if tail > 0 && len(logs) > tail {
+		logs = logs[len(logs)-tail:]
+	}
+
+	if !follow {
+		return logs, nil
+	}
+
+	ch := make(chan LogEntry, followBuffer)
+	s.followers[ch] = name
+
+	return logs, ch
+}
+
+func (s *Server) unsubscribe(ch chan LogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.followers[ch]; ok {
+		delete(s.followers, ch)
+		close(ch)
+	}
+}
+
+func knownProcess(ctrl Controller, name string) bool {
+	for _, info := range ctrl.Processes() {
+		if info.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
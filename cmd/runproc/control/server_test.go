@@ -0,0 +1,128 @@
+package control
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/cmd/runproc/task"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeController struct {
+	restarts chan string
+}
+
+func (c *fakeController) Processes() []task.ProcessInfo {
+	return []task.ProcessInfo{
+		{Name: "web", State: task.ProcessStateRunning, PID: 42, ExitCode: -1},
+		{Name: "worker", State: task.ProcessStateExited, PID: 43, Restarts: 1, ExitCode: 2},
+	}
+}
+
+func (c *fakeController) RestartProcess(name string) error {
+	c.restarts <- name
+	return nil
+}
+
+func (c *fakeController) StopProcess(name string) error {
+	return task.ErrUnknownProcess
+}
+
+func (c *fakeController) KillProcess(name string) error {
+	return nil
+}
+
+func serve(t *testing.T) (*Server, *Client, *fakeController) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "runproc.sock")
+	srv := NewServer(path)
+	ctrl := &fakeController{restarts: make(chan string, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ctx, ctrl) }()
+
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+
+	c := NewClient(path)
+	require.Eventually(t, func() bool {
+		_, err := c.Processes(context.Background())
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return srv, c, ctrl
+}
+
+func TestServerControl(t *testing.T) {
+	_, c, ctrl := serve(t)
+	ctx := context.Background()
+
+	procs, err := c.Processes(ctx)
+	require.NoError(t, err)
+	require.Len(t, procs, 2)
+	assert.Equal(t, Process{Name: "worker", State: "exited", PID: 43, Restarts: 1, ExitCode: 2}, procs[1])
+
+	require.NoError(t, c.Restart(ctx, "web"))
+	assert.Equal(t, "web", <-ctrl.restarts)
+
+	require.ErrorContains(t, c.Stop(ctx, "web"), task.ErrUnknownProcess.Error())
+	require.NoError(t, c.Kill(ctx, "web"))
+}
+
+func TestServerLogs(t *testing.T) {
+	srv, c, _ := serve(t)
+
+	now := time.Now()
+	srv.Line(task.Line{Time: now, Process: "web", Stream: task.StreamStdout, Text: "one"})
+	srv.Line(task.Line{Time: now.Add(time.Millisecond), Process: "worker", Stream: task.StreamStdout, Text: "two"})
+	srv.Event(task.Event{Time: now.Add(2 * time.Millisecond), Process: "web", Type: task.EventStopping})
+
+	var texts []string
+	err := c.Logs(context.Background(), "", 2, false, func(e LogEntry) error {
+		texts = append(texts, e.Text)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"two", "Stopping..."}, texts)
+
+	require.ErrorContains(t, c.Logs(context.Background(), "unknown", 0, false, nil), task.ErrUnknownProcess.Error())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	entries := make(chan LogEntry, 10)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- c.Logs(ctx, "web", 0, true, func(e LogEntry) error {
+			entries <- e
+			return nil
+		})
+	}()
+
+	assert.Equal(t, "one", (<-entries).Text)
+	assert.Equal(t, task.EventStopping, (<-entries).Event)
+
+	srv.Line(task.Line{Time: time.Now(), Process: "worker", Text: "skipped"})
+	srv.Line(task.Line{Time: time.Now(), Process: "web", Text: "followed"})
+	assert.Equal(t, "followed", (<-entries).Text)
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
+func TestServerAlreadyRunning(t *testing.T) {
+	srv, _, ctrl := serve(t)
+
+	err := NewServer(srv.path).Serve(context.Background(), ctrl)
+	require.ErrorIs(t, err, ErrAlreadyRunning)
+}
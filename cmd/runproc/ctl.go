@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zeiss/pkg/cmd/runproc/control"
+)
+
+type logsConfig struct {
+	Follow bool
+	Tail   int
+}
+
+var logsCfg = &logsConfig{}
+
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "List the processes of the running instance.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		procs, err := control.NewClient(cfg.Socket).Processes(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSTATE\tPID\tUPTIME\tRESTARTS\tEXIT")
+
+		for _, p := range procs {
+			pid, uptime, exit := "-", "-", "-"
+			if p.PID > 0 {
+				pid = strconv.Itoa(p.PID)
+			}
+			if p.State == "running" && !p.Started.IsZero() {
+				uptime = time.Since(p.Started).Truncate(time.Second).String()
+			}
+			if p.ExitCode >= 0 {
+				exit = strconv.Itoa(p.ExitCode)
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", p.Name, p.State, pid, uptime, p.Restarts, exit)
+		}
+
+		return w.Flush()
+	},
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart <name>",
+	Short: "Restart a process of the running instance.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return control.NewClient(cfg.Socket).Restart(cmd.Context(), args[0])
+	},
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop <name>",
+	Short: "Stop a process of the running instance.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return control.NewClient(cfg.Socket).Stop(cmd.Context(), args[0])
+	},
+}
+
+var killCmd = &cobra.Command{
+	Use:   "kill <name>",
+	Short: "Kill a process of the running instance.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return control.NewClient(cfg.Socket).Kill(cmd.Context(), args[0])
+	},
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [name]",
+	Short: "Show the logs of the running instance.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+
+		return control.NewClient(cfg.Socket).Logs(cmd.Context(), name, logsCfg.Tail, logsCfg.Follow, func(e control.LogEntry) error {
+			text := e.Text
+			switch e.Event {
+			case "":
+			case "error":
+				text = color.New(color.FgRed).Sprint(text)
+			default:
+				text = color.New(color.Bold).Sprint(text)
+			}
+
+			if name == "" {
+				text = e.Process + " | " + text
+			}
+
+			_, err := fmt.Fprintln(os.Stdout, text)
+			return err
+		})
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsCfg.Follow, "follow", "f", logsCfg.Follow, "Follow the logs.")
+	logsCmd.Flags().IntVarP(&logsCfg.Tail, "tail", "n", logsCfg.Tail, "Number of recent lines to show, all if zero.")
+
+	rootCmd.AddCommand(psCmd, restartCmd, stopCmd, killCmd, logsCmd)
+}
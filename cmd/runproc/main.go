@@ -3,19 +3,37 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/zeiss/pkg/cmd/runproc/control"
 	"github.com/zeiss/pkg/cmd/runproc/task"
+	"github.com/zeiss/pkg/cmd/runproc/tui"
 )
 
 type config struct {
-	File  string
-	Local string
+	File   string
+	Local  string
+	TUI    bool
+	Socket string
+
+	Output      string
+	LogDir      string
+	LogMaxSize  int
+	LogMaxFiles int
 }
 
-var cfg = &config{}
+var cfg = &config{
+	Socket:      control.DefaultSocket,
+	Output:      "text",
+	LogMaxSize:  task.DefaultMaxLogSize >> 20,
+	LogMaxFiles: task.DefaultMaxLogFiles,
+}
 
 var rootCmd = &cobra.Command{
 	Use:   "runproc",
@@ -28,6 +46,12 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.Flags().StringVarP(&cfg.File, "file", "f", cfg.File, "Procfile to run.")
 	rootCmd.Flags().StringVarP(&cfg.Local, "local", "l", cfg.Local, "Local Procfile to append.")
+	rootCmd.Flags().BoolVar(&cfg.TUI, "tui", cfg.TUI, "Show an interactive dashboard of the processes.")
+	rootCmd.Flags().StringVarP(&cfg.Output, "output", "o", cfg.Output, "Output format of the processes (text or json).")
+	rootCmd.Flags().StringVar(&cfg.LogDir, "log-dir", cfg.LogDir, "Directory to write a log file per process to.")
+	rootCmd.Flags().IntVar(&cfg.LogMaxSize, "log-max-size", cfg.LogMaxSize, "Size in megabytes at which the log files are rotated.")
+	rootCmd.Flags().IntVar(&cfg.LogMaxFiles, "log-max-files", cfg.LogMaxFiles, "Number of rotated log files to keep per process.")
+	rootCmd.PersistentFlags().StringVarP(&cfg.Socket, "socket", "s", cfg.Socket, "Control socket of the running instance.")
 
 	rootCmd.SilenceUsage = true
 	rootCmd.SilenceErrors = true
@@ -39,14 +63,17 @@ func runRoot(ctx context.Context) error {
 		log.Fatal(err)
 	}
 
-	envData, err := os.ReadFile(cfg.Local)
-	if err != nil {
-		return err
-	}
-
 	buf := bytes.NewBuffer(data)
-	buf.WriteString("\n")
-	buf.Write(envData)
+
+	if cfg.Local != "" {
+		envData, err := os.ReadFile(cfg.Local)
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString("\n")
+		buf.Write(envData)
+	}
 
 	tasks, err := task.Parse(buf)
 	if err != nil {
@@ -54,7 +81,44 @@ func runRoot(ctx context.Context) error {
 	}
 	log.SetFlags(log.Lshortfile)
 
-	run := task.NewRunner(tasks)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	srv := control.NewServer(cfg.Socket)
+	opts := []task.RunnerOpt{task.WithObserver(srv)}
+
+	if cfg.LogDir != "" {
+		files, err := task.NewFileOutput(cfg.LogDir, task.WithMaxSize(int64(cfg.LogMaxSize)<<20), task.WithMaxFiles(cfg.LogMaxFiles))
+		if err != nil {
+			return err
+		}
+		defer files.Close()
+
+		opts = append(opts, task.WithObserver(files))
+	}
+
+	switch cfg.Output {
+	case "text":
+	case "json":
+		if cfg.TUI {
+			return errors.New("the json output can not be used with the tui")
+		}
+
+		opts = append(opts, task.WithOutput(task.NewJSONOutput(os.Stdout)))
+	default:
+		return fmt.Errorf("unknown output: %q", cfg.Output)
+	}
+
+	if cfg.TUI {
+		d := tui.New()
+		run := task.NewRunner(tasks, append(opts, task.WithOutput(d), task.WithKeepAlive())...)
+		go serve(ctx, srv, run)
+
+		return d.Run(ctx, run)
+	}
+
+	run := task.NewRunner(tasks, opts...)
+	go serve(ctx, srv, run)
 
 	err = run.Run(ctx)
 	if err != nil {
@@ -64,11 +128,20 @@ func runRoot(ctx context.Context) error {
 	return nil
 }
 
+func serve(ctx context.Context, srv *control.Server, run *task.Runner) {
+	if err := srv.Serve(ctx, run); err != nil {
+		log.Printf("control socket: %v", err)
+	}
+}
+
 func main() {
 	log.SetFlags(0)
 	log.SetOutput(os.Stderr)
 
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		log.Fatal(err)
 	}
 }
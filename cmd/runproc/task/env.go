@@ -0,0 +1,61 @@
+package task
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// environ returns the environment of the process, the env files are read
+// in order and the env of the task overwrites values of the env files.
+func (t Task) environ() ([]string, error) {
+	env := os.Environ()
+
+	for _, file := range t.EnvFiles {
+		vars, err := readEnvFile(file)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, vars...)
+	}
+
+	return append(env, t.Env...), nil
+}
+
+// readEnvFile reads KEY=VALUE lines, empty lines and comments are ignored.
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var vars []string
+	var line int
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line++
+		str := strings.TrimSpace(s.Text())
+		if str == "" || str[0] == '#' {
+			continue
+		}
+		str = strings.TrimPrefix(str, "export ")
+
+		k, v, ok := strings.Cut(str, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: invalid env '%s' (expected KEY=VALUE)", path, line, str)
+		}
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+
+		vars = append(vars, k+"="+v)
+	}
+
+	return vars, s.Err()
+}
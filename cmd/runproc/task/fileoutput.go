@@ -0,0 +1,215 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxLogSize is the size in bytes at which a log file is rotated.
+	DefaultMaxLogSize = 10 << 20
+	// DefaultMaxLogFiles is the number of rotated log files that are kept.
+	DefaultMaxLogFiles = 3
+)
+
+// FileOutputOpts are the options for the log files.
+type FileOutputOpts struct {
+	// MaxSize is the size in bytes at which a log file is rotated.
+	MaxSize int64
+	// MaxFiles is the number of rotated log files that are kept per process.
+	MaxFiles int
+}
+
+// Configure is a method that configures the log file options.
+func (o *FileOutputOpts) Configure(opts ...FileOutputOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// FileOutputOpt is a function that configures the log file options.
+type FileOutputOpt func(*FileOutputOpts)
+
+// WithMaxSize is setting the size in bytes at which a log file is rotated.
+func WithMaxSize(size int64) FileOutputOpt {
+	return func(opts *FileOutputOpts) {
+		opts.MaxSize = size
+	}
+}
+
+// WithMaxFiles is setting the number of rotated log files that are kept per process.
+func WithMaxFiles(n int) FileOutputOpt {
+	return func(opts *FileOutputOpts) {
+		opts.MaxFiles = n
+	}
+}
+
+var _ Output = (*fileOutput)(nil)
+
+type fileOutput struct {
+	dir  string
+	opts *FileOutputOpts
+
+	mu    sync.Mutex
+	files map[string]*logFile
+}
+
+// NewFileOutput returns an output that writes the output of each process
+// to the file <name>.log in the directory. The files are rotated to <name>.log.1
+// and so on when they exceed the maximum size. Path separators in the name are replaced by _,
+// so the files are always written to the directory.
+func NewFileOutput(dir string, opts ...FileOutputOpt) (*fileOutput, error) {
+	options := &FileOutputOpts{
+		MaxSize:  DefaultMaxLogSize,
+		MaxFiles: DefaultMaxLogFiles,
+	}
+	options.Configure(opts...)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &fileOutput{
+		dir:   dir,
+		opts:  options,
+		files: make(map[string]*logFile),
+	}, nil
+}
+
+func (o *fileOutput) Line(l Line) {
+	o.write(l.Process, l.Time, string(l.Stream), l.Text)
+}
+
+func (o *fileOutput) Event(e Event) {
+	o.write(e.Process, e.Time, "event", e.Message())
+
+	if e.Type != EventError && e.Err != nil {
+		o.write(e.Process, e.Time, "event", e.Err.Error())
+	}
+}
+
+// Close closes the log files.
+func (o *fileOutput) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var errs []error
+	for _, f := range o.files {
+		if f.file != nil {
+			errs = append(errs, f.file.Close())
+			f.file = nil
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (o *fileOutput) write(name string, t time.Time, stream, text string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	f, ok := o.files[name]
+	if !ok {
+		f = &logFile{path: filepath.Join(o.dir, logFileName(name)), opts: o.opts}
+		o.files[name] = f
+	}
+
+	if err := f.write(fmt.Sprintf("%s [%s] %s\n", t.Format(time.RFC3339Nano), stream, text)); err != nil {
+		log.Printf("writing log of %s: %v", name, err)
+	}
+}
+
+// logFileName returns the name of the log file of a process,
+// it does not contain path separators and cannot refer to a parent directory.
+func logFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == filepath.Separator {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	return name + ".log"
+}
+
+// logFile is a log file that is rotated when it exceeds the maximum size.
+type logFile struct {
+	path string
+	opts *FileOutputOpts
+
+	file *os.File
+	size int64
+}
+
+func (f *logFile) write(s string) error {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+
+	if f.opts.MaxSize > 0 && f.size > 0 && f.size+int64(len(s)) > f.opts.MaxSize {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.WriteString(s)
+	f.size += int64(n)
+
+	return err
+}
+
+func (f *logFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	return nil
+}
+
+func (f *logFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	if f.opts.MaxFiles <= 0 {
+		if err := os.Remove(f.path); err != nil {
+			return err
+		}
+
+		return f.open()
+	}
+
+	_ = os.Remove(fmt.Sprintf("%s.%d", f.path, f.opts.MaxFiles))
+
+	for i := f.opts.MaxFiles - 1; i > 0; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return err
+	}
+
+	return f.open()
+}
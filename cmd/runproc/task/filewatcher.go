@@ -1,57 +1,310 @@
 package task
 
 import (
+	"io/fs"
+	"log"
+	"maps"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
-	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// DefaultDebounce is the time to wait for more changes before a change is reported.
+	DefaultDebounce = 250 * time.Millisecond
+	// DefaultPollInterval is the interval to check for changes when polling.
+	DefaultPollInterval = 500 * time.Millisecond
 )
 
+// WatchOptions configure the file watcher.
+type WatchOptions struct {
+	// Patterns are the files to watch, "**" matches any number of directories.
+	Patterns []string
+	// Exclude are the patterns of files and directories that are not watched.
+	Exclude []string
+	// GitIgnore excludes the files that are ignored by .gitignore files.
+	GitIgnore bool
+	// Debounce is the time to wait for more changes before a change is reported.
+	Debounce time.Duration
+	// Poll checks for changes in an interval instead of using inotify.
+	Poll bool
+	// PollInterval is the interval to check for changes when polling.
+	PollInterval time.Duration
+}
+
+// Watcher reports changes of the watched files. It uses inotify
+// and falls back to polling when inotify is not available (e.g. the watch limit is reached).
 type Watcher struct {
-	p []string
+	opts   WatchOptions
+	ignore *gitIgnore
+
+	changes chan struct{}
+	done    chan struct{}
+	once    sync.Once
 
-	state string
+	mu    sync.Mutex
+	timer *time.Timer
 }
 
-func calcTree(patterns []string) string {
-	var files []string
-	for _, p := range patterns {
-		f, err := filepath.Glob(p)
-		if err != nil {
-			continue
+// NewWatcher starts watching the files.
+func NewWatcher(opts WatchOptions) *Watcher {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+
+	w := &Watcher{
+		opts:    opts,
+		ignore:  newGitIgnore(),
+		changes: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	if opts.GitIgnore {
+		w.ignore.load(".")
+	}
+
+	if opts.Poll {
+		go w.poll()
+		return w
+	}
+
+	fw, err := w.notify()
+	if err != nil {
+		log.Printf("watching with inotify failed, polling for changes: %v", err)
+		go w.poll()
+		return w
+	}
+
+	go w.run(fw)
+
+	return w
+}
+
+// Changes returns a channel that receives when the files have been changed.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops watching the files.
+func (w *Watcher) Close() {
+	w.once.Do(func() {
+		close(w.done)
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		if w.timer != nil {
+			w.timer.Stop()
 		}
-		files = append(files, f...)
+	})
+}
+
+// changed reports the change once no more changes happened within the debounce window.
+func (w *Watcher) changed() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Reset(w.opts.Debounce)
+		return
 	}
 
-	sort.Strings(files)
+	w.timer = time.AfterFunc(w.opts.Debounce, func() {
+		select {
+		case w.changes <- struct{}{}:
+		default:
+		}
+	})
+}
 
-	var t strings.Builder
-	for _, f := range files {
-		info, err := os.Stat(f)
-		if err != nil {
-			continue
+func (w *Watcher) skip(name string, isDir bool) bool {
+	if filepath.Base(name) == ".git" && isDir {
+		return true
+	}
+
+	for _, ex := range w.opts.Exclude {
+		if Match(ex, name) {
+			return true
 		}
-		t.WriteString(f + "\n")
-		t.WriteString(info.ModTime().String() + "\n")
-		t.WriteString(strconv.FormatInt(info.Size(), 10) + "\n")
 	}
 
-	return t.String()
+	return w.opts.GitIgnore && w.ignore.Ignored(name, isDir)
 }
 
-func (w *Watcher) Changed() bool {
-	tree := calcTree(w.p)
-	if tree == w.state {
-		return false
+func (w *Watcher) matches(name string) bool {
+	for _, p := range w.opts.Patterns {
+		if Match(p, name) {
+			return !w.skip(name, false)
+		}
 	}
 
-	w.state = tree
-	return true
+	return false
 }
 
-func Watch(patterns []string) *Watcher {
-	sort.Strings(patterns)
+// walk calls fn for the directories and matching files of the patterns.
+func (w *Watcher) walk(root string, fn func(name string, d fs.DirEntry)) {
+	seen := make(map[string]bool)
+
+	for _, p := range w.opts.Patterns {
+		base, depth := globBase(p)
+
+		start := base
+		if root != "" {
+			if _, ok := relativeTo(base, root); !ok && filepath.Clean(root) != filepath.Clean(base) {
+				continue
+			}
+			start = root
+		}
+
+		baseDepth := len(splitPath(base))
+
+		_ = filepath.WalkDir(start, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil //nolint:nilerr
+			}
+
+			if d.IsDir() {
+				if name != base && w.skip(name, true) {
+					return filepath.SkipDir
+				}
+
+				if depth >= 0 && len(splitPath(name))-baseDepth > depth {
+					return filepath.SkipDir
+				}
+
+				if w.opts.GitIgnore {
+					w.ignore.load(name)
+				}
+			} else if !w.matches(name) {
+				return nil
+			}
 
-	return &Watcher{p: patterns, state: calcTree(patterns)}
+			if !seen[name] {
+				seen[name] = true
+				fn(name, d)
+			}
+
+			return nil
+		})
+	}
+}
+
+func (w *Watcher) notify() (*fsnotify.Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.add(fw, ""); err != nil {
+		_ = fw.Close()
+		return nil, err
+	}
+
+	return fw, nil
+}
+
+// add watches the directories below root, or of all patterns if root is empty.
+func (w *Watcher) add(fw *fsnotify.Watcher, root string) error {
+	var err error
+
+	w.walk(root, func(name string, d fs.DirEntry) {
+		if d.IsDir() && err == nil {
+			err = fw.Add(name)
+		}
+	})
+
+	return err
+}
+
+func (w *Watcher) run(fw *fsnotify.Watcher) {
+	defer fw.Close()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("watching files: %v", err)
+		case ev, ok := <-fw.Events:
+			if !ok {
+				return
+			}
+
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+
+			if info, err := os.Stat(ev.Name); err == nil && info.IsDir() && ev.Has(fsnotify.Create) {
+				// watch new directories, e.g. created by a checkout
+				if err := w.add(fw, ev.Name); err != nil {
+					log.Printf("watching %s: %v", ev.Name, err)
+				}
+
+				found := false
+				w.walk(ev.Name, func(_ string, d fs.DirEntry) {
+					found = found || !d.IsDir()
+				})
+
+				if found {
+					w.changed()
+				}
+
+				continue
+			}
+
+			if w.matches(ev.Name) {
+				w.changed()
+			}
+		}
+	}
+}
+
+func (w *Watcher) snapshot() map[string]string {
+	files := make(map[string]string)
+
+	w.walk("", func(name string, d fs.DirEntry) {
+		if d.IsDir() {
+			return
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return
+		}
+
+		files[name] = info.ModTime().String() + "|" + strconv.FormatInt(info.Size(), 10)
+	})
+
+	return files
+}
+
+func (w *Watcher) poll() {
+	state := w.snapshot()
+
+	t := time.NewTicker(w.opts.PollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-t.C:
+		}
+
+		next := w.snapshot()
+		if !maps.Equal(state, next) {
+			state = next
+			w.changed()
+		}
+	}
 }
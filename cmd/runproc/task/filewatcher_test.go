@@ -0,0 +1,141 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/runproc/main.go", true},
+		{"cmd/**/*.go", "cmd/runproc/main.go", true},
+		{"cmd/**/*.go", "pkg/main.go", false},
+		{"cmd/**", "cmd/runproc/main.go", true},
+		{"cmd/**/task/*.go", "cmd/runproc/task/parse.go", true},
+		{"cmd/**/task/*.go", "cmd/task/parse.go", true},
+		{"./src/*.ts", "src/index.ts", true},
+		{"/abs/**/*.go", "/abs/x/y.go", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, Match(tt.pattern, tt.name), "%s ~ %s", tt.pattern, tt.name)
+	}
+}
+
+func TestGlobBase(t *testing.T) {
+	tests := []struct {
+		pattern string
+		base    string
+		depth   int
+	}{
+		{"**/*.go", ".", -1},
+		{"*.go", ".", 0},
+		{"main.go", ".", 0},
+		{"src/*/index.ts", "src", 1},
+		{"src/**/*.ts", "src", -1},
+		{"/etc/app/*.yaml", "/etc/app", 0},
+	}
+
+	for _, tt := range tests {
+		base, depth := globBase(tt.pattern)
+		assert.Equal(t, tt.base, base, tt.pattern)
+		assert.Equal(t, tt.depth, depth, tt.pattern)
+	}
+}
+
+func TestGitIgnore(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("# build output\nbuild/\n*.log\n!keep.log\n/vendor\ndocs/**/*.tmp\n"), 0o600))
+
+	g := newGitIgnore()
+	g.load(dir)
+
+	assert.True(t, g.Ignored(filepath.Join(dir, "build"), true))
+	assert.True(t, g.Ignored(filepath.Join(dir, "build", "main.go"), false))
+	assert.False(t, g.Ignored(filepath.Join(dir, "build"), false))
+	assert.True(t, g.Ignored(filepath.Join(dir, "app.log"), false))
+	assert.True(t, g.Ignored(filepath.Join(dir, "logs", "app.log"), false))
+	assert.False(t, g.Ignored(filepath.Join(dir, "keep.log"), false))
+	assert.True(t, g.Ignored(filepath.Join(dir, "vendor", "mod.go"), false))
+	assert.False(t, g.Ignored(filepath.Join(dir, "pkg", "vendor", "mod.go"), false))
+	assert.True(t, g.Ignored(filepath.Join(dir, "docs", "a", "b.tmp"), false))
+	assert.False(t, g.Ignored(filepath.Join(dir, "main.go"), false))
+}
+
+func testWatcher(t *testing.T, poll bool) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "pkg"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "pkg", "main.go"), []byte("package main"), 0o600))
+
+	w := NewWatcher(WatchOptions{
+		Patterns:     []string{filepath.Join(dir, "**", "*.go")},
+		Exclude:      []string{filepath.Join(dir, "node_modules")},
+		Debounce:     50 * time.Millisecond,
+		Poll:         poll,
+		PollInterval: 10 * time.Millisecond,
+	})
+	defer w.Close()
+
+	// a file in an excluded directory
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "node_modules", "dep.go"), []byte("package dep"), 0o600))
+	// a file that does not match
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "README.md"), []byte("# readme"), 0o600))
+
+	select {
+	case <-w.Changes():
+		t.Fatal("unexpected change")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// many changes are reported once
+	for i := range 5 {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "pkg", "main.go"), []byte("package main // "+string(rune('a'+i))), 0o600))
+		time.Sleep(5 * time.Millisecond)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "new"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "new", "new.go"), []byte("package new"), 0o600))
+
+	select {
+	case <-w.Changes():
+	case <-time.After(2 * time.Second):
+		t.Fatal("no change reported")
+	}
+
+	select {
+	case <-w.Changes():
+		t.Fatal("change reported more than once")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// files in new directories are watched
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "new", "new.go"), []byte("package new // changed"), 0o600))
+
+	select {
+	case <-w.Changes():
+	case <-time.After(2 * time.Second):
+		t.Fatal("no change reported in new directory")
+	}
+}
+
+func TestWatcherNotify(t *testing.T) {
+	testWatcher(t, false)
+}
+
+func TestWatcherPoll(t *testing.T) {
+	testWatcher(t, true)
+}
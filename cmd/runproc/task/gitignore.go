@@ -0,0 +1,143 @@
+package task
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type ignoreRule struct {
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func (r ignoreRule) match(name string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	rel, ok := relativeTo(r.base, name)
+	if !ok {
+		return false
+	}
+
+	if r.anchored {
+		return Match(r.pattern, rel)
+	}
+
+	return Match(r.pattern, path.Base(rel))
+}
+
+func relativeTo(base, name string) (string, bool) {
+	if base == "." && !filepath.IsAbs(name) {
+		return name, true
+	}
+
+	rel, err := filepath.Rel(base, name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
+
+// gitIgnore holds the rules of the .gitignore files in the watched directories.
+type gitIgnore struct {
+	mu     sync.RWMutex
+	rules  []ignoreRule
+	loaded map[string]bool
+}
+
+func newGitIgnore() *gitIgnore {
+	return &gitIgnore{loaded: make(map[string]bool)}
+}
+
+// load reads the .gitignore file of the directory, if it exists.
+func (g *gitIgnore) load(dir string) {
+	dir = filepath.Clean(dir)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.loaded[dir] {
+		return
+	}
+	g.loaded[dir] = true
+
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " ")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		r := ignoreRule{base: dir}
+
+		if line[0] == '!' {
+			r.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		if line == "" {
+			continue
+		}
+
+		r.pattern = line
+		g.rules = append(g.rules, r)
+	}
+}
+
+// Ignored reports whether the path or one of its parent directories is ignored.
+func (g *gitIgnore) Ignored(name string, isDir bool) bool {
+	segments := splitPath(name)
+
+	for i := range segments {
+		p := strings.Join(segments[:i+1], "/")
+		if p == "" {
+			continue
+		}
+
+		if g.ignored(filepath.FromSlash(p), isDir || i < len(segments)-1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *gitIgnore) ignored(name string, isDir bool) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	ignored := false
+	for _, r := range g.rules {
+		if r.match(name, isDir) {
+			ignored = !r.negate
+		}
+	}
+
+	return ignored
+}
@@ -0,0 +1,100 @@
+package task
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Match reports whether name matches the pattern. The pattern is matched per path segment
+// with path.Match, and a "**" segment matches zero or more segments (e.g. "src/**/*.go").
+func Match(pattern, name string) bool {
+	return matchSegments(splitPath(pattern), splitPath(name))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// collapse consecutive globstars
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 1 {
+				return true
+			}
+
+			for i := range len(name) + 1 {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
+
+func splitPath(p string) []string {
+	p = filepath.ToSlash(filepath.Clean(p))
+	if p == "." {
+		return nil
+	}
+
+	return strings.Split(strings.TrimPrefix(p, "./"), "/")
+}
+
+func hasMeta(segment string) bool {
+	return strings.ContainsAny(segment, `*?[\`)
+}
+
+// globBase returns the directory without meta characters to start walking for the pattern,
+// and the depth of directories below the base that can match (-1 for any depth).
+func globBase(pattern string) (string, int) {
+	segments := splitPath(pattern)
+	if len(segments) == 0 {
+		return ".", 0
+	}
+
+	i := 0
+	for i < len(segments) && !hasMeta(segments[i]) {
+		i++
+	}
+
+	if i == len(segments) {
+		// a plain file path, its directory is watched
+		i--
+	}
+
+	base := "."
+	if i > 0 {
+		base = strings.Join(segments[:i], "/")
+	}
+	if base == "" {
+		// the root of an absolute pattern
+		base = "/"
+	}
+
+	depth := len(segments) - i - 1
+	for _, s := range segments[i:] {
+		if s == "**" {
+			depth = -1
+		}
+	}
+
+	return base, depth
+}
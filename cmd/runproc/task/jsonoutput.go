@@ -0,0 +1,73 @@
+package task
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type jsonLine struct {
+	Time    time.Time `json:"ts"`
+	Process string    `json:"process"`
+	Stream  Stream    `json:"stream"`
+	Line    string    `json:"line"`
+	PID     int       `json:"pid,omitempty"`
+}
+
+type jsonEvent struct {
+	Time     time.Time `json:"ts"`
+	Process  string    `json:"process"`
+	Event    EventType `json:"event"`
+	PID      int       `json:"pid,omitempty"`
+	ExitCode *int      `json:"exit_code,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type jsonOutput struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONOutput returns an output that writes a JSON object per line and event.
+// Lines have the fields ts, process, stream, line and pid, and events the fields
+// ts, process, event, pid, exit_code and error.
+func NewJSONOutput(out io.Writer) Output {
+	return &jsonOutput{enc: json.NewEncoder(out)}
+}
+
+func (o *jsonOutput) Line(l Line) {
+	o.encode(jsonLine{
+		Time:    l.Time,
+		Process: l.Process,
+		Stream:  l.Stream,
+		Line:    l.Text,
+		PID:     l.PID,
+	})
+}
+
+func (o *jsonOutput) Event(e Event) {
+	ev := jsonEvent{
+		Time:    e.Time,
+		Process: e.Process,
+		Event:   e.Type,
+		PID:     e.PID,
+	}
+
+	if e.Type == EventExited {
+		ev.ExitCode = &e.ExitCode
+	}
+
+	if e.Err != nil {
+		ev.Error = e.Err.Error()
+	}
+
+	o.encode(ev)
+}
+
+func (o *jsonOutput) encode(v any) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_ = o.enc.Encode(v)
+}
@@ -0,0 +1,214 @@
+package task
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Stream is the output stream of a process.
+type Stream string
+
+const (
+	// StreamStdout is the standard output of a process, or its terminal.
+	StreamStdout Stream = "stdout"
+	// StreamStderr is the standard error of a process.
+	StreamStderr Stream = "stderr"
+)
+
+// Line is a line of output of a process.
+type Line struct {
+	Time    time.Time
+	Process string
+	Stream  Stream
+	PID     int
+	Text    string
+}
+
+// EventType is the type of a lifecycle event of a process.
+type EventType string
+
+const (
+	EventStarting   EventType = "starting"
+	EventStarted    EventType = "started"
+	EventStopping   EventType = "stopping"
+	EventKilling    EventType = "killing"
+	EventExited     EventType = "exited"
+	EventRestarting EventType = "restarting"
+	EventChanged    EventType = "changed"
+	EventReady      EventType = "ready"
+	EventError      EventType = "error"
+)
+
+// Event is a lifecycle event of a process.
+type Event struct {
+	Time     time.Time
+	Process  string
+	Type     EventType
+	PID      int
+	ExitCode int
+	Err      error
+}
+
+// Message returns the human readable message of the event.
+func (e Event) Message() string {
+	switch e.Type {
+	case EventStarting:
+		return "Starting..."
+	case EventStarted:
+		return fmt.Sprintf("Started with pid %d.", e.PID)
+	case EventStopping:
+		return "Stopping..."
+	case EventKilling:
+		return "Killing..."
+	case EventExited:
+		if e.ExitCode > 0 {
+			return fmt.Sprintf("Exited with code %d.", e.ExitCode)
+		}
+
+		return "Exited."
+	case EventRestarting:
+		return "Restarting..."
+	case EventChanged:
+		return "Change detected, restarting..."
+	case EventReady:
+		return "Ready."
+	case EventError:
+		return e.Err.Error()
+	default:
+		return string(e.Type)
+	}
+}
+
+// Output receives the output and the lifecycle events of the processes.
+type Output interface {
+	Line(l Line)
+	Event(e Event)
+}
+
+type multiOutput []Output
+
+// MultiOutput returns an output that passes the lines and events to all outputs.
+func MultiOutput(outs ...Output) Output {
+	return multiOutput(outs)
+}
+
+func (m multiOutput) Line(l Line) {
+	for _, out := range m {
+		out.Line(l)
+	}
+}
+
+func (m multiOutput) Event(e Event) {
+	for _, out := range m {
+		out.Event(e)
+	}
+}
+
+type consoleOutput struct {
+	out     io.Writer
+	padding int
+}
+
+// NewConsoleOutput returns an output that writes the lines and events
+// prefixed with the colored and padded process name.
+func NewConsoleOutput(out io.Writer, padding int) Output {
+	return &consoleOutput{
+		out:     out,
+		padding: padding,
+	}
+}
+
+func (o *consoleOutput) prefix(name string) string {
+	return coloredName(name+strings.Repeat(" ", max(o.padding-len(name), 0))) + " | "
+}
+
+func (o *consoleOutput) Line(l Line) {
+	prefix := o.prefix(l.Process)
+
+	logMx.Lock()
+	defer logMx.Unlock()
+
+	_, _ = fmt.Fprint(o.out, prefix, l.Text, "\x1b[0m\n")
+}
+
+func (o *consoleOutput) Event(e Event) {
+	if e.Type == EventStarted {
+		// the start is already logged
+		return
+	}
+
+	prefix := o.prefix(e.Process)
+
+	logMx.Lock()
+	defer logMx.Unlock()
+
+	if e.Type == EventError {
+		_, _ = fmt.Fprint(o.out, prefix, color.New(color.Reset, color.FgRed).Sprint(e.Message()), "\n")
+		return
+	}
+
+	_, _ = fmt.Fprint(o.out, prefix, color.New(color.Reset, color.Bold).Sprint(e.Message()), "\n")
+
+	if e.Err != nil {
+		_, _ = fmt.Fprint(o.out, prefix, color.New(color.Reset, color.FgRed).Sprint(e.Err.Error()), "\n")
+	}
+}
+
+// lineWriter splits the output of a process into lines.
+type lineWriter struct {
+	p      *Process
+	stream Stream
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			return len(p), nil
+		}
+
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush emits the remaining output that is not terminated by a newline.
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *lineWriter) emit(b []byte) {
+	b = bytes.TrimSuffix(b, []byte("\r"))
+
+	// replace yarn escape sequences
+	b = bytes.ReplaceAll(b, []byte("\x1b[2K"), nil)
+	b = bytes.ReplaceAll(b, []byte("\x1b[1G"), nil)
+
+	w.p.out.Line(Line{
+		Time:    time.Now(),
+		Process: w.p.Name,
+		Stream:  w.stream,
+		PID:     w.p.PID(),
+		Text:    string(b),
+	})
+}
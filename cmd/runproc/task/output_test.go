@@ -0,0 +1,93 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONOutput(t *testing.T) {
+	var buf bytes.Buffer
+	out := NewJSONOutput(&buf)
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	out.Line(Line{Time: ts, Process: "web", Stream: StreamStderr, PID: 42, Text: "oops"})
+	out.Event(Event{Time: ts, Process: "web", Type: EventExited, PID: 42, ExitCode: 1, Err: errors.New("exit status 1")})
+	out.Event(Event{Time: ts, Process: "web", Type: EventChanged, PID: 42, ExitCode: -1})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	assert.JSONEq(t, `{"ts":"2024-01-02T03:04:05Z","process":"web","stream":"stderr","line":"oops","pid":42}`, lines[0])
+	assert.JSONEq(t, `{"ts":"2024-01-02T03:04:05Z","process":"web","event":"exited","pid":42,"exit_code":1,"error":"exit status 1"}`, lines[1])
+	assert.JSONEq(t, `{"ts":"2024-01-02T03:04:05Z","process":"web","event":"changed","pid":42}`, lines[2])
+
+	for _, l := range lines {
+		assert.True(t, json.Valid([]byte(l)))
+	}
+}
+
+func TestFileOutputRotate(t *testing.T) {
+	dir := t.TempDir()
+
+	out, err := NewFileOutput(dir, WithMaxSize(100), WithMaxFiles(2))
+	require.NoError(t, err)
+
+	for range 20 {
+		out.Line(Line{Time: time.Now(), Process: "web", Stream: StreamStdout, Text: "0123456789"})
+	}
+	out.Event(Event{Time: time.Now(), Process: "worker", Type: EventStarting})
+
+	require.NoError(t, out.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+
+		info, err := os.Stat(f)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(100))
+	}
+
+	assert.ElementsMatch(t, []string{"web.log", "web.log.1", "web.log.2", "worker.log"}, names)
+
+	data, err := os.ReadFile(filepath.Join(dir, "worker.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "[event] Starting...")
+}
+
+func TestFileOutputName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+
+	out, err := NewFileOutput(dir)
+	require.NoError(t, err)
+
+	for _, name := range []string{"../web", "api/v1", "..", "/etc/passwd"} {
+		out.Line(Line{Time: time.Now(), Process: name, Stream: StreamStdout, Text: "hello"})
+	}
+	require.NoError(t, out.Close())
+
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(dir), "*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{dir}, files, "no files are written outside of the directory")
+
+	files, err = filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	assert.ElementsMatch(t, []string{".._web.log", "api_v1.log", "...log", "_etc_passwd.log"}, names)
+}
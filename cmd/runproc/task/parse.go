@@ -4,7 +4,11 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"net/url"
 	"strings"
+	"time"
+
+	"github.com/zeiss/pkg/waitfor"
 )
 
 func isAlphaNum(r byte) bool {
@@ -19,6 +23,17 @@ func isAlphaNum(r byte) bool {
 	return true
 }
 
+// splitOption splits an option into its name and value,
+// the value is separated by '=' (@dir=path) or a space (@env KEY=VALUE).
+func splitOption(str string) (string, string, bool) {
+	i := strings.IndexAny(str, "= \t")
+	if i == -1 {
+		return str, "", false
+	}
+
+	return str[:i], strings.TrimSpace(str[i+1:]), true
+}
+
 func Parse(r io.Reader) ([]Task, error) {
 	s := bufio.NewScanner(r)
 	var tasks []Task
@@ -33,17 +48,9 @@ func Parse(r io.Reader) ([]Task, error) {
 		}
 		if str[0] == '@' {
 			// parameter
-			parts := strings.SplitN(str[1:], "=", 2)
-			switch strings.TrimSpace(parts[0]) {
-			case "oneshot":
-				t.OneShot = true
-			case "watch-file":
-				if len(parts) != 2 {
-					return nil, fmt.Errorf("line %d: missing file path for watch-file", line)
-				}
-				t.WatchFiles = append(t.WatchFiles, parts[1])
-			default:
-				return nil, fmt.Errorf("line %d: invalid option '%s'", line, parts[0])
+			name, value, ok := splitOption(str[1:])
+			if err := parseOption(&t, name, value, ok, line); err != nil {
+				return nil, err
 			}
 		}
 		if !isAlphaNum(str[0]) {
@@ -57,8 +64,145 @@ func Parse(r io.Reader) ([]Task, error) {
 		}
 		t.Name = strings.TrimSpace(parts[0])
 		t.Command = strings.TrimSpace(parts[1])
+		t.line = line
 		tasks = append(tasks, t)
 		t = Task{}
 	}
-	return tasks, s.Err()
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := validate(tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+//nolint:gocyclo
+func parseOption(t *Task, name, value string, hasValue bool, line int) error {
+	if !hasValue || value == "" {
+		switch name {
+		case "oneshot":
+			t.OneShot = true
+			return nil
+		case "watch-poll":
+			t.WatchPoll = true
+			return nil
+		case "watch-file", "watch-exclude", "watch-debounce", "env-file", "env", "dir", "depends-on", "ready", "restart":
+			return fmt.Errorf("line %d: missing value for %s", line, name)
+		}
+	}
+
+	switch name {
+	case "watch-file":
+		t.WatchFiles = append(t.WatchFiles, value)
+	case "watch-exclude":
+		t.WatchExclude = append(t.WatchExclude, value)
+	case "watch-debounce":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid watch-debounce '%s': %w", line, value, err)
+		}
+		t.WatchDebounce = d
+	case "env-file":
+		t.EnvFiles = append(t.EnvFiles, value)
+	case "env":
+		k, _, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return fmt.Errorf("line %d: invalid env '%s' (expected KEY=VALUE)", line, value)
+		}
+		t.Env = append(t.Env, value)
+	case "dir":
+		t.Dir = value
+	case "depends-on":
+		for dep := range strings.SplitSeq(value, ",") {
+			if dep = strings.TrimSpace(dep); dep != "" {
+				t.DependsOn = append(t.DependsOn, dep)
+				t.dependsOnLines = append(t.dependsOnLines, line)
+			}
+		}
+	case "ready":
+		u, err := url.Parse(value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid ready probe '%s': %w", line, value, err)
+		}
+		if _, ok := probes.Lookup(u.Scheme); !ok {
+			return fmt.Errorf("line %d: unsupported ready probe scheme %q", line, u.Scheme)
+		}
+		t.Ready = value
+	case "restart":
+		switch p := RestartPolicy(value); p {
+		case RestartOnFailure, RestartAlways:
+			t.Restart = p
+		case "never", "no":
+			t.Restart = RestartNever
+		default:
+			return fmt.Errorf("line %d: invalid restart policy '%s'", line, value)
+		}
+	default:
+		return fmt.Errorf("line %d: invalid option '%s'", line, name)
+	}
+
+	return nil
+}
+
+// probes are the wait functions for the ready probes by their scheme.
+var probes = waitfor.DefaultRegistry()
+
+// validate checks that all dependencies exist and do not form a cycle.
+func validate(tasks []Task) error {
+	index := make(map[string]int, len(tasks))
+	for i, t := range tasks {
+		if j, ok := index[t.Name]; ok {
+			return fmt.Errorf("line %d: duplicate proc '%s' (first defined on line %d)", t.line, t.Name, tasks[j].line)
+		}
+		index[t.Name] = i
+	}
+
+	for _, t := range tasks {
+		for i, dep := range t.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return fmt.Errorf("line %d: unknown proc '%s' in depends-on of '%s'", t.dependsOnLines[i], dep, t.Name)
+			}
+		}
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make([]int, len(tasks))
+
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		t := tasks[i]
+		path = append(path, t.Name)
+
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("line %d: dependency cycle %s", t.line, strings.Join(path, " -> "))
+		}
+
+		state[i] = visiting
+		for _, dep := range t.DependsOn {
+			if err := visit(index[dep], path); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+
+		return nil
+	}
+
+	for i := range tasks {
+		if err := visit(i, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,105 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	procfile := `
+# database
+@ready=tcp://localhost:5432
+db: postgres
+
+@oneshot
+@env-file=.env
+@env DATABASE_URL=postgres://localhost:5432/app
+@depends-on=db
+migrate: make migrate
+
+@dir=./api
+@depends-on=db, migrate
+@restart=on-failure
+@watch-file=**/*.go
+api: go run .
+`
+
+	tasks, err := Parse(strings.NewReader(procfile))
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+
+	assert.Equal(t, "db", tasks[0].Name)
+	assert.Equal(t, "tcp://localhost:5432", tasks[0].Ready)
+
+	assert.Equal(t, "migrate", tasks[1].Name)
+	assert.True(t, tasks[1].OneShot)
+	assert.Equal(t, []string{".env"}, tasks[1].EnvFiles)
+	assert.Equal(t, []string{"DATABASE_URL=postgres://localhost:5432/app"}, tasks[1].Env)
+	assert.Equal(t, []string{"db"}, tasks[1].DependsOn)
+
+	assert.Equal(t, "api", tasks[2].Name)
+	assert.Equal(t, "go run .", tasks[2].Command)
+	assert.Equal(t, "./api", tasks[2].Dir)
+	assert.Equal(t, []string{"db", "migrate"}, tasks[2].DependsOn)
+	assert.Equal(t, RestartOnFailure, tasks[2].Restart)
+	assert.Equal(t, []string{"**/*.go"}, tasks[2].WatchFiles)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		procfile string
+		err      string
+	}{
+		{
+			name:     "unknown option",
+			procfile: "@foo=bar\na: true",
+			err:      "line 1: invalid option 'foo'",
+		},
+		{
+			name:     "missing value",
+			procfile: "@dir=\na: true",
+			err:      "line 1: missing value for dir",
+		},
+		{
+			name:     "invalid env",
+			procfile: "@env FOO\na: true",
+			err:      "line 1: invalid env 'FOO' (expected KEY=VALUE)",
+		},
+		{
+			name:     "invalid restart",
+			procfile: "@restart=sometimes\na: true",
+			err:      "line 1: invalid restart policy 'sometimes'",
+		},
+		{
+			name:     "unsupported probe",
+			procfile: "@ready=ftp://localhost\na: true",
+			err:      `line 1: unsupported ready probe scheme "ftp"`,
+		},
+		{
+			name:     "unknown dependency",
+			procfile: "a: true\n\n@depends-on=b\nc: true",
+			err:      "line 3: unknown proc 'b' in depends-on of 'c'",
+		},
+		{
+			name:     "cycle",
+			procfile: "@depends-on=c\na: true\n\n@depends-on=a\nb: true\n\n@depends-on=b\nc: true",
+			err:      "line 2: dependency cycle a -> c -> b -> a",
+		},
+		{
+			name:     "duplicate",
+			procfile: "a: true\na: false",
+			err:      "line 2: duplicate proc 'a' (first defined on line 1)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.procfile))
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
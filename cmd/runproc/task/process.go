@@ -2,10 +2,10 @@ package task
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -17,14 +17,22 @@ import (
 type Process struct {
 	Task
 
-	p   io.Writer
-	cmd *exec.Cmd
-	pty *os.File
+	out    Output
+	stdout *lineWriter
+	stderr *lineWriter
+	cmd    *exec.Cmd
+	pty    *os.File
+	copied chan struct{}
 
 	state chan ProcessState
 
 	result bool
 	exited chan struct{}
+
+	mu       sync.Mutex
+	pid      int
+	started  time.Time
+	exitCode int
 }
 
 type ProcessState int
@@ -39,6 +47,28 @@ const (
 	ProcessStateExited
 )
 
+// String returns the name of the process state.
+func (s ProcessState) String() string {
+	switch s {
+	case ProcessStateIdle:
+		return "idle"
+	case ProcessStateStarting:
+		return "starting"
+	case ProcessStateRunning:
+		return "running"
+	case ProcessStateStopping:
+		return "stopping"
+	case ProcessStateKilling:
+		return "killing"
+	case ProcessStateRestarting:
+		return "restarting"
+	case ProcessStateExited:
+		return "exited"
+	default:
+		return fmt.Sprintf("state(%d)", int(s))
+	}
+}
+
 var colors = []color.Attribute{
 	color.FgGreen,
 	color.FgYellow,
@@ -71,33 +101,68 @@ func coloredName(pName string) string {
 	return color.New(color.Reset, c).Sprint(pName)
 }
 
-func NewProcess(t Task, padding int) *Process {
-	pName := t.Name + strings.Repeat(" ", padding-len(t.Name))
-	pName = coloredName(pName)
+func NewProcess(t Task, out Output) *Process {
 	stateCh := make(chan ProcessState, 1)
 	stateCh <- ProcessStateIdle
-	return &Process{
-		Task:   t,
-		p:      NewPrefixer(os.Stdout, pName),
-		state:  stateCh,
-		exited: make(chan struct{}),
+	p := &Process{
+		Task:     t,
+		out:      out,
+		state:    stateCh,
+		exited:   make(chan struct{}),
+		exitCode: -1,
 	}
+	p.stdout = &lineWriter{p: p, stream: StreamStdout}
+	p.stderr = &lineWriter{p: p, stream: StreamStderr}
+
+	return p
 }
 
 var _ ProcessRunner = (*Process)(nil)
 
 var logMx sync.Mutex
 
+func (p *Process) event(typ EventType, err error) {
+	p.mu.Lock()
+	e := Event{
+		Time:     time.Now(),
+		Process:  p.Name,
+		Type:     typ,
+		PID:      p.pid,
+		ExitCode: p.exitCode,
+		Err:      err,
+	}
+	p.mu.Unlock()
+
+	p.out.Event(e)
+}
+
 func (p *Process) logError(err error) {
-	logMx.Lock()
-	defer logMx.Unlock()
-	color.New(color.Reset, color.FgRed).Fprintln(p.p, err.Error())
+	p.event(EventError, err)
 }
 
-func (p *Process) logAction(s string) {
-	logMx.Lock()
-	defer logMx.Unlock()
-	color.New(color.Reset, color.Bold).Fprintln(p.p, s)
+// PID returns the id of the process, or zero before it has been started.
+func (p *Process) PID() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.pid
+}
+
+// Info returns a snapshot of the state of the process.
+func (p *Process) Info() ProcessInfo {
+	s := <-p.state
+	p.state <- s
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return ProcessInfo{
+		Name:     p.Name,
+		State:    s,
+		PID:      p.pid,
+		Started:  p.started,
+		ExitCode: p.exitCode,
+	}
 }
 
 func (p *Process) Stop() {
@@ -108,7 +173,7 @@ func (p *Process) Stop() {
 		return
 	}
 
-	p.logAction("Stopping...")
+	p.event(EventStopping, nil)
 	go p.gracefulTerm()
 	p.state <- ProcessStateStopping
 
@@ -141,10 +206,11 @@ func (p *Process) Kill() {
 	switch s {
 	case ProcessStateStopping, ProcessStateRunning:
 	default:
+		p.state <- s
 		return
 	}
 
-	p.logAction("Killing...")
+	p.event(EventKilling, nil)
 
 	// The process may have already terminated and we can ignore the error.
 	_ = p.cmd.Process.Kill()
@@ -154,7 +220,14 @@ func (p *Process) Kill() {
 }
 
 func (p *Process) run() error {
+	env, err := p.environ()
+	if err != nil {
+		return err
+	}
+
 	p.cmd = exec.Command("sh", "-ce", p.Command) //nolint:gosec,noctx
+	p.cmd.Dir = p.Dir
+	p.cmd.Env = env
 
 	ptty, tty, err := pty.Open()
 	if err == nil {
@@ -169,29 +242,50 @@ func (p *Process) run() error {
 		p.cmd.Stderr = tty
 		p.cmd.Stdin = tty
 		err = p.cmd.Start()
+		// the terminal is closed once the process and its children closed it
+		tty.Close()
 		if err == nil {
-			go p.copyIO(p.p, p.pty)
+			p.copied = make(chan struct{})
+			go func() {
+				defer close(p.copied)
+				p.copyIO(p.stdout, p.pty)
+			}()
 		} else {
 			p.pty.Close()
 		}
 	} else if errors.Is(err, pty.ErrUnsupported) {
 		p.pty = nil
-		p.cmd.Stdout = p.p
-		p.cmd.Stderr = p.p
+		p.cmd.Stdout = p.stdout
+		p.cmd.Stderr = p.stderr
 		err = p.cmd.Start()
 	}
 	if err != nil {
 		return err
 	}
 
+	p.mu.Lock()
+	p.pid = p.cmd.Process.Pid
+	p.started = time.Now()
+	p.mu.Unlock()
+
 	go func() {
 		err := p.cmd.Wait()
+		if p.pty != nil {
+			p.drain()
+		}
 		<-p.state
 		p.result = err == nil
-		p.logAction("Exited.")
-		if err != nil {
-			p.logError(err)
+
+		p.mu.Lock()
+		p.exitCode = p.cmd.ProcessState.ExitCode()
+		p.mu.Unlock()
+
+		if p.pty == nil {
+			p.stdout.Flush()
+			p.stderr.Flush()
 		}
+
+		p.event(EventExited, err)
 		close(p.exited)
 		p.state <- ProcessStateExited
 	}()
@@ -206,7 +300,7 @@ func (p *Process) Start() {
 		return
 	}
 
-	p.logAction("Starting...")
+	p.event(EventStarting, nil)
 	err := p.run()
 	if err != nil {
 		p.logError(err)
@@ -215,6 +309,8 @@ func (p *Process) Start() {
 		return
 	}
 
+	p.event(EventStarted, nil)
+
 	p.state <- ProcessStateRunning
 }
 
@@ -233,9 +329,29 @@ func (p *Process) Done() bool {
 	}
 }
 
-func (p *Process) copyIO(dst io.Writer, src io.Reader) {
+func (p *Process) copyIO(dst *lineWriter, src io.Reader) {
 	_, err := io.Copy(dst, src)
-	if err != nil {
+	dst.Flush()
+
+	// reading the terminal fails once the process exited
+	if err != nil && !errors.Is(err, syscall.EIO) && !errors.Is(err, os.ErrClosed) {
 		p.logError(err)
 	}
 }
+
+// outputDrainTimeout is the time to wait for the output of an exited process,
+// children that outlive it can keep its terminal open.
+const outputDrainTimeout = time.Second
+
+// drain waits until the output of the exited process has been copied and closes its terminal.
+func (p *Process) drain() {
+	t := time.NewTimer(outputDrainTimeout)
+	defer t.Stop()
+
+	select {
+	case <-p.copied:
+	case <-t.C:
+	}
+
+	_ = p.pty.Close()
+}
@@ -1,9 +1,28 @@
 package task
 
+import "time"
+
 type ProcessRunner interface {
 	Start()
 	Stop()
 	Kill()
 	Done() bool
 	Wait() bool
+	Info() ProcessInfo
+}
+
+// ProcessInfo is a snapshot of the state of a process.
+type ProcessInfo struct {
+	// Name is the name of the task.
+	Name string
+	// State is the state of the process.
+	State ProcessState
+	// PID is the id of the current process, or zero before it has been started.
+	PID int
+	// Started is the time the current process has been started.
+	Started time.Time
+	// Restarts is the number of times the process has been restarted.
+	Restarts int
+	// ExitCode is the exit code of the last process, or -1 if it has not exited.
+	ExitCode int
 }
@@ -1,39 +1,49 @@
 package task
 
 import (
+	"sync"
 	"time"
 )
 
 type ProcessWatcher struct {
-	p *Process
-
-	t       Task
-	padding int
+	t   Task
+	out Output
 
 	state chan ProcessState
 
-	exitCh chan struct{}
-	result bool
+	mu       sync.Mutex
+	p        *Process
+	exitCh   chan struct{}
+	result   bool
+	restarts int
 }
 
-func NewProcessWatcher(t Task, padding int) *ProcessWatcher {
+// restartDelay is the time to wait before a process is restarted by its restart policy.
+const restartDelay = time.Second
+
+var _ ProcessRunner = (*ProcessWatcher)(nil)
+
+func NewProcessWatcher(t Task, out Output) *ProcessWatcher {
 	stateCh := make(chan ProcessState, 1)
 	stateCh <- ProcessStateIdle
 	return &ProcessWatcher{
-		t:       t,
-		padding: padding,
-		state:   stateCh,
-		exitCh:  make(chan struct{}),
+		t:      t,
+		out:    out,
+		state:  stateCh,
+		exitCh: make(chan struct{}),
 	}
 }
 
 func (w *ProcessWatcher) Start() {
 	s := <-w.state
 	if s != ProcessStateIdle {
+		w.state <- s
 		return
 	}
 
-	go w.watch()
+	if len(w.t.WatchFiles) > 0 {
+		go w.watch(w.exited())
+	}
 
 	w.state <- ProcessStateStarting
 	w._start()
@@ -45,8 +55,7 @@ func (w *ProcessWatcher) Stop() {
 	switch s {
 	case ProcessStateRestarting, ProcessStateRunning:
 	case ProcessStateIdle:
-		w.state <- ProcessStateExited
-		close(w.exitCh)
+		w.exit(false)
 		return
 	default:
 		w.state <- s
@@ -54,7 +63,7 @@ func (w *ProcessWatcher) Stop() {
 	}
 
 	w.state <- ProcessStateStopping
-	w.p.Stop()
+	w.process().Stop()
 }
 
 func (w *ProcessWatcher) Kill() {
@@ -63,8 +72,7 @@ func (w *ProcessWatcher) Kill() {
 	switch s {
 	case ProcessStateRestarting, ProcessStateRunning, ProcessStateStopping:
 	case ProcessStateIdle:
-		w.state <- ProcessStateExited
-		close(w.exitCh)
+		w.exit(false)
 		return
 	default:
 		w.state <- s
@@ -72,7 +80,77 @@ func (w *ProcessWatcher) Kill() {
 	}
 
 	w.state <- ProcessStateKilling
-	w.p.Kill()
+	w.process().Kill()
+}
+
+// Restart stops the running process and starts it again,
+// a process that has exited is started again.
+func (w *ProcessWatcher) Restart() {
+	s := <-w.state
+	// nolint:exhaustive
+	switch s {
+	case ProcessStateRunning:
+		w.event(EventRestarting)
+		w.state <- ProcessStateRestarting
+		w.process().Stop()
+	case ProcessStateExited:
+		w.mu.Lock()
+		w.exitCh = make(chan struct{})
+		w.result = false
+		w.restarts++
+		w.mu.Unlock()
+
+		if len(w.t.WatchFiles) > 0 {
+			go w.watch(w.exited())
+		}
+
+		w.event(EventRestarting)
+		w.state <- ProcessStateStarting
+		w._start()
+	default:
+		w.state <- s
+	}
+}
+
+// exit marks the process as exited, the state must be held by the caller.
+func (w *ProcessWatcher) exit(result bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.result = result
+	w.state <- ProcessStateExited
+	close(w.exitCh)
+}
+
+func (w *ProcessWatcher) exited() chan struct{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.exitCh
+}
+
+func (w *ProcessWatcher) process() *Process {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.p
+}
+
+func (w *ProcessWatcher) event(typ EventType) {
+	e := Event{
+		Time:     time.Now(),
+		Process:  w.t.Name,
+		Type:     typ,
+		ExitCode: -1,
+	}
+
+	if p := w.process(); p != nil {
+		info := p.Info()
+		e.PID = info.PID
+		e.ExitCode = info.ExitCode
+	}
+
+	w.out.Event(e)
 }
 
 func (w *ProcessWatcher) _start() {
@@ -80,46 +158,78 @@ func (w *ProcessWatcher) _start() {
 	// nolint:exhaustive
 	switch s {
 	case ProcessStateStarting, ProcessStateRestarting:
+	case ProcessStateStopping, ProcessStateKilling:
+		// stopped while waiting to be restarted
+		w.exit(false)
+		return
 	default:
 		w.state <- s
 		return
 	}
 
-	proc := NewProcess(w.t, w.padding)
+	proc := NewProcess(w.t, w.out)
 	proc.Start()
+
+	w.mu.Lock()
 	w.p = proc
+	w.mu.Unlock()
 
 	w.state <- ProcessStateRunning
 	go func() {
 		result := proc.Wait()
 		s := <-w.state
 		if s == ProcessStateRestarting {
+			w.mu.Lock()
+			w.restarts++
+			w.mu.Unlock()
+
 			w.state <- ProcessStateRestarting
 			go w._start()
 			return
 		}
 
-		w.result = result
-		w.state <- ProcessStateExited
-		close(w.exitCh)
+		if s == ProcessStateRunning && w.shouldRestart(proc) {
+			w.mu.Lock()
+			w.restarts++
+			w.mu.Unlock()
+
+			w.event(EventRestarting)
+			w.state <- ProcessStateRestarting
+			time.AfterFunc(restartDelay, w._start)
+			return
+		}
+
+		w.exit(result)
 	}()
 }
 
-func (w *ProcessWatcher) watch() {
-	wt := Watch(w.t.WatchFiles)
+func (w *ProcessWatcher) shouldRestart(proc *Process) bool {
+	// nolint:exhaustive
+	switch w.t.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return !proc.result
+	default:
+		return false
+	}
+}
 
-	t := time.NewTicker(100 * time.Millisecond)
-	defer t.Stop()
+func (w *ProcessWatcher) watch(exited <-chan struct{}) {
+	wt := NewWatcher(WatchOptions{
+		Patterns:  w.t.WatchFiles,
+		Exclude:   w.t.WatchExclude,
+		GitIgnore: true,
+		Debounce:  w.t.WatchDebounce,
+		Poll:      w.t.WatchPoll,
+	})
+	defer wt.Close()
 
 	for {
 		select {
-		case <-w.exitCh:
+		case <-exited:
 			return
-		case <-t.C:
-		}
-
-		if !wt.Changed() {
-			continue
+		case <-wt.Changes():
 		}
 
 		w._restart()
@@ -133,10 +243,10 @@ func (w *ProcessWatcher) _restart() {
 		return
 	}
 
-	w.p.logAction("Change detected, restarting...")
+	w.event(EventChanged)
 
 	w.state <- ProcessStateRestarting
-	w.p.Stop()
+	w.process().Stop()
 }
 
 func (w *ProcessWatcher) Done() bool {
@@ -146,6 +256,32 @@ func (w *ProcessWatcher) Done() bool {
 }
 
 func (w *ProcessWatcher) Wait() bool {
-	<-w.exitCh
+	<-w.exited()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	return w.result
 }
+
+// Info returns a snapshot of the state of the process.
+func (w *ProcessWatcher) Info() ProcessInfo {
+	s := <-w.state
+	w.state <- s
+
+	info := ProcessInfo{Name: w.t.Name, State: s, ExitCode: -1}
+
+	if p := w.process(); p != nil {
+		pi := p.Info()
+		info.PID = pi.PID
+		info.Started = pi.Started
+		info.ExitCode = pi.ExitCode
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	info.Restarts = w.restarts
+
+	return info
+}
@@ -2,7 +2,11 @@ package task
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/url"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -11,63 +15,305 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
+// ErrUnknownProcess is returned when a process is not part of the runner.
+var ErrUnknownProcess = errors.New("unknown process")
+
+// RunnerOpts are the options for the runner.
+type RunnerOpts struct {
+	// Output receives the output and the lifecycle events of the processes.
+	// It defaults to the prefixed output on stdout.
+	Output Output
+	// Observers receive the output and the lifecycle events in addition to the output.
+	Observers []Output
+	// KeepAlive keeps the runner running after all processes exited,
+	// until it is stopped or its context is canceled.
+	KeepAlive bool
+}
+
+// Configure is a method that configures the runner options.
+func (o *RunnerOpts) Configure(opts ...RunnerOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// RunnerOpt is a function that configures the runner options.
+type RunnerOpt func(*RunnerOpts)
+
+// WithOutput is setting the output of the processes.
+func WithOutput(out Output) RunnerOpt {
+	return func(opts *RunnerOpts) {
+		opts.Output = out
+	}
+}
+
+// WithObserver is adding an output that receives the output of the processes in addition to the output.
+func WithObserver(out Output) RunnerOpt {
+	return func(opts *RunnerOpts) {
+		opts.Observers = append(opts.Observers, out)
+	}
+}
+
+// WithKeepAlive is keeping the runner running after all processes exited.
+func WithKeepAlive() RunnerOpt {
+	return func(opts *RunnerOpts) {
+		opts.KeepAlive = true
+	}
+}
+
 type Runner struct {
-	procs []ProcessRunner
+	tasks []Task
+	procs []*ProcessWatcher
+	opts  *RunnerOpts
 
 	doneCh chan struct{}
 	stopCh chan struct{}
 
+	mu       sync.Mutex
 	stopOnce sync.Once
 }
 
-func NewRunner(tasks []Task) *Runner {
-	procs := make([]ProcessRunner, 0, len(tasks))
-	logName := "RUNPROC"
-	maxLen := len(logName)
-	for _, t := range tasks {
-		if len(t.Name) > maxLen {
-			maxLen = len(t.Name)
+func NewRunner(tasks []Task, opts ...RunnerOpt) *Runner {
+	options := new(RunnerOpts)
+	options.Configure(opts...)
+
+	if options.Output == nil {
+		logName := "RUNPROC"
+		maxLen := len(logName)
+		for _, t := range tasks {
+			if len(t.Name) > maxLen {
+				maxLen = len(t.Name)
+			}
 		}
+
+		if maxLen > len(logName) {
+			logName += strings.Repeat(" ", maxLen-len(logName))
+		}
+		log.SetOutput(&prefixer{
+			out:    log.Default().Writer(),
+			prefix: color.New(color.BgRed, color.FgWhite).Sprint(logName),
+		})
+
+		options.Output = NewConsoleOutput(os.Stdout, maxLen)
 	}
 
-	if maxLen > len(logName) {
-		logName += strings.Repeat(" ", maxLen-len(logName))
+	if len(options.Observers) > 0 {
+		options.Output = MultiOutput(append([]Output{options.Output}, options.Observers...)...)
 	}
-	log.SetOutput(&prefixer{
-		out:    log.Default().Writer(),
-		prefix: color.New(color.BgRed, color.FgWhite).Sprint(logName),
-	})
-	for _, t := range tasks {
-		if len(t.WatchFiles) > 0 {
-			procs = append(procs, NewProcessWatcher(t, maxLen))
-			continue
-		}
 
-		procs = append(procs, NewProcess(t, maxLen))
+	procs := make([]*ProcessWatcher, 0, len(tasks))
+	for _, t := range tasks {
+		procs = append(procs, NewProcessWatcher(t, options.Output))
 	}
+
 	return &Runner{
+		tasks:  tasks,
 		procs:  procs,
+		opts:   options,
 		stopCh: make(chan struct{}),
 		doneCh: make(chan struct{}),
 	}
 }
 
+// Run starts the processes once their dependencies are ready
+// and waits for all of them to exit. The processes are stopped when the context is canceled.
 func (r *Runner) Run(ctx context.Context) error {
-	g, _ := errgroup.WithContext(ctx)
+	// the group has no context, a runner that is kept alive must not stop once all processes exited
+	var g errgroup.Group
 	result := make(chan bool, len(r.procs))
 
-	for _, proc := range r.procs {
+	ready := make(map[string]chan struct{}, len(r.tasks))
+	failed := make(map[string]chan struct{}, len(r.tasks))
+	for _, t := range r.tasks {
+		ready[t.Name] = make(chan struct{})
+		failed[t.Name] = make(chan struct{})
+	}
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.Stop()
+		case <-stopped:
+		}
+	}()
+
+	for i, proc := range r.procs {
+		t := r.tasks[i]
+
 		g.Go(func() error {
+			for _, dep := range t.DependsOn {
+				select {
+				case <-ready[dep]:
+				case <-failed[dep]:
+					// dependents of a skipped process are skipped as well
+					r.event(t.Name, EventError, fmt.Errorf("skipped, dependency %s failed", dep))
+					close(failed[t.Name])
+					return nil
+				case <-r.stopCh:
+					return nil
+				}
+			}
+
 			proc.Start()
+			go r.probe(t, proc, ready[t.Name], failed[t.Name])
+
 			result <- proc.Wait()
 			return nil
 		})
 	}
 
-	if err := g.Wait(); err != nil {
+	err := g.Wait()
+
+	if r.opts.KeepAlive {
+		// processes can be restarted until the runner is stopped
+		<-r.stopCh
+
+		for _, proc := range r.procs {
+			proc.Wait()
+		}
+	}
+
+	close(r.doneCh)
+
+	return err
+}
+
+// probe closes the ready channel once the process is ready, or the failed channel
+// if it will not become ready. A process without a ready probe is ready once it has been started,
+// a oneshot process once it exited successfully.
+func (r *Runner) probe(t Task, proc ProcessRunner, ready, failed chan struct{}) {
+	if t.Ready == "" && t.OneShot {
+		if proc.Wait() {
+			r.event(t.Name, EventReady, nil)
+			close(ready)
+			return
+		}
+
+		r.event(t.Name, EventError, errors.New("exited unsuccessfully"))
+		close(failed)
+		return
+	}
+
+	if t.Ready == "" {
+		close(ready)
+		return
+	}
+
+	u, err := url.Parse(t.Ready)
+	if err != nil {
+		r.event(t.Name, EventError, err)
+		close(failed)
+		return
+	}
+	fn, _ := probes.Lookup(u.Scheme)
+
+	tick := time.NewTicker(probeInterval)
+	defer tick.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		err := fn(ctx, t.Ready)
+		cancel()
+		if err == nil {
+			r.event(t.Name, EventReady, nil)
+			close(ready)
+			return
+		}
+
+		select {
+		case <-r.stopCh:
+			return
+		case <-tick.C:
+		}
+
+		if proc.Done() {
+			r.event(t.Name, EventError, errors.New("exited before it was ready"))
+			close(failed)
+			return
+		}
+	}
+}
+
+const (
+	probeInterval = 500 * time.Millisecond
+	probeTimeout  = 5 * time.Second
+)
+
+func (r *Runner) event(name string, typ EventType, err error) {
+	r.opts.Output.Event(Event{
+		Time:     time.Now(),
+		Process:  name,
+		Type:     typ,
+		ExitCode: -1,
+		Err:      err,
+	})
+}
+
+// Processes returns a snapshot of the state of the processes.
+func (r *Runner) Processes() []ProcessInfo {
+	infos := make([]ProcessInfo, 0, len(r.procs))
+	for _, proc := range r.procs {
+		infos = append(infos, proc.Info())
+	}
+
+	return infos
+}
+
+func (r *Runner) process(name string) (*ProcessWatcher, error) {
+	for i, t := range r.tasks {
+		if t.Name == name {
+			return r.procs[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownProcess, name)
+}
+
+// RestartProcess restarts the process, or starts it again if it has exited.
+func (r *Runner) RestartProcess(name string) error {
+	proc, err := r.process(name)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.stopCh:
+		return nil
+	default:
+	}
+
+	proc.Restart()
+
+	return nil
+}
+
+// StopProcess stops the process gracefully.
+func (r *Runner) StopProcess(name string) error {
+	proc, err := r.process(name)
+	if err != nil {
+		return err
+	}
+
+	proc.Stop()
+
+	return nil
+}
+
+// KillProcess kills the process.
+func (r *Runner) KillProcess(name string) error {
+	proc, err := r.process(name)
+	if err != nil {
 		return err
 	}
 
+	proc.Kill()
+
 	return nil
 }
 
@@ -76,7 +322,9 @@ func (r *Runner) Stop() {
 }
 
 func (r *Runner) _stop() {
+	r.mu.Lock()
 	close(r.stopCh)
+	r.mu.Unlock()
 
 	for _, proc := range r.procs {
 		go proc.Stop()
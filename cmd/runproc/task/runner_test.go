@@ -0,0 +1,177 @@
+package task
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	lines  []Line
+	events []Event
+}
+
+func (r *recorder) Line(l Line) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lines = append(r.lines, l)
+}
+
+func (r *recorder) Event(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) texts(name string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var texts []string
+	for _, l := range r.lines {
+		if l.Process == name {
+			texts = append(texts, l.Text)
+		}
+	}
+
+	return texts
+}
+
+func (r *recorder) count(name string, typ EventType) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, e := range r.events {
+		if e.Process == name && e.Type == typ {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestRunnerOutput(t *testing.T) {
+	out := new(recorder)
+	r := NewRunner([]Task{{Name: "hello", Command: "echo hello; echo world"}}, WithOutput(out))
+
+	require.NoError(t, r.Run(context.Background()))
+
+	assert.Equal(t, []string{"hello", "world"}, out.texts("hello"))
+	assert.Equal(t, 1, out.count("hello", EventStarting))
+	assert.Equal(t, 1, out.count("hello", EventExited))
+
+	procs := r.Processes()
+	require.Len(t, procs, 1)
+	assert.Equal(t, ProcessStateExited, procs[0].State)
+	assert.Equal(t, 0, procs[0].ExitCode)
+	assert.NotZero(t, procs[0].PID)
+}
+
+func TestRunnerRestartProcess(t *testing.T) {
+	out := new(recorder)
+	r := NewRunner([]Task{
+		{Name: "once", Command: "echo once"},
+		{Name: "sleep", Command: "sleep 10"},
+	}, WithOutput(out), WithKeepAlive())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- r.Run(ctx) }()
+
+	exited := func(name string) func() bool {
+		return func() bool {
+			return slices.ContainsFunc(r.Processes(), func(p ProcessInfo) bool {
+				return p.Name == name && p.State == ProcessStateExited
+			})
+		}
+	}
+
+	require.Eventually(t, exited("once"), 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, r.RestartProcess("once"))
+	require.Eventually(t, func() bool { return len(out.texts("once")) == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, exited("once"), 5*time.Second, 10*time.Millisecond)
+
+	info := r.Processes()[0]
+	assert.Equal(t, 1, info.Restarts)
+	assert.Equal(t, 1, out.count("once", EventRestarting))
+
+	require.NoError(t, r.StopProcess("sleep"))
+	require.Eventually(t, exited("sleep"), 5*time.Second, 10*time.Millisecond)
+
+	require.ErrorIs(t, r.KillProcess("unknown"), ErrUnknownProcess)
+
+	select {
+	case <-errCh:
+		t.Fatal("runner returned before it was stopped")
+	default:
+	}
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
+func TestRunnerKeepAlive(t *testing.T) {
+	out := new(recorder)
+	r := NewRunner([]Task{
+		{Name: "a", Command: "echo a"},
+		{Name: "b", Command: "exit 1"},
+	}, WithOutput(out), WithKeepAlive())
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- r.Run(context.Background()) }()
+
+	require.Eventually(t, func() bool {
+		return out.count("a", EventExited) == 1 && out.count("b", EventExited) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	select {
+	case <-errCh:
+		t.Fatal("runner returned after all processes exited")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	r.Stop()
+	require.NoError(t, <-errCh)
+}
+
+func TestRunnerSkipsDependentsOfFailedProcesses(t *testing.T) {
+	out := new(recorder)
+	r := NewRunner([]Task{
+		{Name: "migrate", Command: "exit 1", OneShot: true},
+		{Name: "db", Command: "exit 0", Ready: "tcp://127.0.0.1:1"},
+		{Name: "api", Command: "echo api", DependsOn: []string{"migrate"}},
+		{Name: "worker", Command: "echo worker", DependsOn: []string{"api"}},
+		{Name: "web", Command: "echo web", DependsOn: []string{"db"}},
+	}, WithOutput(out))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- r.Run(context.Background()) }()
+
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("runner did not return")
+	}
+
+	for _, name := range []string{"api", "worker", "web"} {
+		assert.Empty(t, out.texts(name))
+		assert.Equal(t, 0, out.count(name, EventStarting))
+		assert.Equal(t, 1, out.count(name, EventError))
+	}
+
+	assert.Equal(t, 1, out.count("migrate", EventError))
+	assert.Equal(t, 1, out.count("db", EventError))
+}
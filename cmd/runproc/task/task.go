@@ -1,5 +1,19 @@
 package task
 
+import "time"
+
+// RestartPolicy is the policy to restart a process when it exits.
+type RestartPolicy string
+
+const (
+	// RestartNever never restarts the process.
+	RestartNever RestartPolicy = ""
+	// RestartOnFailure restarts the process when it exits with an error.
+	RestartOnFailure RestartPolicy = "on-failure"
+	// RestartAlways restarts the process whenever it exits.
+	RestartAlways RestartPolicy = "always"
+)
+
 type Task struct {
 	// Name used in logs
 	Name string
@@ -10,8 +24,41 @@ type Task struct {
 	// WatchFiles will cause the process to restart if any if the files change (can be patterns).
 	WatchFiles []string
 
+	// WatchExclude are patterns of files and directories that are not watched.
+	// Files ignored by .gitignore files are not watched either.
+	WatchExclude []string
+
+	// WatchDebounce is the time to wait for more changes before the process is restarted.
+	WatchDebounce time.Duration
+
+	// WatchPoll polls for changes instead of using inotify (e.g. for network file systems).
+	WatchPoll bool
+
 	// OneShot indicates that the process should run and then exit.
 	//
 	// Useful for running tests.
 	OneShot bool
+
+	// EnvFiles are files with KEY=VALUE lines that are added to the environment of the process.
+	EnvFiles []string
+
+	// Env are KEY=VALUE pairs that are added to the environment of the process.
+	Env []string
+
+	// Dir is the working directory of the process.
+	Dir string
+
+	// DependsOn are the names of the tasks that have to be ready before the process is started.
+	DependsOn []string
+
+	// Ready is the URL that is probed to check that the process is ready (e.g. tcp://localhost:5432).
+	Ready string
+
+	// Restart is the policy to restart the process when it exits.
+	Restart RestartPolicy
+
+	// line is the line of the proc definition.
+	line int
+	// dependsOnLines are the lines of the depends-on options.
+	dependsOnLines []int
 }
@@ -0,0 +1,341 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeiss/pkg/cmd/runproc/task"
+	"github.com/zeiss/pkg/duplex"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Controller controls the processes of the dashboard.
+type Controller interface {
+	Processes() []task.ProcessInfo
+	RestartProcess(name string) error
+	StopProcess(name string) error
+	KillProcess(name string) error
+}
+
+var (
+	headerStyle   = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	eventStyle    = lipgloss.NewStyle().Bold(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Red)
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.BrightBlack)
+
+	stateStyles = map[task.ProcessState]lipgloss.Style{
+		task.ProcessStateRunning:    lipgloss.NewStyle().Foreground(lipgloss.Green),
+		task.ProcessStateRestarting: lipgloss.NewStyle().Foreground(lipgloss.Yellow),
+		task.ProcessStateStopping:   lipgloss.NewStyle().Foreground(lipgloss.Yellow),
+		task.ProcessStateKilling:    lipgloss.NewStyle().Foreground(lipgloss.Red),
+		task.ProcessStateExited:     lipgloss.NewStyle().Foreground(lipgloss.BrightBlack),
+	}
+)
+
+const help = "↑/↓ select • pgup/pgdn scroll • / filter • r restart • s stop • k kill • q quit"
+
+type tickMsg time.Time
+
+type errMsg struct{ err error }
+
+type model struct {
+	ctrl  Controller
+	sink  *sink
+	store duplex.Duplexer[State]
+	state State
+
+	width, height int
+
+	selected int
+	// scroll is the number of lines the log is scrolled up from the bottom.
+	scroll    int
+	filter    string
+	filtering bool
+	err       error
+}
+
+func newModel(ctrl Controller, sink *sink) *model {
+	return &model{
+		ctrl:  ctrl,
+		sink:  sink,
+		store: duplex.New(State{Processes: ctrl.Processes()}, reduceProcesses, reduceLogs),
+		state: State{Processes: ctrl.Processes()},
+	}
+}
+
+// Init implements tea.Model.
+func (m *model) Init() tea.Cmd {
+	return tea.Batch(m.listen(), tick())
+}
+
+// listen waits for the output of the processes and dispatches it to the store.
+// The next output is only received after the state change has been handled, which keeps the order of the lines.
+func (m *model) listen() tea.Cmd {
+	return func() tea.Msg {
+		items := m.sink.next()
+		if items == nil {
+			return nil
+		}
+
+		return m.store.Dispatch(func() (duplex.Update, error) {
+			return batch{items: items, processes: m.ctrl.Processes()}, nil
+		})()
+	}
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// Update implements tea.Model.
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case duplex.StateChangeMsg[State]:
+		m.state = msg.Curr()
+		return m, m.listen()
+	case tickMsg:
+		return m, tick()
+	case errMsg:
+		m.err = msg.err
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tea.KeyPressMsg:
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
+
+		return m.updateKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m *model) updateFilter(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		m.filtering = false
+	case "esc":
+		m.filtering = false
+		m.filter = ""
+	case "backspace":
+		if r := []rune(m.filter); len(r) > 0 {
+			m.filter = string(r[:len(r)-1])
+		}
+	default:
+		m.filter += msg.Key().Text
+	}
+
+	m.scroll = 0
+
+	return m, nil
+}
+
+func (m *model) updateKey(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "up":
+		m.selected = max(m.selected-1, 0)
+		m.scroll = 0
+	case "down":
+		m.selected = min(m.selected+1, len(m.state.Processes)-1)
+		m.scroll = 0
+	case "pgup":
+		m.scroll += m.logHeight()
+	case "pgdown":
+		m.scroll = max(m.scroll-m.logHeight(), 0)
+	case "home":
+		m.scroll = len(m.lines())
+	case "end":
+		m.scroll = 0
+	case "/":
+		m.filtering = true
+	case "esc":
+		m.filter = ""
+		m.err = nil
+	case "r":
+		return m, m.control(m.ctrl.RestartProcess)
+	case "s":
+		return m, m.control(m.ctrl.StopProcess)
+	case "k":
+		return m, m.control(m.ctrl.KillProcess)
+	}
+
+	return m, nil
+}
+
+// control calls the function with the selected process, it blocks until the process has been stopped.
+func (m *model) control(fn func(name string) error) tea.Cmd {
+	name := m.selectedName()
+	if name == "" {
+		return nil
+	}
+
+	return func() tea.Msg {
+		if err := fn(name); err != nil {
+			return errMsg{err}
+		}
+
+		return nil
+	}
+}
+
+func (m *model) selectedName() string {
+	if m.selected < 0 || m.selected >= len(m.state.Processes) {
+		return ""
+	}
+
+	return m.state.Processes[m.selected].Name
+}
+
+// lines returns the scrollback of the selected process that matches the filter.
+func (m *model) lines() []entry {
+	entries := m.state.Logs[m.selectedName()]
+	if m.filter == "" {
+		return entries
+	}
+
+	filter := strings.ToLower(m.filter)
+	matches := make([]entry, 0, len(entries))
+
+	for _, e := range entries {
+		if strings.Contains(strings.ToLower(ansi.Strip(e.text)), filter) {
+			matches = append(matches, e)
+		}
+	}
+
+	return matches
+}
+
+// logHeight is the height of the log pane, without the process list, the separator and the status line.
+func (m *model) logHeight() int {
+	return max(m.height-len(m.state.Processes)-3, 1)
+}
+
+// View implements tea.Model.
+func (m *model) View() tea.View {
+	var b strings.Builder
+
+	m.viewProcesses(&b)
+	m.viewLogs(&b)
+	m.viewStatus(&b)
+
+	v := tea.NewView(b.String())
+	v.AltScreen = true
+
+	return v
+}
+
+func (m *model) viewProcesses(b *strings.Builder) {
+	nameWidth := len("NAME")
+	for _, p := range m.state.Processes {
+		nameWidth = max(nameWidth, len(p.Name))
+	}
+
+	row := func(cols ...string) string {
+		return fmt.Sprintf("  %-*s  %-10s  %-7s  %-9s  %-8s  %s", nameWidth, cols[0], cols[1], cols[2], cols[3], cols[4], cols[5])
+	}
+
+	b.WriteString(headerStyle.Render(m.truncate(row("NAME", "STATE", "PID", "UPTIME", "RESTARTS", "EXIT"))))
+	b.WriteString("\n")
+
+	for i, p := range m.state.Processes {
+		pid, uptime, exit := "-", "-", "-"
+		if p.PID > 0 {
+			pid = strconv.Itoa(p.PID)
+		}
+		if p.State == task.ProcessStateRunning && !p.Started.IsZero() {
+			uptime = time.Since(p.Started).Truncate(time.Second).String()
+		}
+		if p.ExitCode >= 0 {
+			exit = strconv.Itoa(p.ExitCode)
+		}
+
+		state := fmt.Sprintf("%-10s", p.State)
+		if style, ok := stateStyles[p.State]; ok && i != m.selected {
+			state = style.Render(state)
+		}
+
+		line := row(p.Name, state, pid, uptime, strconv.Itoa(p.Restarts), exit)
+		if i == m.selected {
+			line = selectedStyle.Render(m.truncate(">" + line[1:]))
+		}
+
+		b.WriteString(m.truncate(line))
+		b.WriteString("\n")
+	}
+}
+
+func (m *model) viewLogs(b *strings.Builder) {
+	title := "── " + m.selectedName() + " "
+	if m.filtering || m.filter != "" {
+		title += "── filter: " + m.filter
+		if m.filtering {
+			title += "█"
+		}
+		title += " "
+	}
+	if m.scroll > 0 {
+		title += "── scrolled "
+	}
+
+	b.WriteString(helpStyle.Render(title + strings.Repeat("─", max(m.width-ansi.StringWidth(title), 0))))
+	b.WriteString("\n")
+
+	lines := m.lines()
+	height := m.logHeight()
+
+	m.scroll = min(m.scroll, max(len(lines)-height, 0))
+	end := len(lines) - m.scroll
+	start := max(end-height, 0)
+
+	for _, e := range lines[start:end] {
+		text := m.truncate(e.text)
+
+		switch e.kind {
+		case entryEvent:
+			text = eventStyle.Render(text)
+		case entryError:
+			text = errorStyle.Render(text)
+		default:
+			text += "\x1b[0m"
+		}
+
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+
+	// keep the status line at the bottom
+	b.WriteString(strings.Repeat("\n", height-(end-start)))
+}
+
+func (m *model) viewStatus(b *strings.Builder) {
+	switch {
+	case m.err != nil:
+		b.WriteString(errorStyle.Render(m.truncate(m.err.Error())))
+	case m.state.Message != "":
+		b.WriteString(m.truncate(m.state.Message))
+	default:
+		b.WriteString(helpStyle.Render(m.truncate(help)))
+	}
+}
+
+func (m *model) truncate(s string) string {
+	if m.width <= 0 {
+		return s
+	}
+
+	return ansi.Truncate(s, m.width, "")
+}
@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/cmd/runproc/task"
+	"github.com/zeiss/pkg/duplex"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeController struct {
+	procs    []task.ProcessInfo
+	restarts []string
+	stops    []string
+	kills    []string
+}
+
+func (c *fakeController) Processes() []task.ProcessInfo { return c.procs }
+
+func (c *fakeController) RestartProcess(name string) error {
+	c.restarts = append(c.restarts, name)
+	return nil
+}
+
+func (c *fakeController) StopProcess(name string) error {
+	c.stops = append(c.stops, name)
+	return nil
+}
+
+func (c *fakeController) KillProcess(name string) error {
+	c.kills = append(c.kills, name)
+	return errors.New("kill failed")
+}
+
+func newTestModel(t *testing.T) (*model, *fakeController) {
+	t.Helper()
+
+	ctrl := &fakeController{procs: []task.ProcessInfo{
+		{Name: "web", State: task.ProcessStateRunning, PID: 42, Started: time.Now(), ExitCode: -1},
+		{Name: "worker", State: task.ProcessStateExited, PID: 43, Restarts: 2, ExitCode: 1},
+	}}
+
+	s := newSink()
+	t.Cleanup(s.Close)
+
+	m := newModel(ctrl, s)
+	m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+
+	s.Line(task.Line{Process: "web", Text: "listening on :8080"})
+	s.Line(task.Line{Process: "web", Text: "GET /healthz"})
+	s.Event(task.Event{Process: "worker", Type: task.EventExited, Err: errors.New("exit status 1")})
+	s.Line(task.Line{Process: "worker", Text: "working"})
+
+	msg := m.listen()()
+	require.Implements(t, (*duplex.StateChangeMsg[State])(nil), msg)
+	m.Update(msg)
+
+	return m, ctrl
+}
+
+func key(s string) tea.KeyPressMsg {
+	switch s {
+	case "up":
+		return tea.KeyPressMsg{Code: tea.KeyUp}
+	case "down":
+		return tea.KeyPressMsg{Code: tea.KeyDown}
+	case "enter":
+		return tea.KeyPressMsg{Code: tea.KeyEnter}
+	case "esc":
+		return tea.KeyPressMsg{Code: tea.KeyEscape}
+	default:
+		return tea.KeyPressMsg{Code: []rune(s)[0], Text: s}
+	}
+}
+
+func TestModelView(t *testing.T) {
+	m, _ := newTestModel(t)
+
+	view := m.View().Content
+	assert.Contains(t, view, "web")
+	assert.Contains(t, view, "running")
+	assert.Contains(t, view, "42")
+	assert.Contains(t, view, "listening on :8080")
+	assert.NotContains(t, view, "working")
+
+	m.Update(key("down"))
+
+	view = m.View().Content
+	assert.Contains(t, view, "working")
+	assert.Contains(t, view, "Exited.")
+	assert.Contains(t, view, "exit status 1")
+	assert.NotContains(t, view, "listening on :8080")
+}
+
+func TestModelFilter(t *testing.T) {
+	m, _ := newTestModel(t)
+
+	m.Update(key("/"))
+	for _, r := range "health" {
+		m.Update(key(string(r)))
+	}
+	m.Update(key("enter"))
+
+	view := m.View().Content
+	assert.Contains(t, view, "GET /healthz")
+	assert.NotContains(t, view, "listening on :8080")
+
+	m.Update(key("esc"))
+	assert.Contains(t, m.View().Content, "listening on :8080")
+}
+
+func TestModelControl(t *testing.T) {
+	m, ctrl := newTestModel(t)
+
+	_, cmd := m.Update(key("r"))
+	require.NotNil(t, cmd)
+	assert.Nil(t, cmd())
+
+	m.Update(key("down"))
+
+	_, cmd = m.Update(key("s"))
+	require.NotNil(t, cmd)
+	cmd()
+
+	_, cmd = m.Update(key("k"))
+	require.NotNil(t, cmd)
+	m.Update(cmd())
+
+	assert.Equal(t, []string{"web"}, ctrl.restarts)
+	assert.Equal(t, []string{"worker"}, ctrl.stops)
+	assert.Equal(t, []string{"worker"}, ctrl.kills)
+	assert.Contains(t, m.View().Content, "kill failed")
+}
+
+func TestScrollback(t *testing.T) {
+	var entries []entry
+	for i := range MaxScrollback + 10 {
+		entries = appendEntry(entries, entry{text: fmt.Sprint(i)})
+	}
+
+	require.Len(t, entries, MaxScrollback)
+	assert.Equal(t, "10", entries[0].text)
+	assert.Equal(t, fmt.Sprint(MaxScrollback+9), entries[MaxScrollback-1].text)
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/zeiss/pkg/cmd/runproc/task"
+)
+
+var _ task.Output = (*sink)(nil)
+
+// logMsg is a message that has been written to the log.
+type logMsg string
+
+// sink is queueing the output of the processes and the log until the model receives it.
+// The queue is unbounded, so the processes are never blocked by the terminal.
+type sink struct {
+	mu     sync.Mutex
+	queue  []any
+	notify chan struct{}
+	closed chan struct{}
+}
+
+func newSink() *sink {
+	return &sink{
+		notify: make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+}
+
+// Line implements task.Output.
+func (s *sink) Line(l task.Line) {
+	s.push(l)
+}
+
+// Event implements task.Output.
+func (s *sink) Event(e task.Event) {
+	s.push(e)
+}
+
+// Write implements io.Writer for the log.
+func (s *sink) Write(p []byte) (int, error) {
+	s.push(logMsg(strings.TrimSuffix(string(p), "\n")))
+
+	return len(p), nil
+}
+
+func (s *sink) push(v any) {
+	s.mu.Lock()
+	s.queue = append(s.queue, v)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next waits for queued items and returns all of them,
+// it returns nil once the sink has been closed.
+func (s *sink) next() []any {
+	for {
+		s.mu.Lock()
+		items := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+
+		if len(items) > 0 {
+			return items
+		}
+
+		select {
+		case <-s.notify:
+		case <-s.closed:
+			return nil
+		}
+	}
+}
+
+func (s *sink) Close() {
+	close(s.closed)
+}
@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"maps"
+
+	"github.com/zeiss/pkg/cmd/runproc/task"
+	"github.com/zeiss/pkg/duplex"
+)
+
+// MaxScrollback is the number of lines that are kept per process.
+const MaxScrollback = 5000
+
+type entryKind int
+
+const (
+	entryOutput entryKind = iota
+	entryEvent
+	entryError
+)
+
+// entry is a line in the scrollback of a process.
+type entry struct {
+	kind entryKind
+	text string
+}
+
+// State is the state of the dashboard.
+type State struct {
+	// Processes are the snapshots of the processes.
+	Processes []task.ProcessInfo
+	// Logs are the scrollback of the processes by their name.
+	Logs map[string][]entry
+	// Message is the last message of the log.
+	Message string
+}
+
+// batch is the update of the output that has been received since the last update.
+type batch struct {
+	items     []any
+	processes []task.ProcessInfo
+}
+
+func reduceProcesses(curr State, update duplex.Update) State {
+	if b, ok := update.(batch); ok && b.processes != nil {
+		curr.Processes = b.processes
+	}
+
+	return curr
+}
+
+// reduceLogs is appending the output to the scrollback. The previous state is not modified,
+// appending only writes behind the lines of the previous state.
+func reduceLogs(curr State, update duplex.Update) State {
+	b, ok := update.(batch)
+	if !ok {
+		return curr
+	}
+
+	logs := maps.Clone(curr.Logs)
+	if logs == nil {
+		logs = make(map[string][]entry)
+	}
+
+	for _, item := range b.items {
+		switch v := item.(type) {
+		case task.Line:
+			logs[v.Process] = appendEntry(logs[v.Process], entry{kind: entryOutput, text: v.Text})
+		case task.Event:
+			kind := entryEvent
+			if v.Type == task.EventError {
+				kind = entryError
+			}
+
+			logs[v.Process] = appendEntry(logs[v.Process], entry{kind: kind, text: v.Message()})
+
+			if v.Type != task.EventError && v.Err != nil {
+				logs[v.Process] = appendEntry(logs[v.Process], entry{kind: entryError, text: v.Err.Error()})
+			}
+		case logMsg:
+			curr.Message = string(v)
+		}
+	}
+
+	curr.Logs = logs
+
+	return curr
+}
+
+func appendEntry(entries []entry, e entry) []entry {
+	if len(entries) < MaxScrollback {
+		return append(entries, e)
+	}
+
+	trimmed := make([]entry, MaxScrollback, MaxScrollback+MaxScrollback/10)
+	copy(trimmed, entries[len(entries)-MaxScrollback+1:])
+	trimmed[MaxScrollback-1] = e
+
+	return trimmed
+}
@@ -0,0 +1,50 @@
+// Package tui is an interactive dashboard for the processes of runproc.
+package tui
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/zeiss/pkg/cmd/runproc/task"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// Dashboard is an interactive dashboard for the processes of a runner.
+// It has to be the output of the runner, e.g. with task.WithOutput.
+type Dashboard struct {
+	*sink
+}
+
+// New returns a new dashboard.
+func New() *Dashboard {
+	return &Dashboard{sink: newSink()}
+}
+
+// Run runs the processes and shows the dashboard until it is quit or the context is canceled.
+// The processes are stopped when the dashboard is quit, the runner should be kept alive
+// with task.WithKeepAlive to restart processes that exited.
+func (d *Dashboard) Run(ctx context.Context, r *task.Runner) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	defer d.Close()
+
+	// the log would break the terminal, it is shown in the status line
+	out := log.Writer()
+	log.SetOutput(d.sink)
+	defer log.SetOutput(out)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- r.Run(ctx) }()
+
+	_, err := tea.NewProgram(newModel(r, d.sink), tea.WithContext(ctx)).Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		err = nil
+	}
+
+	cancel()
+
+	return errors.Join(err, <-errCh)
+}
@@ -2,13 +2,19 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
-	"net/url"
 	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
-	"github.com/zeiss/pkg/cmd/waitfor/schemes"
+	"github.com/zeiss/pkg/waitfor"
+	"github.com/zeiss/pkg/waitfor/schemes"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/spf13/cobra"
@@ -22,81 +28,236 @@ var (
 
 var build = fmt.Sprintf("%s (%s) (%s)", version, commit, date)
 
+const (
+	reportText = "text"
+	reportJSON = "json"
+)
+
 type config struct {
 	timeout     time.Duration
 	connTimeout time.Duration
 	retryTime   time.Duration
+	parallel    bool
+	report      string
+
+	method      string
+	status      string
+	headers     []string
+	body        string
+	bodyRegex   string
+	jsonPath    string
+	caFile      string
+	insecure    bool
+	bearerToken string
 }
 
 var cfg = &config{}
 
 var rootCmd = &cobra.Command{
-	Use:   "wait-for",
+	Use:   "wait-for [flags] target... [-- command [args...]]",
 	Short: `wait-for waits for other service to become available.`,
+	Long: `wait-for waits for other service to become available.
+
+When a command is given after --, it is executed in place of wait-for
+once all targets are available.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runRoot(cmd.Context(), args...)
+		targets, command := args, []string(nil)
+		if i := cmd.ArgsLenAtDash(); i >= 0 {
+			targets, command = args[:i], args[i:]
+		}
+
+		return runRoot(cmd.Context(), targets, command)
 	},
 	Version: build,
 }
 
 func init() {
-	register(schemes.HTTP(), "http", "https")
-	register(schemes.TCP(), "tcp")
-	register(schemes.Postgres(), "postgres", "postgresql")
-
 	rootCmd.Flags().DurationVar(&cfg.timeout, "timeout", time.Minute, "Timeout to wait for all checks to complete.")
 	rootCmd.Flags().DurationVar(&cfg.connTimeout, "connect-timeout", 5*time.Second, "Timeout to wait for a single check to complete.")
 	rootCmd.Flags().DurationVar(&cfg.retryTime, "retry-time", 3*time.Second, "Time to wait between retries.")
+	rootCmd.Flags().BoolVar(&cfg.parallel, "parallel", false, "Wait for all targets concurrently and print a summary.")
+	rootCmd.Flags().StringVar(&cfg.report, "report", reportText, "Format of the results, text or json (printed to stdout).")
+
+	rootCmd.Flags().StringVar(&cfg.method, "method", "", "HTTP method of the requests (default HEAD, or GET if the body is asserted).")
+	rootCmd.Flags().StringVar(&cfg.status, "status", "", "Accepted HTTP status codes, e.g. 200-299,301 or 2xx (default 200).")
+	rootCmd.Flags().StringArrayVar(&cfg.headers, "header", nil, "Required HTTP response header as Name or Name:Value.")
+	rootCmd.Flags().StringVar(&cfg.body, "body", "", "Substring the HTTP response body has to contain.")
+	rootCmd.Flags().StringVar(&cfg.bodyRegex, "body-regex", "", "Regular expression the HTTP response body has to match.")
+	rootCmd.Flags().StringVar(&cfg.jsonPath, "jsonpath", "", "Path that has to exist in the JSON response body, or PATH=VALUE, e.g. $.status=UP.")
+	rootCmd.Flags().StringVar(&cfg.caFile, "cacert", "", "CA certificates to verify HTTPS servers.")
+	rootCmd.Flags().BoolVar(&cfg.insecure, "insecure", false, "Skip the verification of HTTPS server certificates.")
+	rootCmd.Flags().StringVar(&cfg.bearerToken, "bearer-token", "", "Bearer token to send to HTTP servers, the user and password of a URL are sent as basic auth.")
 }
 
-var waitFuncs = map[string]schemes.WaitFunc{}
+func httpOpts() ([]schemes.HTTPOpt, error) {
+	opts := []schemes.HTTPOpt{
+		schemes.WithMethod(strings.ToUpper(cfg.method)),
+		schemes.WithBody(cfg.body),
+		schemes.WithJSONPath(cfg.jsonPath),
+		schemes.WithCAFile(cfg.caFile),
+		schemes.WithInsecure(cfg.insecure),
+		schemes.WithBearerToken(cfg.bearerToken),
+	}
+
+	if cfg.status != "" {
+		ranges, err := schemes.ParseStatusRanges(cfg.status)
+		if err != nil {
+			return nil, err
+		}
 
-func register(fn schemes.WaitFunc, schema ...string) {
-	for _, s := range schema {
-		waitFuncs[s] = fn
+		opts = append(opts, schemes.WithStatus(ranges...))
 	}
+
+	for _, h := range cfg.headers {
+		name, value, _ := strings.Cut(h, ":")
+		opts = append(opts, schemes.WithHeader(strings.TrimSpace(name), strings.TrimSpace(value)))
+	}
+
+	if cfg.bodyRegex != "" {
+		re, err := regexp.Compile(cfg.bodyRegex)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, schemes.WithBodyRegex(re))
+	}
+
+	return opts, nil
 }
 
-func waitFor(ctx context.Context, urlStr string) error {
-	u, err := url.Parse(urlStr)
+// logEvent logs the progress of waiting for the targets.
+func logEvent(e waitfor.Event) {
+	switch {
+	case e.Type == waitfor.EventRetry:
+		log.Println("Waiting for", e.Target, e.Err)
+	case !cfg.parallel:
+	case e.Type == waitfor.EventReady:
+		log.Printf("✓ %s is ready after %s", e.Target, e.Elapsed.Round(time.Millisecond))
+	case e.Type == waitfor.EventFailed:
+		log.Printf("✗ %s: %v", e.Target, e.Err)
+	}
+}
+
+func runRoot(ctx context.Context, targets, command []string) error {
+	if cfg.report != reportText && cfg.report != reportJSON {
+		return fmt.Errorf("invalid report format %q, must be %s or %s", cfg.report, reportText, reportJSON)
+	}
+
+	opts, err := httpOpts()
 	if err != nil {
-		return fmt.Errorf("url parse '%s': %w", urlStr, err)
+		return err
 	}
 
-	t := time.NewTicker(cfg.retryTime)
-	defer t.Stop()
+	registry := waitfor.DefaultRegistry()
+	registry.Register(schemes.HTTP(opts...), "http", "https")
 
-	for {
-		fn, ok := waitFuncs[u.Scheme]
-		if !ok {
-			return fmt.Errorf("unsupported schema %q", u.Scheme)
-		}
+	w := waitfor.New(
+		waitfor.WithRegistry(registry),
+		waitfor.WithTimeout(cfg.timeout),
+		waitfor.WithAttemptTimeout(cfg.connTimeout),
+		waitfor.WithInterval(cfg.retryTime),
+		waitfor.WithParallel(cfg.parallel),
+		waitfor.WithOnEvent(logEvent),
+	)
 
-		ct, cancel := context.WithTimeout(ctx, cfg.connTimeout)
-		err = fn(ct, urlStr)
-		cancel()
-		if err == nil {
-			return nil
+	results, err := w.Wait(ctx, targets...)
+
+	switch {
+	case cfg.report == reportJSON:
+		if err := printJSON(targets, results); err != nil {
+			return err
 		}
+	case cfg.parallel:
+		if err := printSummary(results); err != nil {
+			return err
+		}
+	}
 
-		log.Println("Waiting for", urlStr, err)
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("timeout waiting for %s", urlStr)
-		case <-t.C:
+	if err != nil {
+		return err
+	}
+
+	if len(command) > 0 {
+		return execCommand(command)
+	}
+
+	return nil
+}
+
+// printSummary prints a table of the results to stderr.
+func printSummary(results []waitfor.Result) error {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tSTATUS\tDURATION\tATTEMPTS\tERROR")
+
+	for _, res := range results {
+		status, msg := "ready", ""
+		if res.Err != nil {
+			status, msg = "failed", res.Err.Error()
 		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", res.Target, status, res.Duration.Round(time.Millisecond), res.Attempts, msg)
 	}
+
+	return w.Flush()
 }
 
-func runRoot(ctx context.Context, args ...string) error {
-	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
-	defer cancel()
+type report struct {
+	Ready   bool           `json:"ready"`
+	Results []reportResult `json:"results"`
+}
 
-	for _, urlStr := range args {
-		err := waitFor(ctx, urlStr)
-		if err != nil {
-			return err
+type reportResult struct {
+	Target     string `json:"target"`
+	Ready      bool   `json:"ready"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Attempts   int    `json:"attempts"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// printJSON prints the results as JSON to stdout.
+// Targets that have not been waited for after a failure are reported as skipped.
+func printJSON(targets []string, results []waitfor.Result) error {
+	r := report{Ready: true, Results: make([]reportResult, 0, len(targets))}
+
+	for i, target := range targets {
+		if i >= len(results) {
+			r.Ready = false
+			r.Results = append(r.Results, reportResult{Target: target, Skipped: true})
+
+			continue
+		}
+
+		res := results[i]
+		rr := reportResult{
+			Target:     res.Target,
+			Ready:      res.Ready(),
+			Attempts:   res.Attempts,
+			DurationMS: res.Duration.Milliseconds(),
+		}
+		if res.Err != nil {
+			r.Ready = false
+			rr.Error = res.Err.Error()
 		}
+
+		r.Results = append(r.Results, rr)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
+
+// execCommand replaces the process with the command.
+func execCommand(command []string) error {
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return err
+	}
+
+	if err := syscall.Exec(path, command, os.Environ()); err != nil { //nolint:gosec
+		return fmt.Errorf("exec %s: %w", command[0], err)
 	}
 
 	return nil
@@ -1,5 +0,0 @@
-package schemes
-
-import "context"
-
-type WaitFunc func(context.Context, string) error
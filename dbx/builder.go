@@ -2,9 +2,9 @@ package dbx
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"io"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -49,9 +49,54 @@ func NewQueryError(query string, err error) *QueryError {
 }
 
 type databaseImpl[R, W any] struct {
-	r    ReadTxFactory[R]
-	rw   ReadWriteTxFactory[W]
-	conn *gorm.DB
+	r        ReadTxFactory[R]
+	rw       ReadWriteTxFactory[W]
+	conn     *gorm.DB
+	opts     *DatabaseOpts
+	replicas *replicaSet
+}
+
+// DatabaseOpts are the options for the database.
+type DatabaseOpts struct {
+	// Migrator runs the migrations, defaults to GORM auto migrations of the models.
+	Migrator Migrator
+	// Tx are the default options of the transactions.
+	Tx *TxOpts
+	// Replicas are the read replicas of the primary.
+	Replicas []*gorm.DB
+	// MaxReplicaLag is the lag after which a replica is excluded from reads, zero disables the limit.
+	MaxReplicaLag time.Duration
+	// HealthInterval is the interval between health checks of the replicas.
+	HealthInterval time.Duration
+	// ReplicaLag returns the replication lag of a replica.
+	ReplicaLag ReplicaLagFunc
+}
+
+// Configure is configuring the options.
+func (o *DatabaseOpts) Configure(opts ...DatabaseOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultDatabaseOpts returns the default options.
+func DefaultDatabaseOpts() *DatabaseOpts {
+	return &DatabaseOpts{
+		Tx:             DefaultTxOpts(),
+		MaxReplicaLag:  DefaultMaxReplicaLag,
+		HealthInterval: DefaultHealthInterval,
+		ReplicaLag:     DefaultReplicaLag,
+	}
+}
+
+// DatabaseOpt is a function that configures the options.
+type DatabaseOpt func(*DatabaseOpts)
+
+// WithMigrator is setting the migrator that runs the migrations, e.g. versioned migrations.
+func WithMigrator(m Migrator) DatabaseOpt {
+	return func(opts *DatabaseOpts) {
+		opts.Migrator = m
+	}
 }
 
 // ReadTxFactory is a function that creates a new instance of Datastore.
@@ -60,72 +105,83 @@ type ReadTxFactory[R any] func(*gorm.DB) (R, error)
 // ReadWriteTxFactory is a function that creates a new instance of Datastore.
 type ReadWriteTxFactory[W any] func(*gorm.DB) (W, error)
 
+// WithTxOpts is setting the default options of the transactions,
+// they can be overridden per transaction with TxContext.
+func WithTxOpts(opts ...TxOpt) DatabaseOpt {
+	return func(o *DatabaseOpts) {
+		o.Tx.Configure(opts...)
+	}
+}
+
 // NewDatabase returns a new instance of db.
-func NewDatabase[R, W any](conn *gorm.DB, r ReadTxFactory[R], rw ReadWriteTxFactory[W]) (Database[R, W], error) {
-	return &databaseImpl[R, W]{r, rw, conn}, nil
+func NewDatabase[R, W any](conn *gorm.DB, r ReadTxFactory[R], rw ReadWriteTxFactory[W], opts ...DatabaseOpt) (Database[R, W], error) {
+	options := DefaultDatabaseOpts()
+	options.Configure(opts...)
+
+	return &databaseImpl[R, W]{r, rw, conn, options, newReplicaSet(options)}, nil
 }
 
-// Close closes the database connection.
+// Close closes the database connection and the connections of the replicas.
 func (d *databaseImpl[R, W]) Close() error {
 	db, err := d.conn.DB()
 	if err != nil {
 		return err
 	}
 
-	return db.Close()
+	return errors.Join(db.Close(), d.replicas.close())
 }
 
 // RunMigrations runs the database migrations.
 func (d *databaseImpl[R, W]) Migrate(ctx context.Context, dst ...interface{}) error {
+	if d.opts.Migrator != nil {
+		return d.opts.Migrator.Migrate(ctx, dst...)
+	}
+
 	return d.conn.WithContext(ctx).AutoMigrate(dst...)
 }
 
-// ReadWriteTx starts a read only transaction.
+// ReadWriteTx starts a read write transaction.
 func (d *databaseImpl[R, W]) ReadWriteTx(ctx context.Context, fn func(context.Context, W) error) error {
-	tx := d.conn.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return tx.Error
-	}
-
-	rwtx, err := d.rw(tx)
-	if err != nil {
-		return err
-	}
-
-	err = fn(ctx, rwtx)
-	if err != nil {
-		tx.Rollback()
-		return NewQueryError("rollback transaction", err)
-	}
-
-	if err := tx.Commit().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
-		return err
-	}
-
-	return nil
+	return transaction(ctx, d.conn, d.opts.Tx, "read write", false, func(ctx context.Context, tx *gorm.DB) error {
+		rwtx, err := d.rw(tx)
+		if err != nil {
+			return err
+		}
+
+		return fn(ctx, rwtx)
+	})
 }
 
-// ReadTx starts a read only transaction.
+// ReadTx starts a read only transaction on a healthy replica, or on the primary if there is none
+// or the context has been created with ReadYourWrites.
 func (d *databaseImpl[R, W]) ReadTx(ctx context.Context, fn func(context.Context, R) error) error {
-	tx := d.conn.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return NewQueryError("begin read transaction", tx.Error)
+	read := func(conn *gorm.DB) error {
+		return transaction(ctx, conn, d.opts.Tx, "read", true, func(ctx context.Context, tx *gorm.DB) error {
+			rtx, err := d.r(tx)
+			if err != nil {
+				return err
+			}
+
+			return fn(ctx, rtx)
+		})
 	}
 
-	rtx, err := d.r(tx)
-	if err != nil {
-		return err
+	if isReadYourWrites(ctx) {
+		return read(d.conn)
 	}
 
-	err = fn(ctx, rtx)
-	if err != nil {
-		tx.Rollback()
-		return NewQueryError("rollback transaction", err)
+	r := d.replicas.pick()
+	if r == nil {
+		return read(d.conn)
 	}
 
-	if err := tx.Commit().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
-		return NewQueryError("commit read transaction", err)
+	err := read(r.db)
+
+	var qerr *QueryError
+	if errors.As(err, &qerr) && qerr.Query == "begin read transaction" && ctx.Err() == nil {
+		r.healthy.Store(false)
+		return read(d.conn)
 	}
 
-	return nil
+	return err
 }
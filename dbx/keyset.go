@@ -0,0 +1,324 @@
+package dbx
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrInvalidCursor is returned when a cursor is malformed, has been tampered with or belongs to another sort order.
+	ErrInvalidCursor = errors.New("dbx: invalid cursor")
+	// ErrUnknownColumn is returned when a column is not a field of the model.
+	ErrUnknownColumn = errors.New("dbx: unknown column")
+	// ErrMissingSecret is returned when a Keyset has no secret to sign its cursors with.
+	ErrMissingSecret = errors.New("dbx: missing keyset secret")
+)
+
+const (
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+// KeysetColumn is a column of the sort order of a keyset pagination.
+type KeysetColumn struct {
+	// Name is the name of the column.
+	Name string
+	// Desc sorts the column in descending order.
+	Desc bool
+}
+
+// NewKeyset returns a new Keyset struct that signs its cursors with the secret.
+// The columns are the sort order, the last column has to be unique, e.g. the primary key,
+// and none of the columns may be null. Paginating with an empty secret fails with ErrMissingSecret,
+// as the cursors could be forged otherwise.
+func NewKeyset[T any](secret []byte, columns ...KeysetColumn) *Keyset[T] {
+	return &Keyset[T]{
+		Limit:   10,
+		Columns: columns,
+		Secret:  secret,
+	}
+}
+
+// Keyset is a struct that contains the results of a query paginated by keyset.
+// Unlike offset pagination, pages stay stable under concurrent inserts
+// and do not become slower the further they are.
+type Keyset[T any] struct {
+	// Limit is the number of items to return.
+	Limit int `json:"limit" xml:"limit" form:"limit" query:"limit"`
+	// Cursor is the cursor of the page to return, empty for the first page.
+	Cursor string `json:"cursor,omitempty" xml:"cursor" form:"cursor" query:"cursor"`
+	// Columns are the columns to sort by.
+	Columns []KeysetColumn `json:"-"`
+	// Secret is the key to sign the cursors with.
+	Secret []byte `json:"-"`
+	// Next is the cursor of the next page, empty if there is none.
+	Next string `json:"next,omitempty" xml:"next"`
+	// Prev is the cursor of the previous page, empty if there is none.
+	Prev string `json:"prev,omitempty" xml:"prev"`
+	// Rows is the items to return.
+	Rows []T `json:"rows" xml:"rows"`
+
+	schema    *schema.Schema
+	direction string
+}
+
+// GetLimit returns the limit.
+func (p *Keyset[T]) GetLimit() int {
+	if p.Limit <= 0 {
+		p.Limit = 10
+	}
+
+	return p.Limit
+}
+
+// GetRows returns the rows as pointers.
+func (p *Keyset[T]) GetRows() []*T {
+	return RowsPtr(p.Rows)
+}
+
+// GetLen returns the length of the rows.
+func (p *Keyset[T]) GetLen() int {
+	return len(p.Rows)
+}
+
+// cursor is the content of a cursor.
+type cursor struct {
+	// Direction is the direction to paginate in from the row.
+	Direction string `json:"d"`
+	// Columns are the names of the sort columns, with a leading minus if descending.
+	Columns []string `json:"c"`
+	// Values are the values of the sort columns of the row.
+	Values []json.RawMessage `json:"v"`
+}
+
+func (p *Keyset[T]) columns() []string {
+	columns := make([]string, 0, len(p.Columns))
+	for _, c := range p.Columns {
+		if c.Desc {
+			columns = append(columns, "-"+c.Name)
+			continue
+		}
+
+		columns = append(columns, c.Name)
+	}
+
+	return columns
+}
+
+func (p *Keyset[T]) sign(payload string) string {
+	mac := hmac.New(sha256.New, p.Secret)
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// encode returns the signed cursor to paginate from the row in the direction.
+func (p *Keyset[T]) encode(row *T, direction string) (string, error) {
+	if len(p.Secret) == 0 {
+		return "", ErrMissingSecret
+	}
+
+	c := cursor{Direction: direction, Columns: p.columns()}
+
+	v := reflect.ValueOf(row).Elem()
+	for _, col := range p.Columns {
+		value, _ := p.schema.LookUpField(col.Name).ValueOf(context.Background(), v)
+
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, b)
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+
+	return payload + "." + p.sign(payload), nil
+}
+
+// decode verifies the cursor and returns its direction and values typed like the fields of the model.
+func (p *Keyset[T]) decode(s string) (string, []any, error) {
+	if len(p.Secret) == 0 {
+		return "", nil, ErrMissingSecret
+	}
+
+	payload, sig, ok := strings.Cut(s, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(p.sign(payload))) {
+		return "", nil, ErrInvalidCursor
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, errors.Join(ErrInvalidCursor, err)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return "", nil, errors.Join(ErrInvalidCursor, err)
+	}
+
+	if !slices.Equal(c.Columns, p.columns()) || len(c.Values) != len(p.Columns) {
+		return "", nil, ErrInvalidCursor
+	}
+
+	if c.Direction != cursorNext && c.Direction != cursorPrev {
+		return "", nil, ErrInvalidCursor
+	}
+
+	values := make([]any, 0, len(c.Values))
+	for i, col := range p.Columns {
+		value := reflect.New(p.schema.LookUpField(col.Name).FieldType)
+		if err := json.Unmarshal(c.Values[i], value.Interface()); err != nil {
+			return "", nil, errors.Join(ErrInvalidCursor, err)
+		}
+		values = append(values, value.Elem().Interface())
+	}
+
+	return c.Direction, values, nil
+}
+
+var keysetSchemas sync.Map
+
+// KeysetResults returns a function that paginates the results by keyset.
+// It fetches one more row than the limit to know if there is another page,
+// so SetCursors has to be called after the rows have been found.
+//
+//	results := dbx.NewKeyset[Note](secret, dbx.KeysetColumn{Name: "created_at", Desc: true}, dbx.KeysetColumn{Name: "id", Desc: true})
+//	results.Cursor = c.Query("cursor")
+//
+//	err := db.Scopes(dbx.KeysetResults(results)).Find(&results.Rows).Error
+//	...
+//	err = results.SetCursors()
+func KeysetResults[T any](pagination *Keyset[T]) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		s, err := schema.Parse(new(T), &keysetSchemas, db.NamingStrategy)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		pagination.schema = s
+
+		if len(pagination.Columns) == 0 {
+			_ = db.AddError(fmt.Errorf("%w: no sort columns", ErrUnknownColumn))
+			return db
+		}
+
+		for _, c := range pagination.Columns {
+			if f := s.LookUpField(c.Name); f == nil || f.DBName == "" {
+				_ = db.AddError(fmt.Errorf("%w: %s", ErrUnknownColumn, c.Name))
+				return db
+			}
+		}
+
+		pagination.direction = cursorNext
+
+		if pagination.Cursor != "" {
+			direction, values, err := pagination.decode(pagination.Cursor)
+			if err != nil {
+				_ = db.AddError(err)
+				return db
+			}
+			pagination.direction = direction
+
+			where, args := pagination.where(db, values)
+			db = db.Where(where, args...)
+		}
+
+		for _, c := range pagination.Columns {
+			desc := c.Desc != (pagination.direction == cursorPrev)
+
+			order := db.Statement.Quote(s.LookUpField(c.Name).DBName)
+			if desc {
+				order += " DESC"
+			}
+			db = db.Order(order)
+		}
+
+		return db.Limit(pagination.GetLimit() + 1)
+	}
+}
+
+// where returns the condition for the rows after the values in the direction of the pagination,
+// e.g. ((a > ?) OR (a = ? AND b > ?)).
+func (p *Keyset[T]) where(db *gorm.DB, values []any) (string, []any) {
+	var (
+		or   []string
+		args []any
+	)
+
+	for i, c := range p.Columns {
+		var and []string
+		for j := range i {
+			and = append(and, db.Statement.Quote(p.schema.LookUpField(p.Columns[j].Name).DBName)+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := ">"
+		if c.Desc != (p.direction == cursorPrev) {
+			op = "<"
+		}
+
+		and = append(and, db.Statement.Quote(p.schema.LookUpField(c.Name).DBName)+" "+op+" ?")
+		args = append(args, values[i])
+
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+
+	return "(" + strings.Join(or, " OR ") + ")", args
+}
+
+// SetCursors trims the rows to the limit and sets the cursors of the next and the previous page.
+func (p *Keyset[T]) SetCursors() error {
+	if p.schema == nil {
+		return errors.New("dbx: keyset results have not been queried")
+	}
+
+	more := len(p.Rows) > p.GetLimit()
+	if more {
+		p.Rows = p.Rows[:p.GetLimit()]
+	}
+
+	if p.direction == cursorPrev {
+		slices.Reverse(p.Rows)
+	}
+
+	p.Next, p.Prev = "", ""
+	if len(p.Rows) == 0 {
+		return nil
+	}
+
+	var err error
+
+	// there is a next page if there are more rows, or if we came back from it
+	if more && p.direction == cursorNext || p.direction == cursorPrev {
+		if p.Next, err = p.encode(&p.Rows[len(p.Rows)-1], cursorNext); err != nil {
+			return err
+		}
+	}
+
+	// there is a previous page if we came from it, or if there are more rows before
+	if p.Cursor != "" && p.direction == cursorNext || more && p.direction == cursorPrev {
+		if p.Prev, err = p.encode(&p.Rows[0], cursorPrev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
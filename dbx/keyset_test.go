@@ -0,0 +1,169 @@
+package dbx_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/dbx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type note struct {
+	ID        int
+	Title     string
+	CreatedAt time.Time
+}
+
+func newTestDB(t *testing.T, models ...any) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn, err := db.DB()
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+	})
+
+	require.NoError(t, db.AutoMigrate(models...))
+
+	return db
+}
+
+// seedNotes creates notes with the ids 1 to n and three notes sharing every creation time.
+func seedNotes(t *testing.T, db *gorm.DB, n int) {
+	t.Helper()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= n; i++ {
+		require.NoError(t, db.Create(&note{ID: i, Title: fmt.Sprintf("note %d", i), CreatedAt: start.Add(time.Duration(i/3) * time.Hour)}).Error)
+	}
+}
+
+func ids(rows []note) []int {
+	ids := make([]int, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+
+	return ids
+}
+
+var secret = []byte("secret")
+
+func page(t *testing.T, db *gorm.DB, cursor string, columns ...dbx.KeysetColumn) *dbx.Keyset[note] {
+	t.Helper()
+
+	results := dbx.NewKeyset[note](secret, columns...)
+	results.Limit = 4
+	results.Cursor = cursor
+
+	require.NoError(t, db.Scopes(dbx.KeysetResults(results)).Find(&results.Rows).Error)
+	require.NoError(t, results.SetCursors())
+
+	return results
+}
+
+func TestKeysetResults(t *testing.T) {
+	db := newTestDB(t, &note{})
+	seedNotes(t, db, 10)
+
+	columns := []dbx.KeysetColumn{{Name: "created_at", Desc: true}, {Name: "id"}}
+
+	first := page(t, db, "", columns...)
+	assert.Equal(t, []int{9, 10, 6, 7}, ids(first.Rows))
+	assert.Empty(t, first.Prev)
+	require.NotEmpty(t, first.Next)
+
+	// a row inserted before the cursor does not shift the next page
+	require.NoError(t, db.Create(&note{ID: 11, CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}).Error)
+
+	second := page(t, db, first.Next, columns...)
+	assert.Equal(t, []int{8, 3, 4, 5}, ids(second.Rows))
+	require.NotEmpty(t, second.Prev)
+	require.NotEmpty(t, second.Next)
+
+	last := page(t, db, second.Next, columns...)
+	assert.Equal(t, []int{1, 2}, ids(last.Rows))
+	assert.Empty(t, last.Next)
+	require.NotEmpty(t, last.Prev)
+
+	back := page(t, db, last.Prev, columns...)
+	assert.Equal(t, []int{8, 3, 4, 5}, ids(back.Rows))
+	assert.NotEmpty(t, back.Next)
+	require.NotEmpty(t, back.Prev)
+
+	back = page(t, db, back.Prev, columns...)
+	assert.Equal(t, []int{9, 10, 6, 7}, ids(back.Rows))
+	assert.NotEmpty(t, back.Prev, "the inserted row is before")
+
+	back = page(t, db, back.Prev, columns...)
+	assert.Equal(t, []int{11}, ids(back.Rows))
+	assert.Empty(t, back.Prev)
+	assert.NotEmpty(t, back.Next)
+}
+
+func TestKeysetResultsInvalidCursor(t *testing.T) {
+	db := newTestDB(t, &note{})
+	seedNotes(t, db, 5)
+
+	first := page(t, db, "", dbx.KeysetColumn{Name: "id"})
+	require.NotEmpty(t, first.Next)
+
+	tests := []struct {
+		name    string
+		cursor  string
+		columns []dbx.KeysetColumn
+	}{
+		{name: "tampered", cursor: "x" + first.Next, columns: []dbx.KeysetColumn{{Name: "id"}}},
+		{name: "unsigned", cursor: "e30", columns: []dbx.KeysetColumn{{Name: "id"}}},
+		{name: "other order", cursor: first.Next, columns: []dbx.KeysetColumn{{Name: "id", Desc: true}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := dbx.NewKeyset[note](secret, tc.columns...)
+			results.Cursor = tc.cursor
+
+			err := db.Scopes(dbx.KeysetResults(results)).Find(&results.Rows).Error
+			require.ErrorIs(t, err, dbx.ErrInvalidCursor)
+		})
+	}
+}
+
+func TestKeysetResultsUnknownColumn(t *testing.T) {
+	db := newTestDB(t, &note{})
+
+	results := dbx.NewKeyset[note](secret, dbx.KeysetColumn{Name: "id; DROP TABLE notes"})
+
+	err := db.Scopes(dbx.KeysetResults(results)).Find(&results.Rows).Error
+	require.ErrorIs(t, err, dbx.ErrUnknownColumn)
+}
+
+func TestKeysetMissingSecret(t *testing.T) {
+	db := newTestDB(t, &note{})
+	for i := range 6 {
+		require.NoError(t, db.Create(&note{Title: fmt.Sprint(i)}).Error)
+	}
+
+	unsigned := dbx.NewKeyset[note](nil, dbx.KeysetColumn{Name: "id"})
+	unsigned.Limit = 2
+	require.NoError(t, db.Scopes(dbx.KeysetResults(unsigned)).Find(&unsigned.Rows).Error)
+	require.ErrorIs(t, unsigned.SetCursors(), dbx.ErrMissingSecret)
+
+	cursor := page(t, db, "", dbx.KeysetColumn{Name: "id"}).Next
+	require.NotEmpty(t, cursor)
+
+	results := &dbx.Keyset[note]{Limit: 1, Cursor: cursor, Columns: []dbx.KeysetColumn{{Name: "id"}}}
+	err := db.Scopes(dbx.KeysetResults(results)).Find(&results.Rows).Error
+	require.ErrorIs(t, err, dbx.ErrMissingSecret)
+}
@@ -0,0 +1,417 @@
+package dbx
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrChecksumMismatch is returned when an applied migration has been changed.
+	ErrChecksumMismatch = errors.New("dbx: migration checksum mismatch")
+	// ErrIrreversible is returned when a migration without down migration is rolled back.
+	ErrIrreversible = errors.New("dbx: migration is irreversible")
+	// ErrDuplicateMigration is returned when two migrations have the same version.
+	ErrDuplicateMigration = errors.New("dbx: duplicate migration")
+)
+
+const (
+	// DefaultHistoryTable is the default name of the table with the applied migrations.
+	DefaultHistoryTable = "schema_migrations"
+	// DefaultMigrationLockID is the default key of the Postgres advisory lock that is held while migrating.
+	DefaultMigrationLockID int64 = 7_345_116_302_487_512
+)
+
+// MigrationFunc is a function that migrates the database in a transaction.
+type MigrationFunc func(ctx context.Context, tx *gorm.DB) error
+
+// Migration is a versioned migration.
+type Migration struct {
+	// Version is the version of the migration, migrations are applied in ascending order.
+	Version int64
+	// Name is the name of the migration.
+	Name string
+	// Up applies the migration.
+	Up MigrationFunc
+	// Down rolls the migration back, nil if it is irreversible.
+	Down MigrationFunc
+	// Checksum is the checksum of the migration, changes of applied migrations are detected with it.
+	Checksum string
+}
+
+// MigrationStatus is the status of a migration.
+type MigrationStatus struct {
+	// Version is the version of the migration.
+	Version int64
+	// Name is the name of the migration.
+	Name string
+	// Applied is true if the migration has been applied.
+	Applied bool
+	// AppliedAt is the time the migration has been applied.
+	AppliedAt time.Time
+	// Modified is true if the migration has been changed after it has been applied.
+	Modified bool
+	// Missing is true if the migration has been applied but is unknown.
+	Missing bool
+}
+
+// schemaMigration is a row of the history table.
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// MigrationsOpts are the options for the migrations.
+type MigrationsOpts struct {
+	// HistoryTable is the name of the table with the applied migrations.
+	HistoryTable string
+	// LockID is the key of the Postgres advisory lock.
+	LockID int64
+	// Migrations are the migrations.
+	Migrations []Migration
+	// Sources are the file systems with SQL migrations.
+	Sources []fs.FS
+}
+
+// Configure is configuring the options.
+func (o *MigrationsOpts) Configure(opts ...MigrationsOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultMigrationsOpts returns the default options.
+func DefaultMigrationsOpts() *MigrationsOpts {
+	return &MigrationsOpts{
+		HistoryTable: DefaultHistoryTable,
+		LockID:       DefaultMigrationLockID,
+	}
+}
+
+// MigrationsOpt is a function that configures the options.
+type MigrationsOpt func(*MigrationsOpts)
+
+// WithHistoryTable is setting the name of the table with the applied migrations.
+func WithHistoryTable(name string) MigrationsOpt {
+	return func(opts *MigrationsOpts) {
+		opts.HistoryTable = name
+	}
+}
+
+// WithLockID is setting the key of the Postgres advisory lock.
+func WithLockID(id int64) MigrationsOpt {
+	return func(opts *MigrationsOpts) {
+		opts.LockID = id
+	}
+}
+
+// WithMigration is adding Go migrations.
+func WithMigration(migrations ...Migration) MigrationsOpt {
+	return func(opts *MigrationsOpts) {
+		opts.Migrations = append(opts.Migrations, migrations...)
+	}
+}
+
+// WithMigrationsFS is adding the SQL migrations of the file system,
+// named like 0001_create_users.up.sql and 0001_create_users.down.sql.
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	m, err := dbx.NewMigrations(db, dbx.WithMigrationsFS(migrations))
+func WithMigrationsFS(fsys fs.FS) MigrationsOpt {
+	return func(opts *MigrationsOpts) {
+		opts.Sources = append(opts.Sources, fsys)
+	}
+}
+
+var _ Migrator = (*Migrations)(nil)
+
+// Migrations applies versioned migrations and records them in a history table.
+// On Postgres, an advisory lock is held while migrating,
+// so that replicas that start at once do not migrate concurrently.
+type Migrations struct {
+	db         *gorm.DB
+	opts       *MigrationsOpts
+	migrations []Migration
+}
+
+// NewMigrations returns new migrations for the database.
+func NewMigrations(db *gorm.DB, opts ...MigrationsOpt) (*Migrations, error) {
+	options := DefaultMigrationsOpts()
+	options.Configure(opts...)
+
+	migrations := slices.Clone(options.Migrations)
+	for _, fsys := range options.Sources {
+		ms, err := loadMigrations(fsys)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, ms...)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateMigration, migrations[i].Version)
+		}
+	}
+
+	m := new(Migrations)
+	m.db = db
+	m.opts = options
+	m.migrations = migrations
+
+	return m, nil
+}
+
+// loadMigrations loads the SQL migrations in the root of the file system.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[int64]*Migration{}
+	for _, name := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(path.Base(name), ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("dbx: invalid migration file name %s", name)
+		}
+
+		digits, title, _ := strings.Cut(base, "_")
+
+		version, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("dbx: invalid migration version %s: %w", name, err)
+		}
+
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := index[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			index[version] = m
+		}
+
+		if m.Name != title {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateMigration, version)
+		}
+
+		fn := execSQL(string(b))
+		if direction == "down" {
+			m.Down = fn
+			continue
+		}
+
+		sum := sha256.Sum256(b)
+		m.Up, m.Checksum = fn, hex.EncodeToString(sum[:])
+	}
+
+	migrations := make([]Migration, 0, len(index))
+	for _, m := range index {
+		if m.Up == nil {
+			return nil, fmt.Errorf("dbx: migration %d has no up migration", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	return migrations, nil
+}
+
+func execSQL(sql string) MigrationFunc {
+	return func(_ context.Context, tx *gorm.DB) error {
+		return tx.Exec(sql).Error
+	}
+}
+
+// Migrate applies all pending migrations.
+func (m *Migrations) Migrate(ctx context.Context, _ ...any) error {
+	return m.Up(ctx)
+}
+
+// Up applies all pending migrations.
+func (m *Migrations) Up(ctx context.Context) error {
+	return m.UpTo(ctx, -1)
+}
+
+// UpTo applies the pending migrations up to and including the version, or all if it is negative.
+func (m *Migrations) UpTo(ctx context.Context, version int64) error {
+	return m.locked(ctx, func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if version >= 0 && mig.Version > version {
+				break
+			}
+
+			if a, ok := applied[mig.Version]; ok {
+				if a.Checksum != "" && mig.Checksum != "" && a.Checksum != mig.Checksum {
+					return NewQueryError(fmt.Sprintf("migrate %d_%s", mig.Version, mig.Name), ErrChecksumMismatch)
+				}
+
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := mig.Up(ctx, tx); err != nil {
+					return err
+				}
+
+				return tx.Table(m.opts.HistoryTable).Create(&schemaMigration{
+					Version:   mig.Version,
+					Name:      mig.Name,
+					Checksum:  mig.Checksum,
+					AppliedAt: time.Now().UTC(),
+				}).Error
+			})
+			if err != nil {
+				return NewQueryError(fmt.Sprintf("migrate %d_%s", mig.Version, mig.Name), err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down rolls back the applied migrations with a version greater than the version, in descending order.
+func (m *Migrations) Down(ctx context.Context, to int64) error {
+	return m.locked(ctx, func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range slices.Backward(m.migrations) {
+			if mig.Version <= to {
+				break
+			}
+
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+
+			if mig.Down == nil {
+				return NewQueryError(fmt.Sprintf("rollback %d_%s", mig.Version, mig.Name), ErrIrreversible)
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := mig.Down(ctx, tx); err != nil {
+					return err
+				}
+
+				return tx.Table(m.opts.HistoryTable).Delete(&schemaMigration{Version: mig.Version}).Error
+			})
+			if err != nil {
+				return NewQueryError(fmt.Sprintf("rollback %d_%s", mig.Version, mig.Name), err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Status returns the status of the known and the applied migrations, ordered by version.
+func (m *Migrations) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn := m.db.WithContext(ctx)
+
+	applied, err := m.applied(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := MigrationStatus{Version: mig.Version, Name: mig.Name}
+
+		if a, ok := applied[mig.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			s.Modified = a.Checksum != "" && mig.Checksum != "" && a.Checksum != mig.Checksum
+			delete(applied, mig.Version)
+		}
+
+		status = append(status, s)
+	}
+
+	for _, a := range applied {
+		status = append(status, MigrationStatus{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: a.AppliedAt, Missing: true})
+	}
+
+	slices.SortFunc(status, func(a, b MigrationStatus) int { return cmp.Compare(a.Version, b.Version) })
+
+	return status, nil
+}
+
+// applied returns the applied migrations by version and creates the history table if it does not exist.
+func (m *Migrations) applied(conn *gorm.DB) (map[int64]schemaMigration, error) {
+	if err := conn.Table(m.opts.HistoryTable).AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, NewQueryError("create migration history", err)
+	}
+
+	var rows []schemaMigration
+	if err := conn.Table(m.opts.HistoryTable).Find(&rows).Error; err != nil {
+		return nil, NewQueryError("read migration history", err)
+	}
+
+	applied := make(map[int64]schemaMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// locked runs the function on a single connection that holds the advisory lock on Postgres.
+func (m *Migrations) locked(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() != "postgres" {
+			return fn(conn)
+		}
+
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", m.opts.LockID).Error; err != nil {
+			return NewQueryError("acquire migration lock", err)
+		}
+
+		err := fn(conn)
+
+		// the lock is held by the session, it is released even if the context is canceled
+		// and the connection is discarded if that fails, so that it does not return to the pool with the lock
+		if uerr := conn.WithContext(context.WithoutCancel(ctx)).Exec("SELECT pg_advisory_unlock(?)", m.opts.LockID).Error; uerr != nil {
+			discardConn(conn)
+
+			return errors.Join(err, NewQueryError("release migration lock", uerr))
+		}
+
+		return err
+	})
+}
+
+// discardConn closes the connection of a session that was started with gorm.DB.Connection
+// instead of returning it to the pool.
+func discardConn(conn *gorm.DB) {
+	if c, ok := conn.Statement.ConnPool.(*sql.Conn); ok {
+		_ = c.Raw(func(any) error { return driver.ErrBadConn })
+	}
+}
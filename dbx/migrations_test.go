@@ -0,0 +1,181 @@
+package dbx_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/zeiss/pkg/dbx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func migrationsFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_books.up.sql":   {Data: []byte("CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT);")},
+		"0001_create_books.down.sql": {Data: []byte("DROP TABLE books;")},
+		"0002_add_author.up.sql":     {Data: []byte("ALTER TABLE books ADD COLUMN author TEXT;\nCREATE INDEX idx_books_author ON books (author);")},
+		"0002_add_author.down.sql":   {Data: []byte("DROP INDEX idx_books_author;\nALTER TABLE books DROP COLUMN author;")},
+	}
+}
+
+func seedBooks(version int64) dbx.Migration {
+	return dbx.Migration{
+		Version: version,
+		Name:    "seed_books",
+		Up: func(_ context.Context, tx *gorm.DB) error {
+			return tx.Exec("INSERT INTO books (id, title, author) VALUES (1, 'Dune', 'Herbert')").Error
+		},
+	}
+}
+
+func versions(status []dbx.MigrationStatus) (applied, pending []int64) {
+	for _, s := range status {
+		if s.Applied {
+			applied = append(applied, s.Version)
+		} else {
+			pending = append(pending, s.Version)
+		}
+	}
+
+	return applied, pending
+}
+
+func TestMigrationsUpDown(t *testing.T) {
+	db := newTestDB(t)
+
+	m, err := dbx.NewMigrations(db, dbx.WithMigrationsFS(migrationsFS()), dbx.WithMigration(seedBooks(3)))
+	require.NoError(t, err)
+
+	require.NoError(t, m.UpTo(t.Context(), 1))
+
+	status, err := m.Status(t.Context())
+	require.NoError(t, err)
+
+	applied, pending := versions(status)
+	assert.Equal(t, []int64{1}, applied)
+	assert.Equal(t, []int64{2, 3}, pending)
+	assert.Equal(t, "create_books", status[0].Name)
+
+	require.NoError(t, m.Migrate(t.Context()))
+	require.NoError(t, m.Up(t.Context()), "applied migrations are skipped")
+
+	var count int64
+	require.NoError(t, db.Table("books").Where("author = ?", "Herbert").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	require.ErrorIs(t, m.Down(t.Context(), 1), dbx.ErrIrreversible)
+
+	require.NoError(t, db.Exec("DELETE FROM schema_migrations WHERE version = 3").Error)
+	require.NoError(t, m.Down(t.Context(), 0))
+
+	status, err = m.Status(t.Context())
+	require.NoError(t, err)
+
+	applied, _ = versions(status)
+	assert.Empty(t, applied)
+	assert.False(t, db.Migrator().HasTable("books"))
+}
+
+func TestMigrationsChecksum(t *testing.T) {
+	db := newTestDB(t)
+	fsys := migrationsFS()
+
+	m, err := dbx.NewMigrations(db, dbx.WithMigrationsFS(fsys))
+	require.NoError(t, err)
+	require.NoError(t, m.Up(t.Context()))
+
+	fsys["0001_create_books.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE books (id INTEGER PRIMARY KEY);")}
+	delete(fsys, "0002_add_author.up.sql")
+	delete(fsys, "0002_add_author.down.sql")
+
+	m, err = dbx.NewMigrations(db, dbx.WithMigrationsFS(fsys))
+	require.NoError(t, err)
+
+	status, err := m.Status(t.Context())
+	require.NoError(t, err)
+	require.Len(t, status, 2)
+	assert.True(t, status[0].Modified)
+	assert.True(t, status[1].Missing)
+
+	require.ErrorIs(t, m.Up(t.Context()), dbx.ErrChecksumMismatch)
+}
+
+func TestMigrationsFailedMigration(t *testing.T) {
+	db := newTestDB(t)
+
+	m, err := dbx.NewMigrations(db, dbx.WithMigrationsFS(fstest.MapFS{
+		"1_create_books.up.sql": {Data: []byte("CREATE TABLE books (id INTEGER PRIMARY KEY);")},
+		"2_broken.up.sql":       {Data: []byte("CREATE TABLE books (id INTEGER PRIMARY KEY);")},
+	}))
+	require.NoError(t, err)
+
+	err = m.Up(t.Context())
+
+	var qerr *dbx.QueryError
+	require.ErrorAs(t, err, &qerr)
+	assert.Equal(t, "migrate 2_broken", qerr.Query)
+
+	status, err := m.Status(t.Context())
+	require.NoError(t, err)
+
+	applied, pending := versions(status)
+	assert.Equal(t, []int64{1}, applied)
+	assert.Equal(t, []int64{2}, pending)
+}
+
+func TestNewMigrationsInvalid(t *testing.T) {
+	db := newTestDB(t)
+
+	tests := []struct {
+		name string
+		opts []dbx.MigrationsOpt
+		err  error
+	}{
+		{
+			name: "duplicate version",
+			opts: []dbx.MigrationsOpt{dbx.WithMigrationsFS(migrationsFS()), dbx.WithMigration(seedBooks(2))},
+			err:  dbx.ErrDuplicateMigration,
+		},
+		{
+			name: "invalid name",
+			opts: []dbx.MigrationsOpt{dbx.WithMigrationsFS(fstest.MapFS{"create_books.sql": {}})},
+		},
+		{
+			name: "missing up",
+			opts: []dbx.MigrationsOpt{dbx.WithMigrationsFS(fstest.MapFS{"1_create_books.down.sql": {}})},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := dbx.NewMigrations(db, tc.opts...)
+			require.Error(t, err)
+
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestDatabaseWithMigrator(t *testing.T) {
+	db := newTestDB(t)
+
+	m, err := dbx.NewMigrations(db, dbx.WithMigrationsFS(migrationsFS()), dbx.WithHistoryTable("versions"))
+	require.NoError(t, err)
+
+	store, err := dbx.NewDatabase(
+		db,
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		dbx.WithMigrator(m),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Migrate(t.Context()))
+	assert.True(t, db.Migrator().HasTable("versions"))
+	assert.True(t, db.Migrator().HasColumn("books", "author"))
+}
@@ -0,0 +1,516 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/zeiss/pkg/logx"
+	"github.com/zeiss/pkg/notify"
+	"github.com/zeiss/pkg/server"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxChannel is the Postgres channel that is notified when messages are enqueued.
+const OutboxChannel = "dbx_outbox"
+
+// ErrNoSink is returned when a relay has no sink to deliver the messages to.
+var ErrNoSink = errors.New("dbx: outbox relay has no sink")
+
+// Message is a message that is enqueued in the outbox.
+type Message struct {
+	// Key is the idempotency key of the message, a message with a key
+	// that has already been enqueued is discarded. Defaults to a random key.
+	Key string
+	// Topic is the topic of the message.
+	Topic string
+	// Payload is the payload of the message.
+	Payload []byte
+}
+
+// OutboxMessage is a message in the outbox table.
+type OutboxMessage struct {
+	// ID is the sequence number of the message.
+	ID uint64 `gorm:"primaryKey"`
+	// Key is the idempotency key of the message, consumers can use it to discard duplicates.
+	Key string `gorm:"uniqueIndex;size:255"`
+	// Topic is the topic of the message.
+	Topic string `gorm:"index"`
+	// Payload is the payload of the message.
+	Payload []byte
+	// Attempts is the number of failed deliveries.
+	Attempts int
+	// LastError is the error of the last failed delivery.
+	LastError string
+	// AvailableAt is the time of the next delivery.
+	AvailableAt time.Time `gorm:"index"`
+	// CreatedAt is the time the message has been enqueued.
+	CreatedAt time.Time
+	// DeliveredAt is the time the message has been delivered.
+	DeliveredAt *time.Time `gorm:"index"`
+	// DeadAt is the time the message has been dead-lettered after too many failed deliveries.
+	DeadAt *time.Time `gorm:"index"`
+}
+
+// TableName returns the name of the outbox table.
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// Enqueue adds the messages to the outbox in the transaction, so that they are
+// only delivered if the transaction is committed. On Postgres, relays that
+// listen on the OutboxChannel are notified on commit.
+//
+//	err := store.ReadWriteTx(ctx, func(ctx context.Context, tx *gorm.DB) error {
+//		if err := tx.Create(&order).Error; err != nil {
+//			return err
+//		}
+//
+//		return dbx.Enqueue(ctx, tx, dbx.Message{Key: order.ID, Topic: "orders.created", Payload: payload})
+//	})
+func Enqueue(ctx context.Context, tx *gorm.DB, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	rows := make([]OutboxMessage, 0, len(msgs))
+	for _, m := range msgs {
+		key := m.Key
+		if key == "" {
+			key = uuid.NewString()
+		}
+
+		rows = append(rows, OutboxMessage{Key: key, Topic: m.Topic, Payload: m.Payload, AvailableAt: now, CreatedAt: now})
+	}
+
+	db := tx.WithContext(ctx)
+
+	err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "key"}}, DoNothing: true}).Create(&rows).Error
+	if err != nil {
+		return NewQueryError("enqueue", err)
+	}
+
+	if db.Dialector.Name() == "postgres" {
+		if err := db.Exec("SELECT pg_notify(?, '')", OutboxChannel).Error; err != nil {
+			return NewQueryError("notify outbox", err)
+		}
+	}
+
+	return nil
+}
+
+// OutboxSink delivers messages of the outbox, e.g. to a broker or a notify.Notifier.
+// Messages are delivered at least once, so the sink should discard duplicates by their key.
+type OutboxSink interface {
+	// Deliver delivers the message.
+	Deliver(ctx context.Context, msg *OutboxMessage) error
+}
+
+// OutboxSinkFunc is a function that delivers messages of the outbox.
+type OutboxSinkFunc func(ctx context.Context, msg *OutboxMessage) error
+
+// Deliver delivers the message.
+func (f OutboxSinkFunc) Deliver(ctx context.Context, msg *OutboxMessage) error {
+	return f(ctx, msg)
+}
+
+// NotifierSink returns a sink that sends the messages as notifications,
+// with the topic as title and the payload as message.
+func NotifierSink(n notify.Notifier, config ...notify.Config) OutboxSink {
+	return OutboxSinkFunc(func(ctx context.Context, msg *OutboxMessage) error {
+		return n.Notify(ctx, msg.Topic, string(msg.Payload), config...)
+	})
+}
+
+// DefaultOutboxBackoff is the default backoff between failed deliveries of a message.
+var DefaultOutboxBackoff = server.Backoff{
+	Initial:    time.Second,
+	Max:        10 * time.Minute,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// OutboxRelayOpts are the options for the outbox relay.
+type OutboxRelayOpts struct {
+	// Sink is the sink the messages are delivered to.
+	Sink OutboxSink
+	// Interval is the time between polls of the outbox.
+	Interval time.Duration
+	// BatchSize is the number of messages that are delivered in one poll.
+	BatchSize int
+	// MaxAttempts is the number of failed deliveries after which a message is dead-lettered.
+	MaxAttempts int
+	// Backoff is the backoff between failed deliveries of a message,
+	// and between polls after the relay failed.
+	Backoff server.Backoff
+	// Lease is the time a claimed message is hidden from other relays while it is delivered,
+	// it is delivered again after the lease if the relay did not record the delivery.
+	Lease time.Duration
+	// OnError is called with the errors of the relay and its listener before they back off,
+	// it defaults to logging them.
+	OnError func(ctx context.Context, err error)
+	// Listen is listening on the OutboxChannel on Postgres to deliver
+	// enqueued messages without waiting for the next poll.
+	Listen bool
+}
+
+// Configure is configuring the options.
+func (o *OutboxRelayOpts) Configure(opts ...OutboxRelayOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultOutboxRelayOpts returns the default options.
+func DefaultOutboxRelayOpts() *OutboxRelayOpts {
+	return &OutboxRelayOpts{
+		Interval:    time.Second,
+		BatchSize:   DefaultBatchSize,
+		MaxAttempts: 10,
+		Backoff:     DefaultOutboxBackoff,
+		Lease:       time.Minute,
+		OnError:     logRelayError,
+		Listen:      true,
+	}
+}
+
+// OutboxRelayOpt is a function that configures the options.
+type OutboxRelayOpt func(*OutboxRelayOpts)
+
+// WithSink is setting the sink the messages are delivered to.
+func WithSink(sink OutboxSink) OutboxRelayOpt {
+	return func(opts *OutboxRelayOpts) {
+		opts.Sink = sink
+	}
+}
+
+// WithPollInterval is setting the time between polls of the outbox.
+func WithPollInterval(interval time.Duration) OutboxRelayOpt {
+	return func(opts *OutboxRelayOpts) {
+		opts.Interval = interval
+	}
+}
+
+// WithRelayBatchSize is setting the number of messages that are delivered in one poll.
+func WithRelayBatchSize(size int) OutboxRelayOpt {
+	return func(opts *OutboxRelayOpts) {
+		opts.BatchSize = size
+	}
+}
+
+// WithMaxAttempts is setting the number of failed deliveries after which a message is dead-lettered.
+func WithMaxAttempts(attempts int) OutboxRelayOpt {
+	return func(opts *OutboxRelayOpts) {
+		opts.MaxAttempts = attempts
+	}
+}
+
+// WithRelayBackoff is setting the backoff between failed deliveries of a message.
+func WithRelayBackoff(backoff server.Backoff) OutboxRelayOpt {
+	return func(opts *OutboxRelayOpts) {
+		opts.Backoff = backoff
+	}
+}
+
+// WithLease is setting the time a claimed message is hidden from other relays while it is delivered.
+func WithLease(lease time.Duration) OutboxRelayOpt {
+	return func(opts *OutboxRelayOpts) {
+		opts.Lease = lease
+	}
+}
+
+// WithRelayErrorHandler is setting the function that is called with the errors of the relay.
+func WithRelayErrorHandler(fn func(ctx context.Context, err error)) OutboxRelayOpt {
+	return func(opts *OutboxRelayOpts) {
+		opts.OnError = fn
+	}
+}
+
+func logRelayError(_ context.Context, err error) {
+	logx.LogSink.Errorw("outbox relay failed", "error", err)
+}
+
+// WithListen is listening on the OutboxChannel on Postgres, or only polling if false.
+func WithListen(listen ...bool) OutboxRelayOpt {
+	return func(opts *OutboxRelayOpts) {
+		opts.Listen = len(listen) == 0 || listen[0]
+	}
+}
+
+var _ server.Listener = (*OutboxRelay)(nil)
+
+// OutboxRelay delivers the messages of the outbox to the sink at least once.
+// Failed deliveries are retried with backoff, and dead-lettered after the maximum attempts.
+// Errors of the database do not stop the relay, it backs off and polls again.
+// On Postgres, multiple relays can run concurrently, and they are woken up by notifications.
+//
+//	relay := dbx.NewOutboxRelay(db, dbx.WithSink(sink))
+//	s.ListenWith(relay, server.WithName("outbox"))
+type OutboxRelay struct {
+	db   *gorm.DB
+	opts *OutboxRelayOpts
+}
+
+// NewOutboxRelay returns a new relay for the outbox of the database.
+func NewOutboxRelay(db *gorm.DB, opts ...OutboxRelayOpt) *OutboxRelay {
+	options := DefaultOutboxRelayOpts()
+	options.Configure(opts...)
+
+	r := new(OutboxRelay)
+	r.db = db
+	r.opts = options
+
+	return r
+}
+
+// Start is delivering the messages until the context is done.
+func (r *OutboxRelay) Start(ctx context.Context, ready server.ReadyFunc, run server.RunFunc) func() error {
+	return func() error {
+		if r.opts.Sink == nil {
+			return ErrNoSink
+		}
+
+		wake := make(chan struct{}, 1)
+		if r.opts.Listen && r.db.Dialector.Name() == "postgres" {
+			run(func() error {
+				return r.listen(ctx, wake)
+			})
+		}
+
+		ready()
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		failures := 0
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-timer.C:
+			case <-wake:
+			}
+
+			n, err := r.Relay(ctx)
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if err != nil {
+				if r.opts.OnError != nil {
+					r.opts.OnError(ctx, err)
+				}
+
+				timer.Reset(r.opts.Backoff.Delay(failures))
+				failures++
+
+				continue
+			}
+
+			failures = 0
+
+			if n == r.opts.BatchSize {
+				timer.Reset(0)
+				continue
+			}
+
+			timer.Reset(r.opts.Interval)
+		}
+	}
+}
+
+// listen wakes up the relay on notifications of the channel, it returns if the connection is not a pgx connection.
+// A lost connection is reported and listened on again with backoff, the relay keeps polling meanwhile.
+func (r *OutboxRelay) listen(ctx context.Context, wake chan<- struct{}) error {
+	failures := 0
+
+	for {
+		listening, err := r.listenConn(ctx, wake)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+
+		if listening {
+			failures = 0
+		}
+
+		if r.opts.OnError != nil {
+			r.opts.OnError(ctx, NewQueryError("listen outbox", err))
+		}
+
+		timer := time.NewTimer(r.opts.Backoff.Delay(failures))
+		failures++
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// listenConn listens on a connection of the pool until it fails,
+// and reports if it has been listening. The relay is woken up once it listens
+// to deliver the messages that have been enqueued without a listener.
+func (r *OutboxRelay) listenConn(ctx context.Context, wake chan<- struct{}) (bool, error) {
+	db, err := r.db.DB()
+	if err != nil {
+		return false, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	listening := false
+
+	err = conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return nil
+		}
+
+		pgc := c.Conn()
+		if _, err := pgc.Exec(ctx, "LISTEN "+OutboxChannel); err != nil {
+			return err
+		}
+		listening = true
+
+		for {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+
+			if _, err := pgc.WaitForNotification(ctx); err != nil {
+				return err
+			}
+		}
+	})
+
+	return listening, err
+}
+
+// Relay delivers a batch of the available messages and returns the number of messages that were processed.
+// The messages are claimed with a lease in a short transaction, so that they are not held locked while the sink delivers them.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	msgs, err := r.claim(ctx)
+	if err != nil {
+		return 0, NewQueryError("claim outbox", err)
+	}
+
+	var errs []error
+
+	for i := range msgs {
+		msg := &msgs[i]
+
+		// a message that has been delivered by another relay after the lease expired is not reset
+		err := r.db.WithContext(ctx).Model(msg).Where("delivered_at IS NULL").Updates(r.deliver(ctx, msg)).Error
+		if err != nil {
+			errs = append(errs, NewQueryError("relay outbox", err))
+		}
+	}
+
+	return len(msgs), errors.Join(errs...)
+}
+
+// claim selects a batch of the available messages and hides them from other relays for the lease.
+func (r *OutboxRelay) claim(ctx context.Context) ([]OutboxMessage, error) {
+	var msgs []OutboxMessage
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		q := tx.Where("delivered_at IS NULL AND dead_at IS NULL AND available_at <= ?", now).
+			Order("id").
+			Limit(r.opts.BatchSize)
+
+		if tx.Dialector.Name() == "postgres" {
+			q = q.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked})
+		}
+
+		if err := q.Find(&msgs).Error; err != nil {
+			return err
+		}
+
+		if len(msgs) == 0 {
+			return nil
+		}
+
+		ids := make([]uint64, 0, len(msgs))
+		for _, m := range msgs {
+			ids = append(ids, m.ID)
+		}
+
+		return tx.Model(&OutboxMessage{}).Where("id IN ?", ids).Update("available_at", now.Add(r.opts.Lease)).Error
+	})
+
+	return msgs, err
+}
+
+// deliver delivers the message and returns the updates of its row.
+func (r *OutboxRelay) deliver(ctx context.Context, msg *OutboxMessage) map[string]any {
+	now := time.Now().UTC()
+
+	err := r.opts.Sink.Deliver(ctx, msg)
+	if err == nil {
+		return map[string]any{"delivered_at": now, "last_error": ""}
+	}
+
+	updates := map[string]any{
+		"attempts":     msg.Attempts + 1,
+		"last_error":   err.Error(),
+		"available_at": now.Add(r.opts.Backoff.Delay(msg.Attempts)),
+	}
+
+	if msg.Attempts+1 >= r.opts.MaxAttempts {
+		updates["dead_at"] = now
+	}
+
+	return updates
+}
+
+// DeadLetters returns the messages that have been dead-lettered.
+func (r *OutboxRelay) DeadLetters(ctx context.Context) ([]OutboxMessage, error) {
+	var msgs []OutboxMessage
+	if err := r.db.WithContext(ctx).Where("dead_at IS NOT NULL").Order("id").Find(&msgs).Error; err != nil {
+		return nil, NewQueryError("dead letters", err)
+	}
+
+	return msgs, nil
+}
+
+// Redrive makes the dead-lettered messages with the ids available for delivery again.
+func (r *OutboxRelay) Redrive(ctx context.Context, ids ...uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).Model(&OutboxMessage{}).
+		Where("id IN ? AND dead_at IS NOT NULL", ids).
+		Updates(map[string]any{"dead_at": nil, "attempts": 0, "available_at": time.Now().UTC()}).Error
+	if err != nil {
+		return NewQueryError("redrive", err)
+	}
+
+	return nil
+}
+
+// Purge deletes the delivered messages that are older than the time.
+func (r *OutboxRelay) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("delivered_at < ?", before).Delete(&OutboxMessage{})
+	if res.Error != nil {
+		return 0, NewQueryError("purge", res.Error)
+	}
+
+	return res.RowsAffected, nil
+}
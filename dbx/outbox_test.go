@@ -0,0 +1,307 @@
+package dbx_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/dbx"
+	"github.com/zeiss/pkg/notify"
+	"github.com/zeiss/pkg/server"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/logger"
+)
+
+type recordingSink struct {
+	sync.Mutex
+	keys []string
+	fail map[string]bool
+}
+
+func (s *recordingSink) Deliver(_ context.Context, msg *dbx.OutboxMessage) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.fail[msg.Key] {
+		return errors.New("broker unavailable")
+	}
+	s.keys = append(s.keys, msg.Key)
+
+	return nil
+}
+
+func (s *recordingSink) delivered() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	return append([]string(nil), s.keys...)
+}
+
+func TestEnqueueInTransaction(t *testing.T) {
+	db := newTestDB(t, &dbx.OutboxMessage{})
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		require.NoError(t, dbx.Enqueue(t.Context(), tx, dbx.Message{Key: "a", Topic: "orders"}))
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return dbx.Enqueue(
+			t.Context(), tx,
+			dbx.Message{Key: "a", Topic: "orders", Payload: []byte("1")},
+			dbx.Message{Key: "a", Topic: "orders", Payload: []byte("2")},
+			dbx.Message{Topic: "orders"},
+		)
+	})
+	require.NoError(t, err)
+
+	var msgs []dbx.OutboxMessage
+	require.NoError(t, db.Order("id").Find(&msgs).Error)
+	require.Len(t, msgs, 2, "duplicate keys are discarded")
+	assert.Equal(t, []byte("1"), msgs[0].Payload)
+	assert.NotEmpty(t, msgs[1].Key)
+}
+
+func TestOutboxRelayRetriesAndDeadLetters(t *testing.T) {
+	db := newTestDB(t, &dbx.OutboxMessage{})
+
+	require.NoError(t, dbx.Enqueue(
+		t.Context(), db,
+		dbx.Message{Key: "a", Topic: "orders"},
+		dbx.Message{Key: "b", Topic: "orders"},
+		dbx.Message{Key: "c", Topic: "orders"},
+	))
+
+	sink := &recordingSink{fail: map[string]bool{"b": true}}
+	relay := dbx.NewOutboxRelay(
+		db,
+		dbx.WithSink(sink),
+		dbx.WithMaxAttempts(2),
+		dbx.WithRelayBackoff(server.Backoff{}),
+	)
+
+	n, err := relay.Relay(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []string{"a", "c"}, sink.delivered())
+
+	n, err = relay.Relay(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only the failed message is retried")
+
+	n, err = relay.Relay(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "the failed message is dead-lettered")
+
+	dead, err := relay.DeadLetters(t.Context())
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "b", dead[0].Key)
+	assert.Equal(t, 2, dead[0].Attempts)
+	assert.Equal(t, "broker unavailable", dead[0].LastError)
+
+	sink.fail = nil
+	require.NoError(t, relay.Redrive(t.Context(), dead[0].ID))
+
+	n, err = relay.Relay(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"a", "c", "b"}, sink.delivered())
+
+	purged, err := relay.Purge(t.Context(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+}
+
+func TestOutboxRelayListener(t *testing.T) {
+	db := newTestDB(t, &dbx.OutboxMessage{})
+
+	conn, err := db.DB()
+	require.NoError(t, err)
+	conn.SetMaxOpenConns(1)
+
+	sink := &recordingSink{}
+	relay := dbx.NewOutboxRelay(db, dbx.WithSink(sink), dbx.WithPollInterval(10*time.Millisecond), dbx.WithRelayBatchSize(1))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	ready := make(chan struct{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- relay.Start(ctx, func() { close(ready) }, func(fn func() error) { go fn() })()
+	}()
+	<-ready
+
+	require.NoError(t, dbx.Enqueue(t.Context(), db, dbx.Message{Key: "a"}, dbx.Message{Key: "b"}))
+
+	assert.Eventually(t, func() bool { return len(sink.delivered()) == 2 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestOutboxRelayLease(t *testing.T) {
+	db := newTestDB(t, &dbx.OutboxMessage{})
+
+	conn, err := db.DB()
+	require.NoError(t, err)
+	conn.SetMaxOpenConns(1)
+
+	require.NoError(t, dbx.Enqueue(t.Context(), db, dbx.Message{Key: "a"}))
+
+	other := dbx.NewOutboxRelay(db, dbx.WithSink(&recordingSink{}))
+
+	var (
+		claimed  int
+		claimErr error
+	)
+	relay := dbx.NewOutboxRelay(db, dbx.WithSink(dbx.OutboxSinkFunc(func(ctx context.Context, _ *dbx.OutboxMessage) error {
+		// the claim is committed before the delivery, so the only connection is free
+		// and the leased message is hidden from the other relay
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		claimed, claimErr = other.Relay(ctx)
+
+		return claimErr
+	})))
+
+	n, err := relay.Relay(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, claimErr)
+	assert.Equal(t, 0, claimed)
+
+	n, err = relay.Relay(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "the message has been delivered")
+}
+
+func TestOutboxRelayBacksOffOnErrors(t *testing.T) {
+	// the outbox table does not exist
+	db := newTestDB(t)
+
+	var errs atomic.Int32
+	relay := dbx.NewOutboxRelay(
+		db,
+		dbx.WithSink(&recordingSink{}),
+		dbx.WithRelayBackoff(server.Backoff{Initial: time.Millisecond, Max: time.Millisecond}),
+		dbx.WithRelayErrorHandler(func(context.Context, error) { errs.Add(1) }),
+	)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- relay.Start(ctx, func() {}, func(fn func() error) { go fn() })()
+	}()
+
+	assert.Eventually(t, func() bool { return errs.Load() >= 3 }, time.Second, time.Millisecond)
+
+	select {
+	case err := <-done:
+		t.Fatalf("relay returned: %v", err)
+	default:
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+// unreachablePostgres is a postgres database that refuses all connections.
+type unreachablePostgres struct {
+	gorm.Dialector
+}
+
+func (unreachablePostgres) Name() string {
+	return "postgres"
+}
+
+func (unreachablePostgres) Initialize(db *gorm.DB) error {
+	cfg, err := pgx.ParseConfig("postgres://127.0.0.1:1/outbox?connect_timeout=1")
+	if err != nil {
+		return err
+	}
+
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	db.ConnPool = stdlib.OpenDB(*cfg)
+
+	return nil
+}
+
+func TestOutboxRelayReconnectsListener(t *testing.T) {
+	db, err := gorm.Open(unreachablePostgres{Dialector: sqlite.Open("")}, &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+
+	var listenErrs atomic.Int32
+	relay := dbx.NewOutboxRelay(
+		db,
+		dbx.WithSink(&recordingSink{}),
+		dbx.WithPollInterval(time.Hour),
+		dbx.WithRelayBackoff(server.Backoff{Initial: time.Millisecond, Max: time.Millisecond}),
+		dbx.WithRelayErrorHandler(func(_ context.Context, err error) {
+			if strings.Contains(err.Error(), "listen outbox") {
+				listenErrs.Add(1)
+			}
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 2)
+
+	go func() {
+		done <- relay.Start(ctx, func() {}, func(fn func() error) { go func() { done <- fn() }() })()
+	}()
+
+	assert.Eventually(t, func() bool { return listenErrs.Load() >= 3 }, 5*time.Second, time.Millisecond, "the listener connects again")
+
+	select {
+	case err := <-done:
+		t.Fatalf("relay returned: %v", err)
+	default:
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+	require.NoError(t, <-done)
+}
+
+func TestOutboxRelayNoSink(t *testing.T) {
+	relay := dbx.NewOutboxRelay(newTestDB(t))
+	require.ErrorIs(t, relay.Start(t.Context(), func() {}, func(func() error) {})(), dbx.ErrNoSink)
+}
+
+type notifier struct {
+	title, message string
+}
+
+func (n *notifier) Notify(_ context.Context, title, message string, _ ...notify.Config) error {
+	n.title, n.message = title, message
+	return nil
+}
+
+func TestNotifierSink(t *testing.T) {
+	n := &notifier{}
+
+	err := dbx.NotifierSink(n).Deliver(t.Context(), &dbx.OutboxMessage{Topic: "welcome", Payload: []byte("hello")})
+	require.NoError(t, err)
+	assert.Equal(t, "welcome", n.title)
+	assert.Equal(t, "hello", n.message)
+}
@@ -1,7 +1,6 @@
 package dbx
 
 import (
-	"fmt"
 	"math"
 
 	"github.com/zeiss/pkg/utilx"
@@ -114,6 +113,8 @@ type Results[T any] struct {
 	SearchFields []string `json:"-"`
 	// Sort is the sorting order.
 	Sort string `json:"sort,omitempty" xml:"sort" form:"sort" query:"sort"`
+	// Spec is the sort order, the filters and the search of the query, see ParseQuerySpec.
+	Spec *QuerySpec `json:"-" xml:"-" form:"-" query:"-"`
 	// TotalRows is the total number of rows.
 	TotalRows int `json:"total_rows"`
 	// TotalPages is the total number of pages.
@@ -182,29 +183,34 @@ func (p *Results[T]) GetLen() int {
 // PaginatedResults returns a function that paginates the results.
 func PaginatedResults[T any](value interface{}, pagination *Results[T], db *gorm.DB) func(db *gorm.DB) *gorm.DB {
 	var totalRows int64
-	db.Model(value).Scopes(SearchScope(pagination)).Count(&totalRows).Where("deleted_at IS NULL")
+	db.Model(value).Scopes(SearchScope(pagination), specScope(value, pagination.Spec)).Count(&totalRows).Where("deleted_at IS NULL")
 
 	pagination.TotalRows = int(totalRows)
 	totalPages := int(math.Ceil(float64(totalRows) / float64(pagination.Limit)))
 	pagination.TotalPages = totalPages
 
 	return func(db *gorm.DB) *gorm.DB {
-		db = db.Scopes(SearchScope(pagination))
+		db = db.Scopes(SearchScope(pagination), specScope(value, pagination.Spec))
 		return db.Offset(pagination.GetOffset()).Limit(pagination.GetLimit())
 	}
 }
 
 // SearchScope is a function that returns a scope that searches the given fields.
+// A row matches if any of the fields contains the search term, ignoring the case.
 func SearchScope[T any](pagination *Results[T]) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
-		if utilx.Empty(pagination.GetSearch()) {
+		if utilx.Empty(pagination.GetSearch()) || len(pagination.SearchFields) == 0 {
 			return db
 		}
 
-		for _, field := range pagination.SearchFields {
-			db = db.Where(fmt.Sprintf("%s LIKE ? ", field), fmt.Sprintf("%%%s%%", pagination.GetSearch()))
-		}
+		return db.Scopes(searchScope(pagination.SearchFields, pagination.GetSearch()))
+	}
+}
 
-		return db
+func specScope(value any, spec *QuerySpec) func(db *gorm.DB) *gorm.DB {
+	if spec == nil {
+		return func(db *gorm.DB) *gorm.DB { return db }
 	}
+
+	return spec.Scope(value)
 }
@@ -2,11 +2,10 @@ package pg
 
 import (
 	"context"
-	"fmt"
+	"net"
 	"strconv"
 	"strings"
-
-	"github.com/zeiss/pkg/utilx"
+	"time"
 )
 
 type contextKey int
@@ -31,7 +30,8 @@ func FromContext(ctx context.Context) *Config {
 type Config struct {
 	// Database is the name of the database.
 	Database string `envconfig:"PG_DB"`
-	// Host is the host of the database.
+	// Host is the host of the database, or a comma-separated list of hosts
+	// that are tried in order, each optionally with a port, e.g. pg-0:5432,pg-1:5433.
 	Host string `envconfig:"PG_HOST"`
 	// Password is the password of the database.
 	Password string `envconfig:"PG_PASSWORD"`
@@ -41,52 +41,89 @@ type Config struct {
 	SslMode string `envconfig:"PG_SSL_MODE" default:"disable"`
 	// User is the user of the database.
 	User string `envconfig:"PG_USER"`
+	// Replicas are the hosts of the read replicas, optionally with a port, e.g. replica-0:5433.
+	Replicas []string `envconfig:"PG_REPLICAS"`
+	// MaxOpenConns is the maximum number of open connections of each pool, zero is unlimited.
+	MaxOpenConns int `envconfig:"PG_MAX_OPEN_CONNS" default:"10"`
+	// MaxIdleConns is the maximum number of idle connections of each pool, zero keeps the default of database/sql.
+	MaxIdleConns int `envconfig:"PG_MAX_IDLE_CONNS" default:"2"`
+	// ConnMaxLifetime is the maximum time a connection is reused, zero is forever.
+	ConnMaxLifetime time.Duration `envconfig:"PG_CONN_MAX_LIFETIME" default:"30m"`
+	// ConnMaxIdleTime is the maximum time a connection is idle, zero is forever.
+	ConnMaxIdleTime time.Duration `envconfig:"PG_CONN_MAX_IDLE_TIME" default:"5m"`
+	// ConnectTimeout is the maximum time to wait for a connection, zero is forever.
+	ConnectTimeout time.Duration `envconfig:"PG_CONNECT_TIMEOUT"`
+	// ApplicationName is the name of the application that is reported to the server.
+	ApplicationName string `envconfig:"PG_APPLICATION_NAME"`
+	// SslRootCert is the path of the certificate authorities to verify the server certificate.
+	SslRootCert string `envconfig:"PG_SSL_ROOT_CERT"`
+	// SslCert is the path of the client certificate.
+	SslCert string `envconfig:"PG_SSL_CERT"`
+	// SslKey is the path of the key of the client certificate.
+	SslKey string `envconfig:"PG_SSL_KEY"`
+	// TargetSessionAttrs is the kind of server to connect to with multiple hosts, e.g. read-write.
+	TargetSessionAttrs string `envconfig:"PG_TARGET_SESSION_ATTRS"`
+	// SearchPath is the schema search path of the sessions.
+	SearchPath string `envconfig:"PG_SEARCH_PATH"`
+	// PassFile is the path of the password file, defaults to ~/.pgpass.
+	PassFile string `envconfig:"PG_PASSFILE"`
+	// Params are further parameters that are passed to the driver.
+	Params map[string]string
 }
 
 // NewConfig returns a new Config instance.
 func NewConfig() *Config {
 	return &Config{
-		Port:    5432,
-		SslMode: "disable",
+		Port:            5432,
+		SslMode:         "disable",
+		MaxOpenConns:    10,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: 30 * time.Minute,
+		ConnMaxIdleTime: 5 * time.Minute,
 	}
 }
 
 // FormatDSN formats the given Config into a DSN string which can be passed to the driver.
+// Values are quoted and escaped as needed, multiple hosts are rendered as lists of hosts and ports.
 func (c *Config) FormatDSN() string {
-	var params []string
+	params := c.params()
 
-	if utilx.NotEmpty(c.Database) {
-		params = append(params, "dbname="+c.escape(c.Database))
+	pairs := make([]string, 0, len(params))
+	for _, p := range params {
+		pairs = append(pairs, p.key+"="+escape(p.value))
 	}
 
-	if utilx.NotEmpty(c.User) {
-		params = append(params, "user="+c.escape(c.User))
-	}
+	return strings.Join(pairs, " ")
+}
 
-	if utilx.NotEmpty(c.Password) {
-		params = append(params, "password="+c.escape(c.Password))
-	}
+// FormatReplicaDSNs formats a DSN string for each of the replicas,
+// with the database and the credentials of the Config. The TargetSessionAttrs
+// of the primary are not used, as a replica is a standby.
+func (c *Config) FormatReplicaDSNs() []string {
+	dsns := make([]string, 0, len(c.Replicas))
 
-	if utilx.NotEmpty(c.Host) {
-		params = append(params, "host="+c.escape(c.Host))
-	}
+	for _, host := range c.Replicas {
+		replica := *c
+		replica.Host = host
+		replica.TargetSessionAttrs = ""
 
-	if utilx.NotEmpty(c.Port) {
-		params = append(params, "port="+strconv.Itoa(c.Port))
-	}
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			if port, err := strconv.Atoi(p); err == nil {
+				replica.Host, replica.Port = h, port
+			}
+		}
 
-	if utilx.NotEmpty(c.SslMode) {
-		params = append(params, "sslmode="+c.escape(c.SslMode))
+		dsns = append(dsns, replica.FormatDSN())
 	}
 
-	return strings.Join(params, " ")
+	return dsns
 }
 
-func (c *Config) escape(str string) string {
-	if !strings.Contains(str, " ") {
+// escape quotes the value of a keyword DSN if it is empty or contains spaces, quotes or backslashes.
+func escape(str string) string {
+	if str != "" && !strings.ContainsAny(str, " \t\n\r\\'") {
 		return str
 	}
 
-	str = strings.ReplaceAll(str, "'", "\\'")
-	return fmt.Sprintf("'%s'", str)
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(str) + "'"
 }
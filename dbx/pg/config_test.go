@@ -49,3 +49,27 @@ func TestContext(t *testing.T) {
 	ctx := config.Context(t.Context())
 	assert.Equal(t, config, pg.FromContext(ctx))
 }
+
+func TestFormatReplicaDSNs(t *testing.T) {
+	t.Parallel()
+
+	config := &pg.Config{
+		Database: "test_db",
+		Host:     "primary",
+		Port:     5432,
+		User:     "test_user",
+		Replicas: []string{"replica-0", "replica-1:5433"},
+	}
+
+	assert.Equal(t, []string{
+		"dbname=test_db user=test_user host=replica-0 port=5432",
+		"dbname=test_db user=test_user host=replica-1 port=5433",
+	}, config.FormatReplicaDSNs())
+	config.Host = "primary-0,primary-1"
+	config.TargetSessionAttrs = "read-write"
+
+	assert.Equal(t, []string{
+		"dbname=test_db user=test_user host=replica-0 port=5432",
+		"dbname=test_db user=test_user host=replica-1 port=5433",
+	}, config.FormatReplicaDSNs(), "the replicas are standbys")
+}
@@ -0,0 +1,101 @@
+package pg
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/zeiss/pkg/dbx"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// DB is a PostgreSQL database with a primary and its read replicas.
+//
+//	db, err := cfg.Open(&gorm.Config{})
+//	store, err := dbx.NewDatabase(db.Primary, readFactory, writeFactory, db.DatabaseOpts()...)
+type DB struct {
+	// Primary is the pool of the primary, it is used for ReadWriteTx.
+	Primary *gorm.DB
+	// Replicas are the pools of the read replicas, they are used for ReadTx.
+	Replicas []*gorm.DB
+}
+
+// Open opens the pools of the primary and the replicas with the limits of the Config.
+func (c *Config) Open(opts ...gorm.Option) (*DB, error) {
+	primary, err := c.open(c.FormatDSN(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{Primary: primary}
+
+	for _, dsn := range c.FormatReplicaDSNs() {
+		replica, err := c.open(dsn, opts...)
+		if err != nil {
+			return nil, errors.Join(err, db.Close())
+		}
+
+		db.Replicas = append(db.Replicas, replica)
+	}
+
+	return db, nil
+}
+
+func (c *Config) open(dsn string, opts ...gorm.Option) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	c.configure(conn)
+
+	return db, nil
+}
+
+// configure sets the limits of the pool that are set, zero values keep the defaults of database/sql.
+func (c *Config) configure(conn *sql.DB) {
+	if c.MaxOpenConns > 0 {
+		conn.SetMaxOpenConns(c.MaxOpenConns)
+	}
+
+	if c.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(c.MaxIdleConns)
+	}
+
+	if c.ConnMaxLifetime > 0 {
+		conn.SetConnMaxLifetime(c.ConnMaxLifetime)
+	}
+
+	if c.ConnMaxIdleTime > 0 {
+		conn.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+	}
+}
+
+// DatabaseOpts returns the options that use the replicas for ReadTx of a dbx.Database.
+func (db *DB) DatabaseOpts() []dbx.DatabaseOpt {
+	if len(db.Replicas) == 0 {
+		return nil
+	}
+
+	return []dbx.DatabaseOpt{dbx.WithReplicas(db.Replicas...)}
+}
+
+// Close closes the pools.
+func (db *DB) Close() error {
+	var errs []error
+
+	for _, g := range append([]*gorm.DB{db.Primary}, db.Replicas...) {
+		conn, err := g.DB()
+		if err == nil {
+			err = conn.Close()
+		}
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,47 @@
+package pg_test
+
+import (
+	"testing"
+
+	"github.com/zeiss/pkg/dbx/pg"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	config := pg.NewConfig()
+	config.Host = "localhost"
+	config.MaxOpenConns = 5
+	config.Replicas = []string{"replica-0", "replica-1:5433"}
+
+	db, err := config.Open(&gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.Len(t, db.Replicas, 2)
+	assert.Len(t, db.DatabaseOpts(), 1)
+
+	for _, g := range append(db.Replicas, db.Primary) {
+		conn, err := g.DB()
+		require.NoError(t, err)
+		assert.Equal(t, 5, conn.Stats().MaxOpenConnections)
+	}
+}
+
+func TestOpenZeroLimits(t *testing.T) {
+	t.Parallel()
+
+	config := &pg.Config{Host: "localhost", Port: 5432}
+
+	db, err := config.Open(&gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	defer db.Close()
+
+	conn, err := db.Primary.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 0, conn.Stats().MaxOpenConnections)
+}
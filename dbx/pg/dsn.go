@@ -0,0 +1,376 @@
+package pg
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidDSN is returned when a DSN or URL cannot be parsed.
+var ErrInvalidDSN = errors.New("pg: invalid dsn")
+
+type param struct {
+	key   string
+	value string
+}
+
+// params returns the libpq parameters of the Config in a stable order.
+func (c *Config) params() []param {
+	var params []param
+
+	add := func(key, value string) {
+		if value != "" {
+			params = append(params, param{key, value})
+		}
+	}
+
+	hosts, ports := c.hosts()
+
+	add("dbname", c.Database)
+	add("user", c.User)
+	add("password", c.Password)
+	add("host", strings.Join(hosts, ","))
+	add("port", strings.Join(ports, ","))
+	add("sslmode", c.SslMode)
+	add("sslrootcert", c.SslRootCert)
+	add("sslcert", c.SslCert)
+	add("sslkey", c.SslKey)
+
+	if c.ConnectTimeout > 0 {
+		add("connect_timeout", strconv.Itoa(int(max(c.ConnectTimeout/time.Second, 1))))
+	}
+
+	add("application_name", c.ApplicationName)
+	add("target_session_attrs", c.TargetSessionAttrs)
+	add("search_path", c.SearchPath)
+	add("passfile", c.PassFile)
+
+	for _, key := range slices.Sorted(maps.Keys(c.Params)) {
+		add(key, c.Params[key])
+	}
+
+	return params
+}
+
+// hosts returns the hosts and their ports, the ports are collapsed to one if they are all the same.
+func (c *Config) hosts() ([]string, []string) {
+	var hosts, ports []string
+
+	port := ""
+	if c.Port != 0 {
+		port = strconv.Itoa(c.Port)
+	}
+
+	for _, h := range strings.Split(c.Host, ",") {
+		if h = strings.TrimSpace(h); h == "" {
+			continue
+		}
+
+		p := port
+		if host, hp, err := net.SplitHostPort(h); err == nil {
+			h, p = host, hp
+		}
+
+		hosts = append(hosts, h)
+		ports = append(ports, p)
+	}
+
+	if len(ports) == 0 && port != "" {
+		ports = append(ports, port)
+	}
+
+	if len(ports) > 1 && !slices.ContainsFunc(ports, func(p string) bool { return p != ports[0] }) {
+		ports = ports[:1]
+	}
+
+	if slices.Contains(ports, "") {
+		ports = nil
+	}
+
+	return hosts, ports
+}
+
+// FormatURL formats the Config into a postgres:// URL, multiple hosts are rendered as a comma-separated list.
+func (c *Config) FormatURL() string {
+	u := &url.URL{Scheme: "postgres", Path: "/" + c.Database}
+
+	if c.Database == "" {
+		u.Path = ""
+	}
+
+	if c.User != "" || c.Password != "" {
+		u.User = url.User(c.User)
+		if c.Password != "" {
+			u.User = url.UserPassword(c.User, c.Password)
+		}
+	}
+
+	hosts, ports := c.hosts()
+
+	addrs := make([]string, 0, len(hosts))
+	for i, h := range hosts {
+		p := ""
+		switch {
+		case len(ports) == len(hosts):
+			p = ports[i]
+		case len(ports) == 1:
+			p = ports[0]
+		}
+
+		if p == "" {
+			addrs = append(addrs, h)
+			continue
+		}
+
+		addrs = append(addrs, net.JoinHostPort(h, p))
+	}
+	u.Host = strings.Join(addrs, ",")
+
+	query := url.Values{}
+	for _, p := range c.params() {
+		switch p.key {
+		case "dbname", "user", "password", "host", "port":
+			continue
+		}
+		query.Set(p.key, p.value)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// ParseDSN parses a postgres:// or postgresql:// URL, or a keyword DSN like "host=localhost dbname=app",
+// into a Config with the defaults of NewConfig. Unknown parameters are kept in Params.
+func ParseDSN(dsn string) (*Config, error) {
+	var (
+		params []param
+		err    error
+	)
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		params, err = parseURL(dsn)
+	} else {
+		params, err = parseKeywords(dsn)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewConfig()
+	if err := c.set(params); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func parseURL(dsn string) ([]param, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidDSN, err)
+	}
+
+	var params []param
+
+	if u.User != nil {
+		params = append(params, param{"user", u.User.Username()})
+
+		if password, ok := u.User.Password(); ok {
+			params = append(params, param{"password", password})
+		}
+	}
+
+	var hosts, ports []string
+	for _, h := range strings.Split(u.Host, ",") {
+		if h == "" {
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(h)
+		if err != nil {
+			host, port = strings.Trim(h, "[]"), ""
+		}
+
+		hosts = append(hosts, host)
+		ports = append(ports, port)
+	}
+
+	if len(hosts) > 0 {
+		params = append(params, param{"host", strings.Join(hosts, ",")})
+	}
+
+	if slices.ContainsFunc(ports, func(p string) bool { return p != "" }) {
+		params = append(params, param{"port", strings.Join(ports, ",")})
+	}
+
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		params = append(params, param{"dbname", db})
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidDSN, err)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		params = append(params, param{key, query.Get(key)})
+	}
+
+	return params, nil
+}
+
+// parseKeywords parses a keyword DSN, values can be quoted with single quotes
+// and escape quotes and backslashes with a backslash.
+func parseKeywords(dsn string) ([]param, error) {
+	var params []param
+
+	s := strings.TrimSpace(dsn)
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: missing = after %q", ErrInvalidDSN, s)
+		}
+
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t\n\r") {
+			return nil, fmt.Errorf("%w: invalid key %q", ErrInvalidDSN, key)
+		}
+
+		rest = strings.TrimLeft(rest, " \t\n\r")
+
+		var value strings.Builder
+
+		quoted := strings.HasPrefix(rest, "'")
+		if quoted {
+			rest = rest[1:]
+		}
+
+		i, closed := 0, !quoted
+		for ; i < len(rest); i++ {
+			ch := rest[i]
+
+			if ch == '\\' && i+1 < len(rest) {
+				i++
+				value.WriteByte(rest[i])
+
+				continue
+			}
+
+			if quoted && ch == '\'' {
+				closed = true
+				i++
+
+				break
+			}
+
+			if !quoted && strings.ContainsRune(" \t\n\r", rune(ch)) {
+				break
+			}
+
+			value.WriteByte(ch)
+		}
+
+		if !closed {
+			return nil, fmt.Errorf("%w: unterminated quote of %s", ErrInvalidDSN, key)
+		}
+
+		params = append(params, param{key, value.String()})
+		s = strings.TrimLeft(rest[i:], " \t\n\r")
+	}
+
+	return params, nil
+}
+
+// set sets the fields of the Config from the parameters.
+func (c *Config) set(params []param) error {
+	var hosts, ports []string
+
+	for _, p := range params {
+		switch p.key {
+		case "dbname":
+			c.Database = p.value
+		case "user":
+			c.User = p.value
+		case "password":
+			c.Password = p.value
+		case "host":
+			hosts = strings.Split(p.value, ",")
+		case "port":
+			ports = strings.Split(p.value, ",")
+		case "sslmode":
+			c.SslMode = p.value
+		case "sslrootcert":
+			c.SslRootCert = p.value
+		case "sslcert":
+			c.SslCert = p.value
+		case "sslkey":
+			c.SslKey = p.value
+		case "connect_timeout":
+			seconds, err := strconv.Atoi(p.value)
+			if err != nil {
+				return fmt.Errorf("%w: connect_timeout: %w", ErrInvalidDSN, err)
+			}
+			c.ConnectTimeout = time.Duration(seconds) * time.Second
+		case "application_name":
+			c.ApplicationName = p.value
+		case "target_session_attrs":
+			c.TargetSessionAttrs = p.value
+		case "search_path":
+			c.SearchPath = p.value
+		case "passfile":
+			c.PassFile = p.value
+		default:
+			if c.Params == nil {
+				c.Params = map[string]string{}
+			}
+			c.Params[p.key] = p.value
+		}
+	}
+
+	return c.setHosts(hosts, ports)
+}
+
+// setHosts sets the hosts and the ports, a single port applies to all hosts.
+func (c *Config) setHosts(hosts, ports []string) error {
+	if len(ports) > 1 && len(ports) != len(hosts) {
+		return fmt.Errorf("%w: %d ports for %d hosts", ErrInvalidDSN, len(ports), len(hosts))
+	}
+
+	nums := make([]int, len(ports))
+	for i, p := range ports {
+		if p == "" {
+			nums[i] = c.Port
+			continue
+		}
+
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return fmt.Errorf("%w: port: %w", ErrInvalidDSN, err)
+		}
+		nums[i] = n
+	}
+
+	if len(nums) > 0 {
+		c.Port = nums[0]
+	}
+
+	if len(nums) <= 1 || !slices.ContainsFunc(nums, func(n int) bool { return n != nums[0] }) {
+		c.Host = strings.Join(hosts, ",")
+		return nil
+	}
+
+	addrs := make([]string, 0, len(hosts))
+	for i, h := range hosts {
+		addrs = append(addrs, net.JoinHostPort(h, strconv.Itoa(nums[i])))
+	}
+	c.Host = strings.Join(addrs, ",")
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package pg
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jackc/pgpassfile"
+	"github.com/zeiss/pkg/envx"
+)
+
+// FromEnv returns a Config from the environment variables of the envconfig tags,
+// with the password from the password file if it is not set.
+func FromEnv() (*Config, error) {
+	return Load(os.LookupEnv)
+}
+
+// Load returns a Config from the variables of the envconfig tags that are looked up,
+// with the password from the password file if it is not set.
+func Load(lookup envx.LookupFunc) (*Config, error) {
+	c := new(Config)
+	if err := envx.LoadEnv(c, lookup); err != nil {
+		return nil, err
+	}
+
+	if err := c.LoadPassword(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// LoadPassword sets the password from the password file of the first host, if it is not set.
+// The password file defaults to ~/.pgpass, which is ignored if it does not exist.
+func (c *Config) LoadPassword() error {
+	if c.Password != "" {
+		return nil
+	}
+
+	path := c.PassFile
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(home, ".pgpass")
+	}
+
+	pf, err := pgpassfile.ReadPassfile(path)
+	if errors.Is(err, fs.ErrNotExist) && c.PassFile == "" {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	host, port := "localhost", strconv.Itoa(c.Port)
+	if hosts, ports := c.hosts(); len(hosts) > 0 {
+		host = hosts[0]
+		if len(ports) > 0 {
+			port = ports[0]
+		}
+	}
+
+	c.Password = pf.FindPassword(host, port, c.Database, c.User)
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package pg_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/dbx/pg"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	passfile := filepath.Join(t.TempDir(), "pgpass")
+	require.NoError(t, os.WriteFile(passfile, []byte("pg-0:5433:app:app:from-file\n*:*:*:*:other\n"), 0o600))
+
+	config, err := pg.Load(lookup(map[string]string{
+		"PG_DB":              "app",
+		"PG_HOST":            "pg-0:5433,pg-1:5433",
+		"PG_USER":            "app",
+		"PG_REPLICAS":        "replica-0, replica-1",
+		"PG_CONNECT_TIMEOUT": "3s",
+		"PG_PASSFILE":        passfile,
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "app", config.Database)
+	assert.Equal(t, "from-file", config.Password)
+	assert.Equal(t, 5432, config.Port)
+	assert.Equal(t, "disable", config.SslMode)
+	assert.Equal(t, []string{"replica-0", "replica-1"}, config.Replicas)
+	assert.Equal(t, 3*time.Second, config.ConnectTimeout)
+	assert.Equal(t, 30*time.Minute, config.ConnMaxLifetime)
+	assert.Equal(t, "dbname=app user=app password=from-file host=pg-0,pg-1 port=5433 sslmode=disable connect_timeout=3 passfile="+passfile, config.FormatDSN())
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := pg.Load(lookup(map[string]string{"PG_PORT": "fivefourthreetwo"}))
+	require.ErrorContains(t, err, "PG_PORT")
+
+	_, err = pg.Load(lookup(map[string]string{"PG_PASSFILE": filepath.Join(t.TempDir(), "missing")}))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
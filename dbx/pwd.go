@@ -1,9 +1,18 @@
 package dbx
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
 )
 
 var (
@@ -11,24 +20,435 @@ var (
 	ErrFailedToHashPassword = errors.New("dbx: failed to hash password")
 	// ErrFailedCheckPassword is returned when the password check fails.
 	ErrFailedCheckPassword = errors.New("dbx: failed to check password")
+	// ErrUnknownHashFormat is returned when a hash is in none of the formats of the hasher.
+	ErrUnknownHashFormat = errors.New("dbx: unknown password hash format")
 )
 
-// HashPassword returns the bcrypt hash of the password.
-func HashPassword(password []byte) ([]byte, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+// PasswordScheme is an algorithm to hash passwords.
+type PasswordScheme interface {
+	// Hash returns the hash of the password.
+	Hash(password []byte) ([]byte, error)
+	// Verify returns ErrFailedCheckPassword if the password does not match the hash.
+	Verify(password, hash []byte) error
+	// Identify returns true if the hash is in the format of the scheme.
+	Identify(hash []byte) bool
+	// NeedsRehash returns true if the hash has other parameters than the scheme.
+	NeedsRehash(hash []byte) bool
+}
+
+// DefaultArgon2id are the recommended argon2id parameters of RFC 9106 with 64 MiB of memory.
+var DefaultArgon2id = Argon2id{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32, SaltLen: 16}
+
+// DefaultScrypt are the recommended scrypt parameters for interactive logins.
+var DefaultScrypt = Scrypt{N: 1 << 15, R: 8, P: 1, KeyLen: 32, SaltLen: 16}
+
+// DefaultBcrypt is bcrypt with the default cost.
+var DefaultBcrypt = Bcrypt{Cost: bcrypt.DefaultCost}
+
+// DefaultPasswordHasher hashes new passwords with argon2id and verifies bcrypt and scrypt hashes.
+var DefaultPasswordHasher = NewPasswordHasher(DefaultArgon2id, DefaultBcrypt, DefaultScrypt)
+
+// PasswordHasher hashes passwords with a scheme and verifies the hashes of it and of legacy schemes,
+// so that hashes can be upgraded to the scheme when the users log in.
+type PasswordHasher struct {
+	scheme PasswordScheme
+	legacy []PasswordScheme
+}
+
+// NewPasswordHasher returns a hasher that hashes with the scheme and also verifies the legacy schemes.
+func NewPasswordHasher(scheme PasswordScheme, legacy ...PasswordScheme) *PasswordHasher {
+	h := new(PasswordHasher)
+	h.scheme = scheme
+	h.legacy = legacy
+
+	return h
+}
+
+// Hash returns the hash of the password.
+func (h *PasswordHasher) Hash(password []byte) ([]byte, error) {
+	hash, err := h.scheme.Hash(password)
 	if err != nil {
 		return nil, errors.Join(ErrFailedToHashPassword, err)
 	}
 
-	return hashedPassword, nil
+	return hash, nil
+}
+
+func (h *PasswordHasher) identify(hash []byte) (PasswordScheme, error) {
+	if h.scheme.Identify(hash) {
+		return h.scheme, nil
+	}
+
+	for _, s := range h.legacy {
+		if s.Identify(hash) {
+			return s, nil
+		}
+	}
+
+	return nil, ErrUnknownHashFormat
+}
+
+// Verify returns ErrFailedCheckPassword if the password does not match the hash.
+func (h *PasswordHasher) Verify(password, hash []byte) error {
+	s, err := h.identify(hash)
+	if err != nil {
+		return errors.Join(ErrFailedCheckPassword, err)
+	}
+
+	return s.Verify(password, hash)
+}
+
+// NeedsRehash returns true if the hash is of a legacy scheme or has other parameters than the scheme.
+func (h *PasswordHasher) NeedsRehash(hash []byte) bool {
+	return !h.scheme.Identify(hash) || h.scheme.NeedsRehash(hash)
+}
+
+// CheckAndUpgrade verifies the password and returns a new hash to persist if the hash needs a rehash,
+// or nil if it is up to date.
+//
+//	upgraded, err := dbx.CheckAndUpgrade(password, user.Password)
+//	if err != nil {
+//		return err
+//	}
+//
+//	if upgraded != nil {
+//		user.Password = upgraded
+//	}
+func (h *PasswordHasher) CheckAndUpgrade(password, hash []byte) ([]byte, error) {
+	if err := h.Verify(password, hash); err != nil {
+		return nil, err
+	}
+
+	if !h.NeedsRehash(hash) {
+		return nil, nil
+	}
+
+	return h.Hash(password)
+}
+
+// HashPassword returns the hash of the password with the DefaultPasswordHasher.
+func HashPassword(password []byte) ([]byte, error) {
+	return DefaultPasswordHasher.Hash(password)
 }
 
 // CheckPassword checks if the provided password is correct or not.
 func CheckPassword(password []byte, hashedPassword []byte) error {
-	err := bcrypt.CompareHashAndPassword(hashedPassword, password)
+	return DefaultPasswordHasher.Verify(password, hashedPassword)
+}
+
+// NeedsRehash returns true if the hash should be upgraded with the DefaultPasswordHasher.
+func NeedsRehash(hashedPassword []byte) bool {
+	return DefaultPasswordHasher.NeedsRehash(hashedPassword)
+}
+
+// CheckAndUpgrade verifies the password with the DefaultPasswordHasher and returns a new hash
+// to persist if the hash needs a rehash, or nil if it is up to date.
+func CheckAndUpgrade(password, hashedPassword []byte) ([]byte, error) {
+	return DefaultPasswordHasher.CheckAndUpgrade(password, hashedPassword)
+}
+
+var b64 = base64.RawStdEncoding
+
+func salt(n int) ([]byte, error) {
+	s := make([]byte, n)
+	_, err := rand.Read(s)
+
+	return s, err
+}
+
+// minKeyLen is the minimum length of a stored hash that is accepted.
+const minKeyLen = 16
+
+// maxCostFactor is how many times the configured or the default cost a stored hash may require,
+// a tampered hash could exhaust the memory or the CPU otherwise.
+const maxCostFactor = 4
+
+// maxCost returns the maximum cost of a stored hash.
+func maxCost(configured, def uint64) uint64 {
+	return maxCostFactor * max(configured, def)
+}
+
+// phc is a hash in the PHC string format, $id$v=version$params$salt$hash.
+type phc struct {
+	id      string
+	version string
+	params  map[string]string
+	salt    []byte
+	hash    []byte
+}
+
+func parsePHC(s []byte) (*phc, error) {
+	fields := strings.Split(string(s), "$")
+	if len(fields) < 5 || fields[0] != "" {
+		return nil, ErrUnknownHashFormat
+	}
+
+	p := &phc{id: fields[1], params: map[string]string{}}
+
+	fields = fields[2:]
+	if v, ok := strings.CutPrefix(fields[0], "v="); ok {
+		p.version, fields = v, fields[1:]
+	}
+
+	if len(fields) != 3 {
+		return nil, ErrUnknownHashFormat
+	}
+
+	for _, kv := range strings.Split(fields[0], ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, ErrUnknownHashFormat
+		}
+		p.params[k] = v
+	}
+
+	var err error
+	if p.salt, err = b64.DecodeString(fields[1]); err != nil {
+		return nil, errors.Join(ErrUnknownHashFormat, err)
+	}
+
+	if p.hash, err = b64.DecodeString(fields[2]); err != nil {
+		return nil, errors.Join(ErrUnknownHashFormat, err)
+	}
+
+	if len(p.salt) == 0 || len(p.hash) < minKeyLen {
+		return nil, ErrUnknownHashFormat
+	}
+
+	return p, nil
+}
+
+func (p *phc) param(key string) (uint64, error) {
+	var v uint64
+	if _, err := fmt.Sscan(p.params[key], &v); err != nil {
+		return 0, errors.Join(ErrUnknownHashFormat, fmt.Errorf("parameter %s: %w", key, err))
+	}
+
+	return v, nil
+}
+
+func verifyKey(key, hash []byte) error {
+	if subtle.ConstantTimeCompare(key, hash) != 1 {
+		return ErrFailedCheckPassword
+	}
+
+	return nil
+}
+
+var _ PasswordScheme = Argon2id{}
+
+// Argon2id hashes passwords with argon2id in the PHC string format.
+type Argon2id struct {
+	// Time is the number of passes over the memory.
+	Time uint32
+	// Memory is the memory in KiB.
+	Memory uint32
+	// Threads is the number of threads.
+	Threads uint8
+	// KeyLen is the length of the hash.
+	KeyLen uint32
+	// SaltLen is the length of the random salt.
+	SaltLen int
+}
+
+// Hash returns the hash of the password.
+func (a Argon2id) Hash(password []byte) ([]byte, error) {
+	s, err := salt(a.SaltLen)
+	if err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey(password, s, a.Time, a.Memory, a.Threads, a.KeyLen)
+
+	return fmt.Appendf(nil, "$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Time, a.Threads, b64.EncodeToString(s), b64.EncodeToString(key)), nil
+}
+
+func (a Argon2id) parse(hash []byte) (*phc, Argon2id, error) {
+	p, err := parsePHC(hash)
+	if err != nil {
+		return nil, Argon2id{}, err
+	}
+
+	if p.id != "argon2id" || p.version != fmt.Sprint(argon2.Version) {
+		return nil, Argon2id{}, ErrUnknownHashFormat
+	}
+
+	m, err := p.param("m")
+	if err != nil {
+		return nil, Argon2id{}, err
+	}
+
+	t, err := p.param("t")
+	if err != nil {
+		return nil, Argon2id{}, err
+	}
+
+	threads, err := p.param("p")
+	if err != nil {
+		return nil, Argon2id{}, err
+	}
+
+	if t < 1 || t > maxCost(uint64(a.Time), uint64(DefaultArgon2id.Time)) ||
+		threads < 1 || threads > math.MaxUint8 ||
+		m < 8*threads || m > maxCost(uint64(a.Memory), uint64(DefaultArgon2id.Memory)) {
+		return nil, Argon2id{}, ErrUnknownHashFormat
+	}
+
+	params := Argon2id{Time: uint32(t), Memory: uint32(m), Threads: uint8(threads), KeyLen: uint32(len(p.hash)), SaltLen: len(p.salt)}
+
+	return p, params, nil
+}
+
+// Verify returns ErrFailedCheckPassword if the password does not match the hash.
+func (a Argon2id) Verify(password, hash []byte) error {
+	p, params, err := a.parse(hash)
+	if err != nil {
+		return errors.Join(ErrFailedCheckPassword, err)
+	}
+
+	return verifyKey(argon2.IDKey(password, p.salt, params.Time, params.Memory, params.Threads, params.KeyLen), p.hash)
+}
+
+// Identify returns true if the hash is an argon2id hash.
+func (Argon2id) Identify(hash []byte) bool {
+	return strings.HasPrefix(string(hash), "$argon2id$")
+}
+
+// NeedsRehash returns true if the hash has other parameters.
+func (a Argon2id) NeedsRehash(hash []byte) bool {
+	_, params, err := a.parse(hash)
+	return err != nil || params != a
+}
+
+var _ PasswordScheme = Scrypt{}
+
+// Scrypt hashes passwords with scrypt in the PHC string format.
+type Scrypt struct {
+	// N is the CPU and memory cost, a power of two.
+	N int
+	// R is the block size.
+	R int
+	// P is the parallelization.
+	P int
+	// KeyLen is the length of the hash.
+	KeyLen int
+	// SaltLen is the length of the random salt.
+	SaltLen int
+}
+
+// Hash returns the hash of the password.
+func (s Scrypt) Hash(password []byte) ([]byte, error) {
+	if s.N <= 1 || s.N&(s.N-1) != 0 {
+		return nil, fmt.Errorf("scrypt: N must be a power of two greater than one")
+	}
+
+	sl, err := salt(s.SaltLen)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key(password, sl, s.N, s.R, s.P, s.KeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	return fmt.Appendf(nil, "$scrypt$ln=%d,r=%d,p=%d$%s$%s",
+		bits.TrailingZeros(uint(s.N)), s.R, s.P, b64.EncodeToString(sl), b64.EncodeToString(key)), nil
+}
+
+func (s Scrypt) parse(hash []byte) (*phc, Scrypt, error) {
+	p, err := parsePHC(hash)
+	if err != nil {
+		return nil, Scrypt{}, err
+	}
+
+	if p.id != "scrypt" {
+		return nil, Scrypt{}, ErrUnknownHashFormat
+	}
+
+	ln, err := p.param("ln")
+	if err != nil {
+		return nil, Scrypt{}, err
+	}
+
+	r, err := p.param("r")
+	if err != nil {
+		return nil, Scrypt{}, err
+	}
+
+	par, err := p.param("p")
+	if err != nil {
+		return nil, Scrypt{}, err
+	}
+
+	if ln < 1 || ln > 31 || 1<<ln > maxCost(uint64(max(s.N, 0)), uint64(DefaultScrypt.N)) ||
+		r < 1 || r > maxCost(uint64(max(s.R, 0)), uint64(DefaultScrypt.R)) ||
+		par < 1 || par > maxCost(uint64(max(s.P, 0)), uint64(DefaultScrypt.P)) || r*par >= 1<<30 {
+		return nil, Scrypt{}, ErrUnknownHashFormat
+	}
+
+	params := Scrypt{N: 1 << ln, R: int(r), P: int(par), KeyLen: len(p.hash), SaltLen: len(p.salt)}
+
+	return p, params, nil
+}
+
+// Verify returns ErrFailedCheckPassword if the password does not match the hash.
+func (s Scrypt) Verify(password, hash []byte) error {
+	p, params, err := s.parse(hash)
+	if err != nil {
+		return errors.Join(ErrFailedCheckPassword, err)
+	}
+
+	key, err := scrypt.Key(password, p.salt, params.N, params.R, params.P, params.KeyLen)
 	if err != nil {
 		return errors.Join(ErrFailedCheckPassword, err)
 	}
 
+	return verifyKey(key, p.hash)
+}
+
+// Identify returns true if the hash is a scrypt hash.
+func (Scrypt) Identify(hash []byte) bool {
+	return strings.HasPrefix(string(hash), "$scrypt$")
+}
+
+// NeedsRehash returns true if the hash has other parameters.
+func (s Scrypt) NeedsRehash(hash []byte) bool {
+	_, params, err := s.parse(hash)
+	return err != nil || params != s
+}
+
+var _ PasswordScheme = Bcrypt{}
+
+// Bcrypt hashes passwords with bcrypt, which only uses the first 72 bytes of a password.
+type Bcrypt struct {
+	// Cost is the cost of the hash.
+	Cost int
+}
+
+// Hash returns the hash of the password.
+func (b Bcrypt) Hash(password []byte) ([]byte, error) {
+	return bcrypt.GenerateFromPassword(password, b.Cost)
+}
+
+// Verify returns ErrFailedCheckPassword if the password does not match the hash.
+func (Bcrypt) Verify(password, hash []byte) error {
+	if err := bcrypt.CompareHashAndPassword(hash, password); err != nil {
+		return errors.Join(ErrFailedCheckPassword, err)
+	}
+
 	return nil
 }
+
+// Identify returns true if the hash is a bcrypt hash.
+func (Bcrypt) Identify(hash []byte) bool {
+	s := string(hash)
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
+
+// NeedsRehash returns true if the hash has another cost.
+func (b Bcrypt) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost != b.Cost
+}
@@ -0,0 +1,135 @@
+package dbx_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zeiss/pkg/dbx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	fastArgon2id = dbx.Argon2id{Time: 1, Memory: 64, Threads: 1, KeyLen: 32, SaltLen: 16}
+	fastScrypt   = dbx.Scrypt{N: 16, R: 8, P: 1, KeyLen: 32, SaltLen: 16}
+	fastBcrypt   = dbx.Bcrypt{Cost: bcrypt.MinCost}
+)
+
+func TestPasswordSchemes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme dbx.PasswordScheme
+		prefix string
+	}{
+		{name: "argon2id", scheme: fastArgon2id, prefix: "$argon2id$v=19$m=64,t=1,p=1$"},
+		{name: "scrypt", scheme: fastScrypt, prefix: "$scrypt$ln=4,r=8,p=1$"},
+		{name: "bcrypt", scheme: fastBcrypt, prefix: "$2a$04$"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := tc.scheme.Hash([]byte("secret"))
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(hash), tc.prefix), string(hash))
+			assert.True(t, tc.scheme.Identify(hash))
+			assert.False(t, tc.scheme.NeedsRehash(hash))
+
+			require.NoError(t, tc.scheme.Verify([]byte("secret"), hash))
+			require.ErrorIs(t, tc.scheme.Verify([]byte("wrong"), hash), dbx.ErrFailedCheckPassword)
+		})
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	h := dbx.NewPasswordHasher(fastArgon2id, fastBcrypt)
+
+	hash, err := h.Hash([]byte("secret"))
+	require.NoError(t, err)
+	assert.False(t, h.NeedsRehash(hash))
+
+	stronger := fastArgon2id
+	stronger.Time = 2
+	assert.True(t, dbx.NewPasswordHasher(stronger).NeedsRehash(hash), "changed parameters need a rehash")
+
+	legacy, err := fastBcrypt.Hash([]byte("secret"))
+	require.NoError(t, err)
+	assert.True(t, h.NeedsRehash(legacy))
+}
+
+func TestPasswordHasherCheckAndUpgrade(t *testing.T) {
+	h := dbx.NewPasswordHasher(fastArgon2id, fastBcrypt)
+
+	legacy, err := fastBcrypt.Hash([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = h.CheckAndUpgrade([]byte("wrong"), legacy)
+	require.ErrorIs(t, err, dbx.ErrFailedCheckPassword)
+
+	upgraded, err := h.CheckAndUpgrade([]byte("secret"), legacy)
+	require.NoError(t, err)
+	assert.True(t, fastArgon2id.Identify(upgraded))
+	require.NoError(t, h.Verify([]byte("secret"), upgraded))
+
+	again, err := h.CheckAndUpgrade([]byte("secret"), upgraded)
+	require.NoError(t, err)
+	assert.Nil(t, again, "an up to date hash is not upgraded")
+
+	err = h.Verify([]byte("secret"), []byte("$md5$abc"))
+	require.ErrorIs(t, err, dbx.ErrFailedCheckPassword)
+	require.ErrorIs(t, err, dbx.ErrUnknownHashFormat)
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := dbx.HashPassword([]byte("secret"))
+	require.NoError(t, err)
+	assert.True(t, dbx.DefaultArgon2id.Identify(hash))
+	require.NoError(t, dbx.CheckPassword([]byte("secret"), hash))
+	assert.False(t, dbx.NeedsRehash(hash))
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, dbx.CheckPassword([]byte("secret"), legacy))
+	require.ErrorIs(t, dbx.CheckPassword([]byte("wrong"), legacy), dbx.ErrFailedCheckPassword)
+}
+
+func TestPasswordSchemesMalformed(t *testing.T) {
+	const key = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+	tests := []struct {
+		name   string
+		scheme dbx.PasswordScheme
+		hash   string
+	}{
+		{name: "argon2id zero time", scheme: fastArgon2id, hash: "$argon2id$v=19$m=65536,t=0,p=4$c2FsdHNhbHQ$" + key},
+		{name: "argon2id zero threads", scheme: fastArgon2id, hash: "$argon2id$v=19$m=65536,t=1,p=0$c2FsdHNhbHQ$" + key},
+		{name: "argon2id too many threads", scheme: fastArgon2id, hash: "$argon2id$v=19$m=65536,t=1,p=256$c2FsdHNhbHQ$" + key},
+		{name: "argon2id too little memory", scheme: fastArgon2id, hash: "$argon2id$v=19$m=31,t=1,p=4$c2FsdHNhbHQ$" + key},
+		{name: "argon2id too much memory", scheme: fastArgon2id, hash: "$argon2id$v=19$m=4294967296,t=1,p=4$c2FsdHNhbHQ$" + key},
+		{name: "argon2id memory above the maximum", scheme: fastArgon2id, hash: "$argon2id$v=19$m=1048576,t=1,p=4$c2FsdHNhbHQ$" + key},
+		{name: "argon2id time above the maximum", scheme: fastArgon2id, hash: "$argon2id$v=19$m=65536,t=100,p=4$c2FsdHNhbHQ$" + key},
+		{name: "argon2id empty salt", scheme: fastArgon2id, hash: "$argon2id$v=19$m=65536,t=1,p=4$$" + key},
+		{name: "argon2id empty hash", scheme: fastArgon2id, hash: "$argon2id$v=19$m=65536,t=1,p=4$c2FsdHNhbHQ$"},
+		{name: "argon2id short hash", scheme: fastArgon2id, hash: "$argon2id$v=19$m=65536,t=1,p=4$c2FsdHNhbHQ$aGFzaA"},
+		{name: "scrypt zero ln", scheme: fastScrypt, hash: "$scrypt$ln=0,r=8,p=1$c2FsdHNhbHQ$" + key},
+		{name: "scrypt large ln", scheme: fastScrypt, hash: "$scrypt$ln=32,r=8,p=1$c2FsdHNhbHQ$" + key},
+		{name: "scrypt cost above the maximum", scheme: fastScrypt, hash: "$scrypt$ln=20,r=8,p=1$c2FsdHNhbHQ$" + key},
+		{name: "scrypt zero r", scheme: fastScrypt, hash: "$scrypt$ln=4,r=0,p=1$c2FsdHNhbHQ$" + key},
+		{name: "scrypt r above the maximum", scheme: fastScrypt, hash: "$scrypt$ln=4,r=1024,p=1$c2FsdHNhbHQ$" + key},
+		{name: "scrypt zero p", scheme: fastScrypt, hash: "$scrypt$ln=4,r=8,p=0$c2FsdHNhbHQ$" + key},
+		{name: "scrypt p above the maximum", scheme: fastScrypt, hash: "$scrypt$ln=4,r=8,p=64$c2FsdHNhbHQ$" + key},
+		{name: "scrypt large r*p", scheme: fastScrypt, hash: "$scrypt$ln=4,r=1073741824,p=1$c2FsdHNhbHQ$" + key},
+		{name: "scrypt empty salt", scheme: fastScrypt, hash: "$scrypt$ln=4,r=8,p=1$$" + key},
+		{name: "scrypt empty hash", scheme: fastScrypt, hash: "$scrypt$ln=15,r=8,p=1$c2FsdHNhbHQ$"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.scheme.Verify([]byte("secret"), []byte(tc.hash))
+			require.ErrorIs(t, err, dbx.ErrFailedCheckPassword)
+			require.ErrorIs(t, err, dbx.ErrUnknownHashFormat)
+			assert.True(t, tc.scheme.NeedsRehash([]byte(tc.hash)))
+		})
+	}
+}
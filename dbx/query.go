@@ -0,0 +1,333 @@
+package dbx
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrColumnNotAllowed is returned when a column is not allowed to be sorted, filtered or searched by.
+	ErrColumnNotAllowed = errors.New("dbx: column not allowed")
+	// ErrInvalidFilter is returned when a filter has an unknown operator or a value of the wrong type.
+	ErrInvalidFilter = errors.New("dbx: invalid filter")
+)
+
+// Operator is the operator of a filter.
+type Operator string
+
+const (
+	// OpEq matches values that are equal.
+	OpEq Operator = "eq"
+	// OpNe matches values that are not equal.
+	OpNe Operator = "ne"
+	// OpGt matches values that are greater.
+	OpGt Operator = "gt"
+	// OpGte matches values that are greater or equal.
+	OpGte Operator = "gte"
+	// OpLt matches values that are less.
+	OpLt Operator = "lt"
+	// OpLte matches values that are less or equal.
+	OpLte Operator = "lte"
+	// OpIn matches values that are in a comma-separated list.
+	OpIn Operator = "in"
+	// OpLike matches values that contain the value, ignoring the case.
+	OpLike Operator = "like"
+	// OpNull matches values that are null for true, or not null for false.
+	OpNull Operator = "null"
+)
+
+var operators = map[Operator]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+// SortField is a column to sort by.
+type SortField struct {
+	// Column is the name of the column.
+	Column string
+	// Desc sorts the column in descending order.
+	Desc bool
+}
+
+// Filter is a condition on a column.
+type Filter struct {
+	// Column is the name of the column.
+	Column string
+	// Op is the operator of the condition.
+	Op Operator
+	// Value is the value to compare the column with.
+	Value string
+}
+
+// QuerySpec is the sort order, the filters and the search of a list query.
+//
+// The columns have to be allowed by the dbx tag of the fields of the model,
+// e.g. `dbx:"sort,filter,search"`.
+type QuerySpec struct {
+	// Sort is the sort order.
+	Sort []SortField
+	// Filters are the conditions that all have to match.
+	Filters []Filter
+	// Search is the term to search for in the searchable columns, ignoring the case.
+	Search string
+}
+
+// ParseQuerySpec parses the query spec from query parameters like
+// sort=-created_at,name&filter[status]=eq:active&filter[age]=gte:18&search=term.
+// Filters without an operator compare for equality.
+func ParseQuerySpec(values url.Values) (*QuerySpec, error) {
+	spec := &QuerySpec{Search: values.Get("search")}
+
+	for _, v := range values["sort"] {
+		for _, s := range strings.Split(v, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+
+			desc := strings.HasPrefix(s, "-")
+			spec.Sort = append(spec.Sort, SortField{Column: strings.TrimLeft(s, "+-"), Desc: desc})
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		column, ok := strings.CutPrefix(key, "filter[")
+		if !ok {
+			continue
+		}
+
+		column, ok = strings.CutSuffix(column, "]")
+		if !ok || column == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, key)
+		}
+
+		for _, v := range values[key] {
+			spec.Filters = append(spec.Filters, parseFilter(column, v))
+		}
+	}
+
+	return spec, nil
+}
+
+func parseFilter(column, value string) Filter {
+	op, v, ok := strings.Cut(value, ":")
+	if !ok {
+		return Filter{Column: column, Op: OpEq, Value: value}
+	}
+
+	switch o := Operator(op); o {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpLike, OpNull:
+		return Filter{Column: column, Op: o, Value: v}
+	default:
+		return Filter{Column: column, Op: OpEq, Value: value}
+	}
+}
+
+var querySchemas sync.Map
+
+// allowedField returns the field of the column if the dbx tag of the field allows the usage.
+func allowedField(s *schema.Schema, column, usage string) (*schema.Field, error) {
+	f := s.LookUpField(column)
+	if f == nil || f.DBName == "" || !slices.Contains(strings.Split(f.Tag.Get("dbx"), ","), usage) {
+		return nil, fmt.Errorf("%w: %s by %s", ErrColumnNotAllowed, usage, column)
+	}
+
+	return f, nil
+}
+
+// Validate checks that all columns are allowed for the model
+// and that the filter values match the types of the fields.
+func (s *QuerySpec) Validate(db *gorm.DB, model any) error {
+	_, err := s.build(db, model)
+	return err
+}
+
+// Scope returns a function that sorts, filters and searches the results of the model.
+func (s *QuerySpec) Scope(model any) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		scope, err := s.build(db, model)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+
+		return scope(db)
+	}
+}
+
+func (s *QuerySpec) build(db *gorm.DB, model any) (func(db *gorm.DB) *gorm.DB, error) {
+	sch, err := schema.Parse(model, &querySchemas, db.NamingStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	var scopes []func(db *gorm.DB) *gorm.DB
+
+	for _, f := range s.Filters {
+		field, err := allowedField(sch, f.Column, "filter")
+		if err != nil {
+			return nil, err
+		}
+
+		scope, err := filterScope(field, f)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, scope)
+	}
+
+	if s.Search != "" {
+		columns := make([]string, 0)
+		for _, field := range sch.Fields {
+			if _, err := allowedField(sch, field.Name, "search"); err == nil {
+				columns = append(columns, field.DBName)
+			}
+		}
+
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("%w: search", ErrColumnNotAllowed)
+		}
+		scopes = append(scopes, searchScope(columns, s.Search))
+	}
+
+	for _, sf := range s.Sort {
+		field, err := allowedField(sch, sf.Column, "sort")
+		if err != nil {
+			return nil, err
+		}
+
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB {
+			order := db.Statement.Quote(field.DBName)
+			if sf.Desc {
+				order += " DESC"
+			}
+
+			return db.Order(order)
+		})
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(scopes...)
+	}, nil
+}
+
+// filterScope returns the condition of the filter with the value typed like the field.
+func filterScope(field *schema.Field, f Filter) (func(db *gorm.DB) *gorm.DB, error) {
+	switch f.Op {
+	case OpNull:
+		null, err := strconv.ParseBool(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidFilter, f.Column, err)
+		}
+
+		return func(db *gorm.DB) *gorm.DB {
+			if null {
+				return db.Where(db.Statement.Quote(field.DBName) + " IS NULL")
+			}
+
+			return db.Where(db.Statement.Quote(field.DBName) + " IS NOT NULL")
+		}, nil
+	case OpLike:
+		return searchScope([]string{field.DBName}, f.Value), nil
+	case OpIn:
+		values := make([]any, 0)
+		for _, s := range strings.Split(f.Value, ",") {
+			v, err := parseValue(field, s)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrInvalidFilter, f.Column, err)
+			}
+			values = append(values, v)
+		}
+
+		return func(db *gorm.DB) *gorm.DB {
+			return db.Where(db.Statement.Quote(field.DBName)+" IN ?", values)
+		}, nil
+	}
+
+	op, ok := operators[f.Op]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s: unknown operator %q", ErrInvalidFilter, f.Column, f.Op)
+	}
+
+	v, err := parseValue(field, f.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidFilter, f.Column, err)
+	}
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(db.Statement.Quote(field.DBName)+" "+op+" ?", v)
+	}, nil
+}
+
+// parseValue parses the value into the type of the field.
+func parseValue(field *schema.Field, value string) (any, error) {
+	t := field.FieldType
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeFor[time.Time]() {
+		return time.Parse(time.RFC3339, value)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(value, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	default:
+		return value, nil
+	}
+}
+
+// searchScope returns a condition that matches if any of the columns contains the term, ignoring the case.
+// It uses ILIKE on Postgres.
+func searchScope(columns []string, term string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		pattern := "%" + escapeLike(term) + "%"
+
+		conds := make([]string, 0, len(columns))
+		args := make([]any, 0, len(columns))
+
+		for _, c := range columns {
+			if db.Dialector.Name() == "postgres" {
+				conds = append(conds, db.Statement.Quote(c)+` ILIKE ? ESCAPE '!'`)
+			} else {
+				conds = append(conds, "LOWER("+db.Statement.Quote(c)+`) LIKE LOWER(?) ESCAPE '!'`)
+			}
+			args = append(args, pattern)
+		}
+
+		return db.Where("("+strings.Join(conds, " OR ")+")", args...)
+	}
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern with '!', which unlike a backslash
+// is not an escape character of string literals on MySQL.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s)
+}
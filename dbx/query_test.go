@@ -0,0 +1,192 @@
+package dbx_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/dbx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type user struct {
+	ID        int    `dbx:"sort"`
+	Name      string `dbx:"sort,filter,search"`
+	Email     string `dbx:"search"`
+	Status    string `dbx:"filter"`
+	Age       int    `dbx:"sort,filter"`
+	Secret    string
+	DeletedAt *time.Time `dbx:"filter"`
+}
+
+func seedUsers(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	now := time.Now()
+	users := []user{
+		{ID: 1, Name: "Alice", Email: "alice@example.com", Status: "active", Age: 30},
+		{ID: 2, Name: "Bob", Email: "bob@example.com", Status: "active", Age: 17},
+		{ID: 3, Name: "Carol", Email: "carol@ALICE.org", Status: "inactive", Age: 45},
+		{ID: 4, Name: "Dave_100%", Email: "dave@example.com", Status: "active", Age: 18, DeletedAt: &now},
+	}
+	require.NoError(t, db.Create(&users).Error)
+}
+
+func userIDs(rows []user) []int {
+	ids := make([]int, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+
+	return ids
+}
+
+func TestParseQuerySpec(t *testing.T) {
+	values, err := url.ParseQuery("sort=-created_at,name&sort=+id&filter[status]=eq:active&filter[age]=gte:18&filter[age]=lt:65&filter[name]=Bob&filter[at]=10:00&search=foo")
+	require.NoError(t, err)
+
+	spec, err := dbx.ParseQuerySpec(values)
+	require.NoError(t, err)
+
+	assert.Equal(t, []dbx.SortField{{Column: "created_at", Desc: true}, {Column: "name"}, {Column: "id"}}, spec.Sort)
+	assert.Equal(t, []dbx.Filter{
+		{Column: "age", Op: dbx.OpGte, Value: "18"},
+		{Column: "age", Op: dbx.OpLt, Value: "65"},
+		{Column: "at", Op: dbx.OpEq, Value: "10:00"},
+		{Column: "name", Op: dbx.OpEq, Value: "Bob"},
+		{Column: "status", Op: dbx.OpEq, Value: "active"},
+	}, spec.Filters)
+	assert.Equal(t, "foo", spec.Search)
+
+	_, err = dbx.ParseQuerySpec(url.Values{"filter[]": {"x"}})
+	require.ErrorIs(t, err, dbx.ErrInvalidFilter)
+}
+
+func TestQuerySpecScope(t *testing.T) {
+	db := newTestDB(t, &user{})
+	seedUsers(t, db)
+
+	tests := []struct {
+		name  string
+		query string
+		ids   []int
+	}{
+		{name: "sort", query: "sort=-age", ids: []int{3, 1, 4, 2}},
+		{name: "sort multiple", query: "sort=name,-id", ids: []int{1, 2, 3, 4}},
+		{name: "filter", query: "filter[status]=eq:active&filter[age]=gte:18&sort=id", ids: []int{1, 4}},
+		{name: "filter ne", query: "filter[status]=ne:active", ids: []int{3}},
+		{name: "filter in", query: "filter[age]=in:17,45&sort=id", ids: []int{2, 3}},
+		{name: "filter null", query: "filter[deleted_at]=null:false", ids: []int{4}},
+		{name: "filter like", query: "filter[name]=like:AR", ids: []int{3}},
+		{name: "search across fields", query: "search=alice&sort=id", ids: []int{1, 3}},
+		{name: "search escapes wildcards", query: "search=_100%25", ids: []int{4}},
+		{name: "search and filter", query: "search=example&filter[age]=lt:20&sort=-id", ids: []int{4, 2}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			spec, err := dbx.ParseQuerySpec(values)
+			require.NoError(t, err)
+
+			var rows []user
+			require.NoError(t, db.Scopes(spec.Scope(&user{})).Find(&rows).Error)
+			assert.Equal(t, tc.ids, userIDs(rows))
+		})
+	}
+}
+
+func TestQuerySpecNotAllowed(t *testing.T) {
+	db := newTestDB(t, &user{})
+
+	tests := []struct {
+		name  string
+		query string
+		err   error
+	}{
+		{name: "sort not allowed", query: "sort=email", err: dbx.ErrColumnNotAllowed},
+		{name: "filter not allowed", query: "filter[secret]=x", err: dbx.ErrColumnNotAllowed},
+		{name: "unknown column", query: "sort=name%3Bdrop+table+users", err: dbx.ErrColumnNotAllowed},
+		{name: "wrong type", query: "filter[age]=gte:old", err: dbx.ErrInvalidFilter},
+		{name: "wrong null", query: "filter[deleted_at]=null:maybe", err: dbx.ErrInvalidFilter},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			spec, err := dbx.ParseQuerySpec(values)
+			require.NoError(t, err)
+
+			require.ErrorIs(t, spec.Validate(db, &user{}), tc.err)
+
+			var rows []user
+			require.ErrorIs(t, db.Scopes(spec.Scope(&user{})).Find(&rows).Error, tc.err)
+		})
+	}
+}
+
+type postgresDialector struct {
+	*sqlite.Dialector
+}
+
+func (postgresDialector) Name() string { return "postgres" }
+
+func TestQuerySpecSearchPostgres(t *testing.T) {
+	db, err := gorm.Open(postgresDialector{sqlite.Open(":memory:").(*sqlite.Dialector)}, &gorm.Config{})
+	require.NoError(t, err)
+
+	spec := &dbx.QuerySpec{Search: "alice"}
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Scopes(spec.Scope(&user{})).Find(&[]user{})
+	})
+	assert.Contains(t, sql, "(`name` ILIKE \"%alice%\" ESCAPE '!' OR `email` ILIKE \"%alice%\" ESCAPE '!')")
+}
+
+func TestQuerySpecSearchEscapesWildcards(t *testing.T) {
+	db := newTestDB(t, &user{})
+	require.NoError(t, db.Create(&[]user{
+		{ID: 1, Name: "50%"}, {ID: 2, Name: "500"}, {ID: 3, Name: "a_b"}, {ID: 4, Name: "axb"}, {ID: 5, Name: "hey!"}, {ID: 6, Name: "hey"},
+	}).Error)
+
+	tests := []struct {
+		search string
+		ids    []int
+	}{
+		{search: "0%", ids: []int{1}},
+		{search: "_", ids: []int{3}},
+		{search: "!", ids: []int{5}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.search, func(t *testing.T) {
+			var rows []user
+			require.NoError(t, db.Scopes((&dbx.QuerySpec{Search: tc.search}).Scope(&user{})).Find(&rows).Error)
+			assert.Equal(t, tc.ids, userIDs(rows))
+		})
+	}
+}
+
+func TestPaginatedResultsSpec(t *testing.T) {
+	db := newTestDB(t, &user{})
+	seedUsers(t, db)
+
+	results := dbx.NewResults[user]()
+	results.Limit = 1
+	results.Search = "EXAMPLE"
+	results.SearchFields = []string{"name", "email"}
+	results.Spec = &dbx.QuerySpec{Sort: []dbx.SortField{{Column: "age", Desc: true}}, Filters: []dbx.Filter{{Column: "status", Op: dbx.OpEq, Value: "active"}}}
+
+	require.NoError(t, db.Scopes(dbx.PaginatedResults(&user{}, results, db)).Find(&results.Rows).Error)
+	assert.Equal(t, 3, results.TotalRows)
+	assert.Equal(t, 3, results.TotalPages)
+	assert.Equal(t, []int{1}, userIDs(results.Rows))
+}
@@ -0,0 +1,178 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultMaxReplicaLag is the default lag after which a replica is excluded from reads.
+	DefaultMaxReplicaLag = 10 * time.Second
+	// DefaultHealthInterval is the default interval between health checks of the replicas.
+	DefaultHealthInterval = 5 * time.Second
+)
+
+// ReplicaLagFunc returns the replication lag of a replica, an error marks it unhealthy.
+type ReplicaLagFunc func(ctx context.Context, db *gorm.DB) (time.Duration, error)
+
+// WithReplicas is adding read replicas that ReadTx is load-balanced across.
+// Replicas that fail the health check or lag behind are excluded,
+// and ReadTx falls back to the primary when no replica is healthy.
+func WithReplicas(replicas ...*gorm.DB) DatabaseOpt {
+	return func(opts *DatabaseOpts) {
+		opts.Replicas = append(opts.Replicas, replicas...)
+	}
+}
+
+// WithMaxReplicaLag is setting the lag after which a replica is excluded from reads.
+func WithMaxReplicaLag(lag time.Duration) DatabaseOpt {
+	return func(opts *DatabaseOpts) {
+		opts.MaxReplicaLag = lag
+	}
+}
+
+// WithHealthInterval is setting the interval between health checks of the replicas.
+func WithHealthInterval(interval time.Duration) DatabaseOpt {
+	return func(opts *DatabaseOpts) {
+		opts.HealthInterval = interval
+	}
+}
+
+// WithReplicaLag is setting the function that returns the replication lag of a replica.
+func WithReplicaLag(fn ReplicaLagFunc) DatabaseOpt {
+	return func(opts *DatabaseOpts) {
+		opts.ReplicaLag = fn
+	}
+}
+
+type readYourWritesKey struct{}
+
+// ReadYourWrites returns a context with which ReadTx reads from the primary,
+// e.g. to read the writes of a preceding ReadWriteTx that may not be replicated yet.
+func ReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKey{}, true)
+}
+
+func isReadYourWrites(ctx context.Context) bool {
+	ok, _ := ctx.Value(readYourWritesKey{}).(bool)
+	return ok
+}
+
+// DefaultReplicaLag returns the replay lag of a Postgres standby, it is zero if it has replayed
+// all received changes. Other databases are pinged and have no lag.
+func DefaultReplicaLag(ctx context.Context, db *gorm.DB) (time.Duration, error) {
+	if db.Dialector.Name() != "postgres" {
+		conn, err := db.DB()
+		if err != nil {
+			return 0, err
+		}
+
+		return 0, conn.PingContext(ctx)
+	}
+
+	var seconds float64
+	err := db.WithContext(ctx).Raw(`SELECT CASE
+		WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END`).Scan(&seconds).Error
+
+	return time.Duration(seconds * float64(time.Second)), err
+}
+
+type replica struct {
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// replicaSet routes reads to the healthy replicas in turn.
+type replicaSet struct {
+	opts     *DatabaseOpts
+	replicas []*replica
+	next     atomic.Uint64
+	checked  atomic.Int64
+	checking atomic.Bool
+}
+
+func newReplicaSet(opts *DatabaseOpts) *replicaSet {
+	s := new(replicaSet)
+	s.opts = opts
+
+	for _, db := range opts.Replicas {
+		s.replicas = append(s.replicas, &replica{db: db})
+	}
+
+	// the replicas are unhealthy until the first check, which does not block on unreachable replicas
+	if len(s.replicas) > 0 {
+		s.checkAsync()
+	}
+
+	return s
+}
+
+// pick returns the next healthy replica, or nil if there is none.
+func (s *replicaSet) pick() *replica {
+	if len(s.replicas) == 0 {
+		return nil
+	}
+
+	if time.Since(time.Unix(0, s.checked.Load())) > s.opts.HealthInterval {
+		s.checkAsync()
+	}
+
+	healthy := make([]*replica, 0, len(s.replicas))
+	for _, r := range s.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	return healthy[s.next.Add(1)%uint64(len(healthy))]
+}
+
+// checkAsync updates the health of the replicas in the background, unless they are already being checked.
+func (s *replicaSet) checkAsync() {
+	if !s.checking.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer s.checking.Store(false)
+		s.check()
+	}()
+}
+
+// check updates the health of the replicas.
+func (s *replicaSet) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.HealthInterval)
+	defer cancel()
+
+	for _, r := range s.replicas {
+		lag, err := s.opts.ReplicaLag(ctx, r.db)
+		r.healthy.Store(err == nil && (s.opts.MaxReplicaLag <= 0 || lag <= s.opts.MaxReplicaLag))
+	}
+
+	s.checked.Store(time.Now().UnixNano())
+}
+
+// close closes the connections of the replicas.
+func (s *replicaSet) close() error {
+	var errs []error
+
+	for _, r := range s.replicas {
+		db, err := r.db.DB()
+		if err == nil {
+			err = db.Close()
+		}
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
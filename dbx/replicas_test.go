@@ -0,0 +1,163 @@
+package dbx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/dbx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type origin struct {
+	Name string `gorm:"primaryKey"`
+}
+
+func openOrigin(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s-%s?mode=memory&cache=shared", t.Name(), name)), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn, err := db.DB()
+		require.NoError(t, err)
+		_ = conn.Close()
+	})
+
+	require.NoError(t, db.AutoMigrate(&origin{}))
+	require.NoError(t, db.Create(&origin{Name: name}).Error)
+
+	return db
+}
+
+func readOrigin(t *testing.T, ctx context.Context, store dbx.Database[*gorm.DB, *gorm.DB]) string {
+	t.Helper()
+
+	var o origin
+	require.NoError(t, store.ReadTx(ctx, func(_ context.Context, tx *gorm.DB) error {
+		return tx.First(&o).Error
+	}))
+
+	return o.Name
+}
+
+// waitForReplicas waits until the first health check routed a read to a replica.
+func waitForReplicas(t *testing.T, store dbx.Database[*gorm.DB, *gorm.DB]) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return readOrigin(t, t.Context(), store) != "primary"
+	}, time.Second, time.Millisecond)
+}
+
+func TestReadTxReplicas(t *testing.T) {
+	primary := openOrigin(t, "primary")
+	a, b, c := openOrigin(t, "a"), openOrigin(t, "b"), openOrigin(t, "c")
+
+	lags := map[*gorm.DB]time.Duration{a: 0, b: time.Second, c: time.Minute}
+
+	store, err := dbx.NewDatabase(
+		primary,
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		dbx.WithReplicas(a, b, c),
+		dbx.WithMaxReplicaLag(10*time.Second),
+		dbx.WithHealthInterval(time.Hour),
+		dbx.WithReplicaLag(func(_ context.Context, db *gorm.DB) (time.Duration, error) {
+			return lags[db], nil
+		}),
+	)
+	require.NoError(t, err)
+	defer store.Close()
+
+	waitForReplicas(t, store)
+
+	reads := map[string]int{}
+	for range 10 {
+		reads[readOrigin(t, t.Context(), store)]++
+	}
+	assert.Equal(t, map[string]int{"a": 5, "b": 5}, reads, "the lagging replica is excluded")
+
+	assert.Equal(t, "primary", readOrigin(t, dbx.ReadYourWrites(t.Context()), store))
+
+	err = store.ReadWriteTx(t.Context(), func(_ context.Context, tx *gorm.DB) error {
+		var o origin
+		require.NoError(t, tx.First(&o).Error)
+		assert.Equal(t, "primary", o.Name)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestReadTxReplicasUnhealthy(t *testing.T) {
+	primary := openOrigin(t, "primary")
+	a, b := openOrigin(t, "a"), openOrigin(t, "b")
+
+	store, err := dbx.NewDatabase(
+		primary,
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		dbx.WithReplicas(a, b),
+		dbx.WithHealthInterval(time.Hour),
+		dbx.WithReplicaLag(func(ctx context.Context, db *gorm.DB) (time.Duration, error) {
+			if db == b {
+				return 0, errors.New("connection refused")
+			}
+
+			return dbx.DefaultReplicaLag(ctx, db)
+		}),
+	)
+	require.NoError(t, err)
+
+	waitForReplicas(t, store)
+
+	assert.Equal(t, "a", readOrigin(t, t.Context(), store))
+	assert.Equal(t, "a", readOrigin(t, t.Context(), store))
+
+	conn, err := a.DB()
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	assert.Equal(t, "primary", readOrigin(t, t.Context(), store), "a failing replica falls back to the primary")
+	assert.Equal(t, "primary", readOrigin(t, t.Context(), store))
+}
+
+func TestReadTxReplicasUnreachable(t *testing.T) {
+	primary := openOrigin(t, "primary")
+	a := openOrigin(t, "a")
+
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+
+	store, err := dbx.NewDatabase(
+		primary,
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		dbx.WithReplicas(a),
+		dbx.WithReplicaLag(func(ctx context.Context, _ *gorm.DB) (time.Duration, error) {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+
+			return 0, errors.New("connection timed out")
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Less(t, time.Since(start), time.Second, "the replicas are checked in the background")
+	assert.Equal(t, "primary", readOrigin(t, t.Context(), store), "unchecked replicas are not used")
+}
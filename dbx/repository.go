@@ -0,0 +1,337 @@
+package dbx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var (
+	// ErrNotFound is returned when a record does not exist.
+	ErrNotFound = gorm.ErrRecordNotFound
+	// ErrStaleVersion is returned when a record has been changed or deleted since it has been read.
+	ErrStaleVersion = errors.New("dbx: stale version")
+	// ErrNotSoftDeletable is returned when a record without a gorm.DeletedAt field is restored.
+	ErrNotSoftDeletable = errors.New("dbx: not soft deletable")
+)
+
+// DefaultBatchSize is the default number of records that are upserted in one statement.
+const DefaultBatchSize = 100
+
+// ReadRepository is the part of a repository that can be used in read transactions.
+type ReadRepository[T any] interface {
+	// Get returns the record with the primary key.
+	Get(ctx context.Context, id any) (*T, error)
+	// List returns a page of the records.
+	List(ctx context.Context, results *Results[T]) error
+}
+
+// RepositoryOpts are the options for a repository.
+type RepositoryOpts struct {
+	// VersionField is the name of the integer field that is used for optimistic locking.
+	VersionField string
+	// BatchSize is the number of records that are upserted in one statement.
+	BatchSize int
+}
+
+// Configure is configuring the options.
+func (o *RepositoryOpts) Configure(opts ...RepositoryOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultRepositoryOpts returns the default options.
+func DefaultRepositoryOpts() *RepositoryOpts {
+	return &RepositoryOpts{
+		VersionField: "Version",
+		BatchSize:    DefaultBatchSize,
+	}
+}
+
+// RepositoryOpt is a function that configures the options.
+type RepositoryOpt func(*RepositoryOpts)
+
+// WithVersionField is setting the name of the field that is used for optimistic locking.
+func WithVersionField(name string) RepositoryOpt {
+	return func(opts *RepositoryOpts) {
+		opts.VersionField = name
+	}
+}
+
+// WithBatchSize is setting the number of records that are upserted in one statement.
+func WithBatchSize(size int) RepositoryOpt {
+	return func(opts *RepositoryOpts) {
+		opts.BatchSize = size
+	}
+}
+
+var _ ReadRepository[any] = (*Repository[any])(nil)
+
+// Repository provides the common operations on the records of a model.
+// It is created with the connection of a transaction in a factory,
+// and uses optimistic locking if the model has a version field.
+//
+//	func(tx *gorm.DB) (Store, error) {
+//		return &store{users: dbx.NewRepository[User](tx)}, nil
+//	}
+//
+// All errors are returned as QueryError with the failing operation.
+type Repository[T any] struct {
+	db   *gorm.DB
+	opts *RepositoryOpts
+}
+
+// NewRepository returns a new repository for the model.
+func NewRepository[T any](db *gorm.DB, opts ...RepositoryOpt) *Repository[T] {
+	options := DefaultRepositoryOpts()
+	options.Configure(opts...)
+
+	r := new(Repository[T])
+	r.db = db
+	r.opts = options
+
+	return r
+}
+
+var repositorySchemas sync.Map
+
+func (r *Repository[T]) schema() (*schema.Schema, error) {
+	return schema.Parse(new(T), &repositorySchemas, r.db.NamingStrategy)
+}
+
+// version returns the version field of the model, or nil if it has none.
+func (r *Repository[T]) version(s *schema.Schema) *schema.Field {
+	f := s.LookUpField(r.opts.VersionField)
+	if f == nil || f.DBName == "" {
+		return nil
+	}
+
+	switch f.FieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return f
+	default:
+		return nil
+	}
+}
+
+// primaryKey returns the condition on the primary key.
+func primaryKey(s *schema.Schema, id any) (clause.Eq, error) {
+	if s.PrioritizedPrimaryField == nil {
+		return clause.Eq{}, fmt.Errorf("dbx: %s has no primary key", s.Name)
+	}
+
+	return clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: s.PrioritizedPrimaryField.DBName}, Value: id}, nil
+}
+
+// Get returns the record with the primary key.
+func (r *Repository[T]) Get(ctx context.Context, id any) (*T, error) {
+	s, err := r.schema()
+	if err != nil {
+		return nil, NewQueryError("get", err)
+	}
+
+	pk, err := primaryKey(s, id)
+	if err != nil {
+		return nil, NewQueryError("get", err)
+	}
+
+	v := new(T)
+	if err := r.db.WithContext(ctx).Where(pk).First(v).Error; err != nil {
+		return nil, NewQueryError("get "+s.Table, err)
+	}
+
+	return v, nil
+}
+
+// List returns a page of the records.
+func (r *Repository[T]) List(ctx context.Context, results *Results[T]) error {
+	db := r.db.WithContext(ctx)
+
+	if err := db.Scopes(PaginatedResults(new(T), results, db)).Find(&results.Rows).Error; err != nil {
+		return NewQueryError("list", err)
+	}
+
+	return nil
+}
+
+// Create creates the record.
+func (r *Repository[T]) Create(ctx context.Context, v *T) error {
+	if err := r.db.WithContext(ctx).Create(v).Error; err != nil {
+		return NewQueryError("create", err)
+	}
+
+	return nil
+}
+
+// Update updates all fields of the record. If the model has a version field,
+// the record is only updated if its version has not changed since it has been read,
+// otherwise ErrStaleVersion is returned. The version is incremented on success.
+func (r *Repository[T]) Update(ctx context.Context, v *T) error {
+	s, err := r.schema()
+	if err != nil {
+		return NewQueryError("update", err)
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	db := r.db.WithContext(ctx).Model(v).Select("*")
+
+	if f := s.LookUpField("CreatedAt"); f != nil && f.DBName != "" {
+		db = db.Omit(f.Name)
+	}
+
+	version := r.version(s)
+	if version == nil {
+		res := db.Updates(v)
+		if res.Error != nil {
+			return NewQueryError("update "+s.Table, res.Error)
+		}
+
+		if res.RowsAffected == 0 {
+			// MySQL does not count rows whose values did not change
+			found, err := r.exists(ctx, s, rv)
+			if err != nil {
+				return NewQueryError("update "+s.Table, err)
+			}
+
+			if !found {
+				return NewQueryError("update "+s.Table, ErrNotFound)
+			}
+		}
+
+		return nil
+	}
+
+	current, _ := version.ValueOf(ctx, rv)
+	next := reflect.ValueOf(current).Convert(version.FieldType)
+	if next.CanInt() {
+		next = reflect.ValueOf(next.Int() + 1).Convert(version.FieldType)
+	} else {
+		next = reflect.ValueOf(next.Uint() + 1).Convert(version.FieldType)
+	}
+
+	if err := version.Set(ctx, rv, next.Interface()); err != nil {
+		return NewQueryError("update "+s.Table, err)
+	}
+
+	res := db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: version.DBName}, Value: current}).Updates(v)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = ErrStaleVersion
+	}
+
+	if res.Error != nil {
+		_ = version.Set(ctx, rv, current)
+		return NewQueryError("update "+s.Table, res.Error)
+	}
+
+	return nil
+}
+
+// exists reports whether the record with the primary key of the value exists.
+func (r *Repository[T]) exists(ctx context.Context, s *schema.Schema, rv reflect.Value) (bool, error) {
+	if s.PrioritizedPrimaryField == nil {
+		return false, fmt.Errorf("dbx: %s has no primary key", s.Name)
+	}
+
+	id, _ := s.PrioritizedPrimaryField.ValueOf(ctx, rv)
+	pk, err := primaryKey(s, id)
+	if err != nil {
+		return false, err
+	}
+
+	var n int64
+	if err := r.db.WithContext(ctx).Model(new(T)).Where(pk).Count(&n).Error; err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// Delete deletes the record with the primary key, softly if the model has a gorm.DeletedAt field.
+func (r *Repository[T]) Delete(ctx context.Context, id any) error {
+	s, err := r.schema()
+	if err != nil {
+		return NewQueryError("delete", err)
+	}
+
+	pk, err := primaryKey(s, id)
+	if err != nil {
+		return NewQueryError("delete", err)
+	}
+
+	res := r.db.WithContext(ctx).Where(pk).Delete(new(T))
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = ErrNotFound
+	}
+
+	if res.Error != nil {
+		return NewQueryError("delete "+s.Table, res.Error)
+	}
+
+	return nil
+}
+
+// Restore restores the softly deleted record with the primary key.
+func (r *Repository[T]) Restore(ctx context.Context, id any) error {
+	s, err := r.schema()
+	if err != nil {
+		return NewQueryError("restore", err)
+	}
+
+	pk, err := primaryKey(s, id)
+	if err != nil {
+		return NewQueryError("restore", err)
+	}
+
+	var deletedAt *schema.Field
+	for _, f := range s.Fields {
+		if f.FieldType == reflect.TypeFor[gorm.DeletedAt]() {
+			deletedAt = f
+			break
+		}
+	}
+
+	if deletedAt == nil {
+		return NewQueryError("restore "+s.Table, ErrNotSoftDeletable)
+	}
+
+	res := r.db.WithContext(ctx).Unscoped().Model(new(T)).
+		Where(pk).
+		Where(clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: deletedAt.DBName}, Value: nil}).
+		Update(deletedAt.DBName, nil)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = ErrNotFound
+	}
+
+	if res.Error != nil {
+		return NewQueryError("restore "+s.Table, res.Error)
+	}
+
+	return nil
+}
+
+// Upsert creates the records, or updates all their fields if they conflict on the columns.
+// The columns default to the primary key. The records are written in batches.
+func (r *Repository[T]) Upsert(ctx context.Context, values []T, columns ...string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	conflict := clause.OnConflict{UpdateAll: true}
+	for _, c := range columns {
+		conflict.Columns = append(conflict.Columns, clause.Column{Name: c})
+	}
+
+	if err := r.db.WithContext(ctx).Clauses(conflict).CreateInBatches(&values, r.opts.BatchSize).Error; err != nil {
+		return NewQueryError("upsert", err)
+	}
+
+	return nil
+}
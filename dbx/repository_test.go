@@ -0,0 +1,173 @@
+package dbx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zeiss/pkg/dbx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type account struct {
+	ID        int
+	Name      string
+	Balance   int
+	Version   int
+	DeletedAt gorm.DeletedAt
+}
+
+type tag struct {
+	Name  string `gorm:"primaryKey"`
+	Count int
+}
+
+type accountReader interface {
+	Account(ctx context.Context, id int) (*account, error)
+}
+
+type accountStore struct {
+	accounts *dbx.Repository[account]
+}
+
+func (s *accountStore) Account(ctx context.Context, id int) (*account, error) {
+	return s.accounts.Get(ctx, id)
+}
+
+func TestRepositoryTransactions(t *testing.T) {
+	db := newTestDB(t, &account{})
+
+	store, err := dbx.NewDatabase(
+		db,
+		func(tx *gorm.DB) (accountReader, error) {
+			return &accountStore{accounts: dbx.NewRepository[account](tx)}, nil
+		},
+		func(tx *gorm.DB) (*dbx.Repository[account], error) {
+			return dbx.NewRepository[account](tx), nil
+		},
+	)
+	require.NoError(t, err)
+
+	err = store.ReadWriteTx(t.Context(), func(ctx context.Context, accounts *dbx.Repository[account]) error {
+		return accounts.Create(ctx, &account{ID: 1, Name: "alice", Balance: 10})
+	})
+	require.NoError(t, err)
+
+	err = store.ReadTx(t.Context(), func(ctx context.Context, r accountReader) error {
+		a, err := r.Account(ctx, 1)
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(t, "alice", a.Name)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRepositoryGet(t *testing.T) {
+	db := newTestDB(t, &account{})
+	accounts := dbx.NewRepository[account](db)
+
+	_, err := accounts.Get(t.Context(), 1)
+	require.ErrorIs(t, err, dbx.ErrNotFound)
+
+	var qerr *dbx.QueryError
+	require.ErrorAs(t, err, &qerr)
+	assert.Equal(t, "get accounts", qerr.Query)
+}
+
+func TestRepositoryUpdateOptimisticLocking(t *testing.T) {
+	db := newTestDB(t, &account{})
+	accounts := dbx.NewRepository[account](db)
+
+	require.NoError(t, accounts.Create(t.Context(), &account{ID: 1, Name: "alice", Balance: 10}))
+
+	a, err := accounts.Get(t.Context(), 1)
+	require.NoError(t, err)
+
+	b, err := accounts.Get(t.Context(), 1)
+	require.NoError(t, err)
+
+	a.Balance = 20
+	require.NoError(t, accounts.Update(t.Context(), a))
+	assert.Equal(t, 1, a.Version)
+
+	b.Balance = 30
+	err = accounts.Update(t.Context(), b)
+	require.ErrorIs(t, err, dbx.ErrStaleVersion)
+	assert.Equal(t, 0, b.Version, "the version is kept on failure")
+
+	got, err := accounts.Get(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 20, got.Balance)
+	assert.Equal(t, 1, got.Version)
+}
+
+func TestRepositoryUpdateWithoutVersion(t *testing.T) {
+	db := newTestDB(t, &tag{})
+	tags := dbx.NewRepository[tag](db)
+
+	err := tags.Update(t.Context(), &tag{Name: "go", Count: 1})
+	require.ErrorIs(t, err, dbx.ErrNotFound)
+
+	require.NoError(t, tags.Create(t.Context(), &tag{Name: "go", Count: 1}))
+	require.NoError(t, tags.Update(t.Context(), &tag{Name: "go", Count: 2}))
+
+	got, err := tags.Get(t.Context(), "go")
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Count)
+}
+
+func TestRepositoryUpdateUnchanged(t *testing.T) {
+	db := newTestDB(t, &tag{})
+
+	// MySQL reports no affected rows when an update does not change any value
+	require.NoError(t, db.Callback().Update().After("gorm:update").Register("test:unchanged", func(db *gorm.DB) {
+		db.RowsAffected = 0
+	}))
+
+	tags := dbx.NewRepository[tag](db)
+	require.NoError(t, tags.Create(t.Context(), &tag{Name: "go", Count: 1}))
+
+	require.NoError(t, tags.Update(t.Context(), &tag{Name: "go", Count: 1}))
+	require.ErrorIs(t, tags.Update(t.Context(), &tag{Name: "rust", Count: 1}), dbx.ErrNotFound)
+}
+
+func TestRepositoryDeleteRestore(t *testing.T) {
+	db := newTestDB(t, &account{}, &tag{})
+	accounts := dbx.NewRepository[account](db)
+
+	require.NoError(t, accounts.Create(t.Context(), &account{ID: 1, Name: "alice"}))
+	require.NoError(t, accounts.Delete(t.Context(), 1))
+
+	_, err := accounts.Get(t.Context(), 1)
+	require.ErrorIs(t, err, dbx.ErrNotFound)
+	require.ErrorIs(t, accounts.Delete(t.Context(), 1), dbx.ErrNotFound)
+
+	require.NoError(t, accounts.Restore(t.Context(), 1))
+	_, err = accounts.Get(t.Context(), 1)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, accounts.Restore(t.Context(), 1), dbx.ErrNotFound)
+	require.ErrorIs(t, dbx.NewRepository[tag](db).Restore(t.Context(), "go"), dbx.ErrNotSoftDeletable)
+}
+
+func TestRepositoryUpsertAndList(t *testing.T) {
+	db := newTestDB(t, &tag{})
+	tags := dbx.NewRepository[tag](db, dbx.WithBatchSize(2))
+
+	require.NoError(t, tags.Upsert(t.Context(), []tag{{Name: "a", Count: 1}, {Name: "b", Count: 1}, {Name: "c", Count: 1}}))
+	require.NoError(t, tags.Upsert(t.Context(), []tag{{Name: "b", Count: 2}, {Name: "d", Count: 2}}, "name"))
+
+	results := dbx.NewResults[tag]()
+	results.Limit = 3
+	require.NoError(t, tags.List(t.Context(), results))
+
+	assert.Equal(t, 4, results.TotalRows)
+	assert.Equal(t, 2, results.TotalPages)
+	assert.Equal(t, []tag{{Name: "a", Count: 1}, {Name: "b", Count: 2}, {Name: "c", Count: 1}}, results.Rows)
+}
@@ -0,0 +1,73 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Backup copies the database online to the file at the path with the SQLite backup API,
+// while it is being written to.
+func (db *DB) Backup(ctx context.Context, path string) error {
+	src, err := db.Reader.DB()
+	if err != nil {
+		return err
+	}
+
+	dst, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dc any) error {
+		return srcConn.Raw(func(sc any) error {
+			d, ok := dc.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("sqlite: unsupported connection %T", dc)
+			}
+
+			s, ok := sc.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("sqlite: unsupported connection %T", sc)
+			}
+
+			b, err := d.Backup("main", s, "main")
+			if err != nil {
+				return err
+			}
+
+			for {
+				done, err := b.Step(256)
+				if err != nil {
+					return errors.Join(err, b.Finish())
+				}
+
+				if done {
+					return b.Finish()
+				}
+
+				if err := ctx.Err(); err != nil {
+					return errors.Join(err, b.Finish())
+				}
+			}
+		})
+	})
+}
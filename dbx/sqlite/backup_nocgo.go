@@ -0,0 +1,16 @@
+//go:build !cgo
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBackupUnsupported is returned by Backup if the module is built without cgo.
+var ErrBackupUnsupported = errors.New("sqlite: backup requires cgo")
+
+// Backup is not supported without cgo, use VacuumInto instead.
+func (db *DB) Backup(_ context.Context, _ string) error {
+	return ErrBackupUnsupported
+}
@@ -2,7 +2,11 @@ package sqlite
 
 import (
 	"context"
+	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/zeiss/pkg/filex"
 )
@@ -25,15 +29,75 @@ func FromContext(ctx context.Context) *Config {
 	return ctx.Value(configKey).(*Config)
 }
 
-// Config represents configuration for PostgreSQL connection.
+// Config represents configuration for SQLite connection.
 type Config struct {
 	// Path is the path to the SQLite database file.
 	Path string `envconfig:"DBX_SQLITE_PATH"`
+	// JournalMode is the journal mode of the database.
+	JournalMode string `envconfig:"DBX_SQLITE_JOURNAL_MODE" default:"WAL"`
+	// Synchronous is the synchronous level of the database.
+	Synchronous string `envconfig:"DBX_SQLITE_SYNCHRONOUS" default:"NORMAL"`
+	// BusyTimeout is the time to wait for a locked database.
+	BusyTimeout time.Duration `envconfig:"DBX_SQLITE_BUSY_TIMEOUT" default:"5s"`
+	// ForeignKeys enforces foreign key constraints.
+	ForeignKeys bool `envconfig:"DBX_SQLITE_FOREIGN_KEYS" default:"true"`
+	// MaxReaders is the maximum number of open read connections.
+	MaxReaders int `envconfig:"DBX_SQLITE_MAX_READERS" default:"4"`
 }
 
 // NewConfig returns a new Config instance.
 func NewConfig(path string) *Config {
-	return &Config{Path: path}
+	return &Config{
+		Path:        path,
+		JournalMode: "WAL",
+		Synchronous: "NORMAL",
+		BusyTimeout: 5 * time.Second,
+		ForeignKeys: true,
+		MaxReaders:  4,
+	}
+}
+
+// memory returns true if the database is in memory.
+func (c *Config) memory() bool {
+	return c.Path == "" || c.Path == ":memory:" || strings.Contains(c.Path, "mode=memory")
+}
+
+// FormatDSN formats the Config into a DSN string with the pragmas of the connections,
+// read only connections are opened in read only mode.
+func (c *Config) FormatDSN(readOnly bool) string {
+	params := url.Values{}
+
+	if c.JournalMode != "" {
+		params.Set("_journal_mode", c.JournalMode)
+	}
+
+	if c.Synchronous != "" {
+		params.Set("_synchronous", c.Synchronous)
+	}
+
+	params.Set("_busy_timeout", strconv.FormatInt(c.BusyTimeout.Milliseconds(), 10))
+	params.Set("_foreign_keys", strconv.FormatBool(c.ForeignKeys))
+
+	if readOnly {
+		params.Set("mode", "ro")
+	} else {
+		params.Set("_txlock", "immediate")
+	}
+
+	path, query, _ := strings.Cut(c.Path, "?")
+	if query != "" {
+		if q, err := url.ParseQuery(query); err == nil {
+			for k, v := range q {
+				params[k] = v
+			}
+		}
+	}
+
+	if !strings.HasPrefix(path, "file:") {
+		path = "file:" + path
+	}
+
+	return path + "?" + params.Encode()
 }
 
 // Dir returns the directory of the SQLite database file.
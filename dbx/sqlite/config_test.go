@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/zeiss/pkg/dbx/sqlite"
 )
@@ -46,3 +47,12 @@ func TestConfigMkdir(t *testing.T) {
 	require.NoError(t, err)
 	require.DirExists(t, filepath.Join(tempDir, ".builder"))
 }
+
+func TestConfigFormatDSN(t *testing.T) {
+	t.Parallel()
+
+	config := sqlite.NewConfig("data/app.db?cache=shared")
+
+	assert.Equal(t, "file:data/app.db?_busy_timeout=5000&_foreign_keys=true&_journal_mode=WAL&_synchronous=NORMAL&_txlock=immediate&cache=shared", config.FormatDSN(false))
+	assert.Equal(t, "file:data/app.db?_busy_timeout=5000&_foreign_keys=true&_journal_mode=WAL&_synchronous=NORMAL&cache=shared&mode=ro", config.FormatDSN(true))
+}
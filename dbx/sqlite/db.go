@@ -0,0 +1,96 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zeiss/pkg/dbx"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// DB is a SQLite database with a single writer connection and a pool of reader connections,
+// so that writes do not fail on a busy database and reads do not wait for them in WAL mode.
+//
+//	db, err := cfg.Open(&gorm.Config{})
+//	store, err := dbx.NewDatabase(db.Writer, readFactory, writeFactory, db.DatabaseOpts()...)
+type DB struct {
+	// Writer is the connection for writes, it is used for ReadWriteTx.
+	Writer *gorm.DB
+	// Reader is the pool for reads, it is used for ReadTx.
+	// It is the Writer for in memory databases.
+	Reader *gorm.DB
+}
+
+// Open opens the database with the pragmas of the Config and creates its directory.
+func (c *Config) Open(opts ...gorm.Option) (*DB, error) {
+	if !c.memory() {
+		if err := c.Mkdir(); err != nil {
+			return nil, err
+		}
+	}
+
+	writer, err := gorm.Open(sqlite.Open(c.FormatDSN(false)), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := writer.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+
+	if err := conn.Ping(); err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+
+	db := &DB{Writer: writer, Reader: writer}
+	if c.memory() {
+		return db, nil
+	}
+
+	reader, err := gorm.Open(sqlite.Open(c.FormatDSN(true)), opts...)
+	if err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+
+	rconn, err := reader.DB()
+	if err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+	rconn.SetMaxOpenConns(max(c.MaxReaders, 1))
+	db.Reader = reader
+
+	return db, nil
+}
+
+// DatabaseOpts returns the options that use the reader pool for ReadTx of a dbx.Database.
+func (db *DB) DatabaseOpts() []dbx.DatabaseOpt {
+	if db.Reader == db.Writer {
+		return nil
+	}
+
+	return []dbx.DatabaseOpt{dbx.WithReplicas(db.Reader), dbx.WithMaxReplicaLag(0)}
+}
+
+// Close closes the connections.
+func (db *DB) Close() error {
+	var errs []error
+
+	for _, g := range []*gorm.DB{db.Writer, db.Reader} {
+		conn, err := g.DB()
+		if err == nil {
+			err = conn.Close()
+		}
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// VacuumInto writes a compacted snapshot of the database to the file at the path,
+// which must not exist.
+func (db *DB) VacuumInto(ctx context.Context, path string) error {
+	return db.Reader.WithContext(ctx).Exec("VACUUM INTO ?", path).Error
+}
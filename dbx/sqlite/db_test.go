@@ -0,0 +1,129 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeiss/pkg/dbx"
+	"github.com/zeiss/pkg/dbx/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type item struct {
+	ID     int
+	Name   string
+	ListID int
+	List   *list
+}
+
+type list struct {
+	ID int
+}
+
+func openDB(t *testing.T, path string) *sqlite.DB {
+	t.Helper()
+
+	db, err := sqlite.NewConfig(path).Open(&gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	return db
+}
+
+func count(t *testing.T, path string) int64 {
+	t.Helper()
+
+	db := openDB(t, path)
+
+	var n int64
+	require.NoError(t, db.Reader.Model(&item{}).Count(&n).Error)
+
+	return n
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "data", "app.db")
+	db := openDB(t, path)
+	require.NoError(t, db.Writer.AutoMigrate(&list{}, &item{}))
+
+	var mode string
+	require.NoError(t, db.Reader.Raw("PRAGMA journal_mode").Scan(&mode).Error)
+	assert.Equal(t, "wal", mode)
+
+	var fk int
+	require.NoError(t, db.Reader.Raw("PRAGMA foreign_keys").Scan(&fk).Error)
+	assert.Equal(t, 1, fk)
+
+	require.Error(t, db.Writer.Create(&item{ID: 1, ListID: 42}).Error, "foreign keys are enforced")
+	require.Error(t, db.Reader.Create(&list{ID: 1}).Error, "readers are read only")
+
+	store, err := dbx.NewDatabase(
+		db.Writer,
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		db.DatabaseOpts()...,
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, store.ReadWriteTx(t.Context(), func(_ context.Context, tx *gorm.DB) error {
+		return tx.Create(&item{ID: 1, Name: "milk", List: &list{ID: 1}}).Error
+	}))
+
+	require.NoError(t, store.ReadTx(t.Context(), func(_ context.Context, tx *gorm.DB) error {
+		var i item
+		require.NoError(t, tx.First(&i, 1).Error)
+		assert.Equal(t, "milk", i.Name)
+
+		return nil
+	}))
+
+	// read transactions use the primary until the reader pool has been checked in the background
+	readOnly := func() bool {
+		err := store.ReadTx(t.Context(), func(_ context.Context, tx *gorm.DB) error {
+			if err := tx.Create(&list{ID: 2}).Error; err != nil {
+				return err
+			}
+
+			return errors.New("rollback")
+		})
+
+		return err != nil && strings.Contains(err.Error(), "readonly")
+	}
+	require.Eventually(t, readOnly, time.Second, time.Millisecond, "read transactions use the read only pool")
+}
+
+func TestOpenMemory(t *testing.T) {
+	t.Parallel()
+
+	db := openDB(t, ":memory:")
+	assert.Same(t, db.Writer, db.Reader)
+	assert.Empty(t, db.DatabaseOpts())
+}
+
+func TestBackupAndVacuumInto(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	db := openDB(t, filepath.Join(dir, "app.db"))
+
+	require.NoError(t, db.Writer.AutoMigrate(&list{}, &item{}))
+	require.NoError(t, db.Writer.Create(&[]item{{ID: 1, List: &list{ID: 1}}, {ID: 2, ListID: 1}}).Error)
+
+	require.NoError(t, db.Backup(t.Context(), filepath.Join(dir, "backup.db")))
+	assert.Equal(t, int64(2), count(t, filepath.Join(dir, "backup.db")))
+
+	require.NoError(t, db.VacuumInto(t.Context(), filepath.Join(dir, "snapshot.db")))
+	assert.Equal(t, int64(2), count(t, filepath.Join(dir, "snapshot.db")))
+
+	require.Error(t, db.VacuumInto(t.Context(), filepath.Join(dir, "snapshot.db")), "the snapshot must not exist")
+}
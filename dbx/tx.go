@@ -0,0 +1,215 @@
+package dbx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zeiss/pkg/server"
+	"gorm.io/gorm"
+)
+
+// DefaultRetryBackoff is the default backoff between attempts of a transaction.
+var DefaultRetryBackoff = server.Backoff{
+	Initial:    10 * time.Millisecond,
+	Max:        time.Second,
+	Multiplier: 2,
+	Jitter:     0.5,
+}
+
+// DefaultRetryPolicy is the default retry policy of transactions.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     DefaultRetryBackoff,
+}
+
+// NoRetry is a retry policy that never retries a transaction.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// RetryPolicy is the policy to retry transactions that failed on
+// serialization failures, deadlocks or a busy database.
+// The callback of a transaction is run again on a retry,
+// so it should not have side effects outside of the transaction.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, less than two never retries.
+	MaxAttempts int
+	// Backoff is the backoff between attempts.
+	Backoff server.Backoff
+	// Retryable returns true if the error is retried, defaults to IsRetryable.
+	Retryable func(error) bool
+	// OnRetry is called with the failed attempt and its error before the transaction is retried.
+	OnRetry func(ctx context.Context, attempt int, err error)
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	return IsRetryable(err)
+}
+
+// RetryError is returned when a transaction failed in all attempts.
+type RetryError struct {
+	// Attempts is the number of attempts that were made.
+	Attempts int
+	// Err is the error of the last attempt.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("transaction failed after %d attempts: %s", e.Attempts, e.Err)
+}
+
+// Unwrap implements the errors.Wrapper interface.
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// IsRetryable returns true if the error is a Postgres serialization failure (40001)
+// or deadlock (40P01), or SQLite reports the database or a table to be locked.
+func IsRetryable(err error) bool {
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) {
+		switch state.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+	}
+
+	if err == nil {
+		return false
+	}
+
+	msg := err.Error()
+
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// TxOpts are the options for transactions.
+type TxOpts struct {
+	// Isolation is the isolation level, zero uses the default of the database.
+	Isolation sql.IsolationLevel
+	// ReadOnly starts read only transactions, it is always set for ReadTx.
+	ReadOnly bool
+	// Retry is the retry policy.
+	Retry RetryPolicy
+}
+
+// Configure is configuring the options.
+func (o *TxOpts) Configure(opts ...TxOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultTxOpts returns the default options.
+func DefaultTxOpts() *TxOpts {
+	return &TxOpts{
+		Retry: DefaultRetryPolicy,
+	}
+}
+
+// TxOpt is a function that configures the options.
+type TxOpt func(*TxOpts)
+
+// WithIsolation is setting the isolation level of transactions.
+func WithIsolation(level sql.IsolationLevel) TxOpt {
+	return func(opts *TxOpts) {
+		opts.Isolation = level
+	}
+}
+
+// WithReadOnly is starting read only transactions.
+func WithReadOnly(readOnly ...bool) TxOpt {
+	return func(opts *TxOpts) {
+		opts.ReadOnly = len(readOnly) == 0 || readOnly[0]
+	}
+}
+
+// WithRetryPolicy is setting the retry policy of transactions.
+func WithRetryPolicy(policy RetryPolicy) TxOpt {
+	return func(opts *TxOpts) {
+		opts.Retry = policy
+	}
+}
+
+type (
+	txOptsKey    struct{}
+	txAttemptKey struct{}
+)
+
+// TxContext returns a context that overrides the options of the transactions that are started with it.
+//
+//	ctx = dbx.TxContext(ctx, dbx.WithIsolation(sql.LevelSerializable))
+//	err := store.ReadWriteTx(ctx, fn)
+func TxContext(ctx context.Context, opts ...TxOpt) context.Context {
+	prev, _ := ctx.Value(txOptsKey{}).([]TxOpt)
+	return context.WithValue(ctx, txOptsKey{}, append(prev[:len(prev):len(prev)], opts...))
+}
+
+// TxAttempt returns the attempt of the transaction in its callback, starting at one.
+func TxAttempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(txAttemptKey{}).(int)
+	return attempt
+}
+
+// transaction runs the callback in a transaction of the kind, e.g. read or read write,
+// and retries it on retryable errors.
+func transaction(ctx context.Context, conn *gorm.DB, defaults *TxOpts, kind string, readOnly bool, fn func(context.Context, *gorm.DB) error) error {
+	opts := *defaults
+	if o, ok := ctx.Value(txOptsKey{}).([]TxOpt); ok {
+		opts.Configure(o...)
+	}
+
+	txOpts := &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly || readOnly}
+
+	for attempt := 1; ; attempt++ {
+		err := attemptTx(context.WithValue(ctx, txAttemptKey{}, attempt), conn, txOpts, kind, fn)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= opts.Retry.MaxAttempts || !opts.Retry.retryable(err) || ctx.Err() != nil {
+			if attempt > 1 {
+				return &RetryError{Attempts: attempt, Err: err}
+			}
+
+			return err
+		}
+
+		if opts.Retry.OnRetry != nil {
+			opts.Retry.OnRetry(ctx, attempt, err)
+		}
+
+		timer := time.NewTimer(opts.Retry.Backoff.Delay(attempt - 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &RetryError{Attempts: attempt, Err: err}
+		case <-timer.C:
+		}
+	}
+}
+
+func attemptTx(ctx context.Context, conn *gorm.DB, opts *sql.TxOptions, kind string, fn func(context.Context, *gorm.DB) error) error {
+	tx := conn.WithContext(ctx).Begin(opts)
+	if tx.Error != nil {
+		return NewQueryError("begin "+kind+" transaction", tx.Error)
+	}
+
+	if err := fn(ctx, tx); err != nil {
+		tx.Rollback()
+		return NewQueryError("rollback transaction", err)
+	}
+
+	if err := tx.Commit().Error; err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return NewQueryError("commit "+kind+" transaction", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package dbx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/zeiss/pkg/dbx"
+	"github.com/zeiss/pkg/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sql state " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, dbx.IsRetryable(fmt.Errorf("update: %w", sqlStateError("40001"))))
+	assert.True(t, dbx.IsRetryable(sqlStateError("40P01")))
+	assert.True(t, dbx.IsRetryable(errors.New("database is locked")))
+	assert.True(t, dbx.IsRetryable(dbx.NewQueryError("insert", errors.New("database table is locked: accounts"))))
+	assert.False(t, dbx.IsRetryable(sqlStateError("23505")))
+	assert.False(t, dbx.IsRetryable(dbx.ErrNotFound))
+	assert.False(t, dbx.IsRetryable(nil))
+}
+
+func newTxDatabase(t *testing.T, opts ...dbx.DatabaseOpt) dbx.Database[*gorm.DB, *gorm.DB] {
+	t.Helper()
+
+	store, err := dbx.NewDatabase(
+		newTestDB(t, &account{}),
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		func(tx *gorm.DB) (*gorm.DB, error) { return tx, nil },
+		opts...,
+	)
+	require.NoError(t, err)
+
+	return store
+}
+
+func TestReadWriteTxRetries(t *testing.T) {
+	var retries []int
+
+	store := newTxDatabase(t, dbx.WithTxOpts(dbx.WithRetryPolicy(dbx.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     server.Backoff{},
+		OnRetry:     func(_ context.Context, attempt int, _ error) { retries = append(retries, attempt) },
+	})))
+
+	var attempts []int
+	err := store.ReadWriteTx(t.Context(), func(ctx context.Context, tx *gorm.DB) error {
+		attempts = append(attempts, dbx.TxAttempt(ctx))
+
+		if err := tx.Create(&account{ID: 1, Name: "alice"}).Error; err != nil {
+			return err
+		}
+
+		if dbx.TxAttempt(ctx) < 3 {
+			return sqlStateError("40001")
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+	assert.Equal(t, []int{1, 2}, retries)
+
+	var count int64
+	require.NoError(t, store.ReadTx(t.Context(), func(_ context.Context, tx *gorm.DB) error {
+		return tx.Model(&account{}).Count(&count).Error
+	}))
+	assert.Equal(t, int64(1), count, "failed attempts are rolled back")
+}
+
+func TestReadWriteTxRetriesExhausted(t *testing.T) {
+	store := newTxDatabase(t, dbx.WithTxOpts(dbx.WithRetryPolicy(dbx.RetryPolicy{MaxAttempts: 2})))
+
+	err := store.ReadWriteTx(t.Context(), func(context.Context, *gorm.DB) error {
+		return errors.New("database is locked")
+	})
+
+	var rerr *dbx.RetryError
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, 2, rerr.Attempts)
+
+	var qerr *dbx.QueryError
+	require.ErrorAs(t, err, &qerr)
+	assert.Equal(t, "rollback transaction", qerr.Query)
+}
+
+func TestReadWriteTxNotRetried(t *testing.T) {
+	store := newTxDatabase(t)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+	}{
+		{name: "not retryable", ctx: t.Context(), err: dbx.ErrNotFound},
+		{name: "no retry", ctx: dbx.TxContext(t.Context(), dbx.WithRetryPolicy(dbx.NoRetry)), err: sqlStateError("40P01")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int
+
+			err := store.ReadWriteTx(tc.ctx, func(ctx context.Context, _ *gorm.DB) error {
+				attempts = dbx.TxAttempt(ctx)
+				return tc.err
+			})
+			require.ErrorIs(t, err, tc.err)
+			assert.Equal(t, 1, attempts)
+
+			var rerr *dbx.RetryError
+			assert.NotErrorAs(t, err, &rerr)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package envx
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LookupFunc returns the value of an environment variable, e.g. os.LookupEnv.
+type LookupFunc func(key string) (string, bool)
+
+// LoadEnv sets the fields of the struct that the pointer points to from the environment variables
+// of their envconfig tags, or from their default tags if the variables are not set.
+// Fields without a tag are kept. Slices are comma-separated.
+//
+//	type Config struct {
+//		Host string `envconfig:"PG_HOST" default:"localhost"`
+//	}
+func LoadEnv(dst any, lookup LookupFunc) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("envx: load env into %T, expected a pointer to a struct", dst)
+	}
+
+	v = v.Elem()
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+
+		key, ok := f.Tag.Lookup("envconfig")
+		if !ok || !f.IsExported() {
+			continue
+		}
+
+		value, ok := lookup(key)
+		if !ok {
+			value, ok = f.Tag.Lookup("default")
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := setField(v.Field(i), value); err != nil {
+			return fmt.Errorf("envx: load env %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func setField(f reflect.Value, value string) error {
+	if f.Type() == reflect.TypeFor[time.Duration]() {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Slice:
+		s := reflect.MakeSlice(f.Type(), 0, 0)
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+
+			e := reflect.New(f.Type().Elem()).Elem()
+			if err := setField(e, part); err != nil {
+				return err
+			}
+			s = reflect.Append(s, e)
+		}
+		f.Set(s)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package envx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/envx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type envConfig struct {
+	Name    string        `envconfig:"NAME" default:"app"`
+	Debug   bool          `envconfig:"DEBUG"`
+	Workers uint8         `envconfig:"WORKERS" default:"4"`
+	Ratio   float64       `envconfig:"RATIO"`
+	Timeout time.Duration `envconfig:"TIMEOUT" default:"1s"`
+	Ports   []int         `envconfig:"PORTS"`
+	Kept    string
+}
+
+func TestLoadEnv(t *testing.T) {
+	env := map[string]string{"DEBUG": "true", "RATIO": "0.5", "PORTS": "80, 443", "TIMEOUT": "5m"}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	cfg := envConfig{Kept: "kept"}
+	require.NoError(t, envx.LoadEnv(&cfg, lookup))
+	assert.Equal(t, envConfig{Name: "app", Debug: true, Workers: 4, Ratio: 0.5, Timeout: 5 * time.Minute, Ports: []int{80, 443}, Kept: "kept"}, cfg)
+
+	env["WORKERS"] = "300"
+	require.ErrorContains(t, envx.LoadEnv(&cfg, lookup), "WORKERS")
+	require.Error(t, envx.LoadEnv(cfg, lookup), "a pointer is required")
+}
@@ -0,0 +1,154 @@
+package fiberx
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zeiss/pkg/server"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var _ server.Listener = (*listener)(nil)
+
+// ListenerOpts are the options for the Fiber listener.
+type ListenerOpts struct {
+	// Addr is the address to listen on.
+	Addr string
+	// GracefulTimeout is the time to wait for open connections
+	// when the context of the listener is canceled.
+	GracefulTimeout time.Duration
+	// CertFile is the path to the TLS certificate, it is reloaded when it changes.
+	CertFile string
+	// KeyFile is the path to the TLS key, it is reloaded when it changes.
+	KeyFile string
+	// TLSConfig is the TLS configuration. The certificate is set
+	// when CertFile and KeyFile are configured.
+	TLSConfig *tls.Config
+	// ListenConfig is the configuration that is passed to the Fiber app.
+	ListenConfig fiber.ListenConfig
+}
+
+// Configure is a method that configures the listener options.
+func (o *ListenerOpts) Configure(opts ...ListenerOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultListenerOpts returns the default options for the Fiber listener.
+func DefaultListenerOpts() *ListenerOpts {
+	return &ListenerOpts{
+		Addr:            ":8080",
+		GracefulTimeout: server.DefaultGracefulTimeout,
+		ListenConfig: fiber.ListenConfig{
+			DisableStartupMessage: true,
+		},
+	}
+}
+
+// ListenerOpt is a function that configures the listener options.
+type ListenerOpt func(*ListenerOpts)
+
+// WithAddr is setting the address the listener listens on.
+func WithAddr(addr string) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.Addr = addr
+	}
+}
+
+// WithGracefulTimeout is setting the time to wait for open connections when shutting down.
+func WithGracefulTimeout(timeout time.Duration) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.GracefulTimeout = timeout
+	}
+}
+
+// WithTLS is serving TLS with the certificate and key files, which are reloaded when they change.
+func WithTLS(certFile, keyFile string) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.CertFile = certFile
+		opts.KeyFile = keyFile
+	}
+}
+
+// WithTLSConfig is serving TLS with the configuration.
+func WithTLSConfig(cfg *tls.Config) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.TLSConfig = cfg
+	}
+}
+
+// WithListenConfig is setting the configuration that is passed to the Fiber app.
+func WithListenConfig(cfg fiber.ListenConfig) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.ListenConfig = cfg
+	}
+}
+
+type listener struct {
+	app  *fiber.App
+	opts *ListenerOpts
+
+	mu   sync.Mutex
+	addr net.Addr
+}
+
+// NewListener returns a new server listener that serves the Fiber app.
+// It binds the address before it signals to be ready and shuts down
+// gracefully when its context is canceled.
+func NewListener(app *fiber.App, opts ...ListenerOpt) *listener {
+	options := DefaultListenerOpts()
+	options.Configure(opts...)
+
+	l := new(listener)
+	l.app = app
+	l.opts = options
+
+	return l
+}
+
+// Addr returns the address the listener is bound to, or nil before it has been started.
+func (l *listener) Addr() net.Addr {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.addr
+}
+
+// Start is a method that starts the Fiber listener.
+func (l *listener) Start(ctx context.Context, ready server.ReadyFunc, _ server.RunFunc) func() error {
+	return func() error {
+		ln, err := server.Bind(ctx, l.opts.Addr, l.opts.TLSConfig, l.opts.CertFile, l.opts.KeyFile)
+		if err != nil {
+			return err
+		}
+
+		l.mu.Lock()
+		l.addr = ln.Addr()
+		l.mu.Unlock()
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- l.app.Listener(ln, l.opts.ListenConfig) }()
+
+		ready()
+
+		select {
+		case err := <-errCh:
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+
+			return err
+		case <-ctx.Done():
+		}
+
+		// the app can be served again after the shutdown, when the listener is restarted
+		return server.GracefulShutdown(ctx, l.opts.GracefulTimeout, l.app.ShutdownWithContext, ln.Close)
+	}
+}
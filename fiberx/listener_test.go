@@ -0,0 +1,82 @@
+package fiberx_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/fiberx"
+	"github.com/zeiss/pkg/server"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	srv, _ := server.WithContext(ctx)
+
+	l := fiberx.NewListener(app, fiberx.WithAddr("127.0.0.1:0"), fiberx.WithGracefulTimeout(time.Second))
+	srv.Listen(l, true)
+
+	done := make(chan error)
+	go func() { done <- srv.Wait() }()
+
+	require.Eventually(t, func() bool { return srv.Ready(ctx) == nil }, time.Second, time.Millisecond)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+l.Addr().String(), nil)
+	require.NoError(t, err)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, "hello", string(body))
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestListenerRestart(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	l := fiberx.NewListener(app, fiberx.WithAddr("127.0.0.1:0"), fiberx.WithGracefulTimeout(time.Second))
+
+	for range 2 {
+		ctx, cancel := context.WithCancel(t.Context())
+
+		ready := make(chan struct{})
+		done := make(chan error)
+		go func() { done <- l.Start(ctx, func() { close(ready) }, nil)() }()
+		<-ready
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+l.Addr().String(), nil)
+		require.NoError(t, err)
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, "hello", string(body))
+
+		cancel()
+		require.NoError(t, <-done)
+	}
+}
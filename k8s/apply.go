@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// DefaultFieldManager is the default field manager of server-side apply.
+const DefaultFieldManager = "zeiss-pkg"
+
+// ApplyOpts are the options of Apply.
+type ApplyOpts struct {
+	// FieldManager is the field manager that owns the applied fields.
+	FieldManager string
+	// Force takes the ownership of fields that are owned by other field managers.
+	Force bool
+	// Owner is set as the controller owner reference of the object.
+	Owner client.Object
+}
+
+// Configure configures the options.
+func (o *ApplyOpts) Configure(opts ...ApplyOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultApplyOpts returns the default options.
+func DefaultApplyOpts() *ApplyOpts {
+	return &ApplyOpts{
+		FieldManager: DefaultFieldManager,
+		Force:        true,
+	}
+}
+
+// ApplyOpt is an option of Apply.
+type ApplyOpt func(*ApplyOpts)
+
+// WithFieldManager sets the field manager.
+func WithFieldManager(manager string) ApplyOpt {
+	return func(o *ApplyOpts) {
+		o.FieldManager = manager
+	}
+}
+
+// WithForce sets whether conflicting fields of other field managers are taken over, defaults to true.
+func WithForce(force ...bool) ApplyOpt {
+	return func(o *ApplyOpts) {
+		o.Force = len(force) == 0 || force[0]
+	}
+}
+
+// WithOwner sets the owner that controls the object.
+func WithOwner(owner client.Object) ApplyOpt {
+	return func(o *ApplyOpts) {
+		o.Owner = owner
+	}
+}
+
+// Apply creates or patches the object via server-side apply,
+// only the fields that are set on the object are owned by the field manager.
+// The object is updated with the state of the server.
+func Apply(ctx context.Context, c client.Client, obj client.Object, opts ...ApplyOpt) error {
+	options := DefaultApplyOpts()
+	options.Configure(opts...)
+
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+
+	if options.Owner != nil {
+		if err := controllerutil.SetControllerReference(options.Owner, obj, c.Scheme()); err != nil {
+			return err
+		}
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	u.SetManagedFields(nil)
+	u.SetResourceVersion("")
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u.Object, "status")
+
+	applyOpts := []client.ApplyOption{client.FieldOwner(options.FieldManager)}
+	if options.Force {
+		applyOpts = append(applyOpts, client.ForceOwnership)
+	}
+
+	if err := c.Apply(ctx, client.ApplyConfigurationFromUnstructured(u), applyOpts...); err != nil {
+		return err
+	}
+
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj)
+}
+
+// Prune deletes the objects of the list kind that are controlled by the owner but are not desired anymore.
+// The objects are listed in the namespace of the owner, the list options can narrow them down, e.g. by labels.
+func Prune(ctx context.Context, c client.Client, owner client.Object, list client.ObjectList, desired []client.Object, opts ...client.ListOption) error {
+	if ns := owner.GetNamespace(); ns != "" {
+		opts = append([]client.ListOption{client.InNamespace(ns)}, opts...)
+	}
+
+	if err := c.List(ctx, list, opts...); err != nil {
+		return err
+	}
+
+	keep := make(map[types.NamespacedName]struct{}, len(desired))
+	for _, obj := range desired {
+		keep[client.ObjectKeyFromObject(obj)] = struct{}{}
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok || !metav1.IsControlledBy(obj, owner) {
+			continue
+		}
+
+		if _, ok := keep[client.ObjectKeyFromObject(obj)]; ok {
+			continue
+		}
+
+		errs = append(errs, client.IgnoreNotFound(c.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground))))
+	}
+
+	return errors.Join(errs...)
+}
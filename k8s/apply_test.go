@@ -0,0 +1,91 @@
+package k8s_test
+
+import (
+	"testing"
+
+	"github.com/zeiss/pkg/k8s"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newOwner() *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "default", UID: types.UID("owner-uid")}}
+}
+
+func newConfigMap(name string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}, Data: data}
+}
+
+func TestApply(t *testing.T) {
+	owner := newOwner()
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(owner).Build()
+
+	cm := newConfigMap("child", map[string]string{"key": "a"})
+	require.NoError(t, k8s.Apply(t.Context(), c, cm, k8s.WithOwner(owner), k8s.WithFieldManager("test")))
+	assert.NotEmpty(t, cm.ResourceVersion)
+
+	got := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(t.Context(), client.ObjectKeyFromObject(cm), got))
+	assert.Equal(t, "a", got.Data["key"])
+	assert.True(t, metav1.IsControlledBy(got, owner))
+
+	cm = newConfigMap("child", map[string]string{"key": "b"})
+	require.NoError(t, k8s.Apply(t.Context(), c, cm, k8s.WithOwner(owner), k8s.WithFieldManager("test")))
+
+	require.NoError(t, c.Get(t.Context(), client.ObjectKeyFromObject(cm), got))
+	assert.Equal(t, "b", got.Data["key"])
+	assert.Len(t, got.OwnerReferences, 1)
+}
+
+func TestPrune(t *testing.T) {
+	owner := newOwner()
+	other := newConfigMap("other", nil)
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(owner, other).Build()
+
+	keep := newConfigMap("keep", nil)
+	drop := newConfigMap("drop", nil)
+
+	for _, cm := range []*corev1.ConfigMap{keep, drop} {
+		require.NoError(t, k8s.Apply(t.Context(), c, cm, k8s.WithOwner(owner)))
+	}
+
+	require.NoError(t, k8s.Prune(t.Context(), c, owner, &corev1.ConfigMapList{}, []client.Object{newConfigMap("keep", nil)}))
+
+	list := &corev1.ConfigMapList{}
+	require.NoError(t, c.List(t.Context(), list))
+
+	names := []string{}
+	for _, item := range list.Items {
+		names = append(names, item.Name)
+	}
+	assert.ElementsMatch(t, []string{"owner", "other", "keep"}, names)
+}
+
+func TestSetContentHash(t *testing.T) {
+	cm := newConfigMap("config", map[string]string{"key": "a"})
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret"}, Data: map[string][]byte{"password": []byte("secret")}}
+
+	deploy := &appsv1.Deployment{}
+	require.NoError(t, k8s.SetContentHash(&deploy.Spec.Template, cm, secret))
+
+	h := deploy.Spec.Template.Annotations[k8s.ContentHashAnnotation]
+	assert.NotEmpty(t, h)
+
+	cm.ResourceVersion = "2"
+	same, err := k8s.ContentHash(cm, secret)
+	require.NoError(t, err)
+	assert.Equal(t, h, same, "metadata is not part of the hash")
+
+	cm.Data["key"] = "b"
+	changed, err := k8s.ContentHash(cm, secret)
+	require.NoError(t, err)
+	assert.NotEqual(t, h, changed)
+}
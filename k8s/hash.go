@@ -0,0 +1,61 @@
+package k8s
+
+import (
+	"strconv"
+
+	"github.com/zeiss/pkg/hash"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ContentHashAnnotation is the annotation that carries the content hash of the sources.
+const ContentHashAnnotation = "zeiss.com/content-hash"
+
+// ContentHash returns the hash of the content of the objects, e.g. the data of ConfigMaps and Secrets.
+// The metadata and the status of the objects are not part of the hash.
+func ContentHash(objs ...client.Object) (string, error) {
+	contents := make([]map[string]any, 0, len(objs))
+
+	for _, obj := range objs {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return "", err
+		}
+
+		delete(content, "apiVersion")
+		delete(content, "kind")
+		delete(content, "metadata")
+		delete(content, "status")
+
+		contents = append(contents, content)
+	}
+
+	h, err := hash.Hash(contents, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatUint(h, 16), nil
+}
+
+// SetContentHash sets the content hash of the sources as annotation on the object,
+// e.g. on the pod template of a Deployment to roll it out when a ConfigMap or Secret changes.
+//
+//	err := k8s.SetContentHash(&deployment.Spec.Template, configMap, secret)
+func SetContentHash(obj metav1.Object, sources ...client.Object) error {
+	h, err := ContentHash(sources...)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ContentHashAnnotation] = h
+	obj.SetAnnotations(annotations)
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package reconciler
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConditionReady is true when all sub-reconcilers have completed.
+	ConditionReady = "Ready"
+	// ConditionProgressing is true while a sub-reconciler waits for a requeue.
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is true when a sub-reconciler has failed.
+	ConditionDegraded = "Degraded"
+)
+
+const (
+	// ReasonReconciled is the reason of the conditions of a reconciled object.
+	ReasonReconciled = "Reconciled"
+	// ReasonProgressing is the reason of the conditions of a progressing object.
+	ReasonProgressing = "Progressing"
+	// ReasonFailed is the reason of the conditions of a failed object without an Event.
+	ReasonFailed = "Failed"
+)
+
+const (
+	// PhaseReady is the phase of an object after all sub-reconcilers have completed.
+	PhaseReady = "Ready"
+	// PhaseFailed is the phase of an object after a sub-reconciler has failed.
+	PhaseFailed = "Failed"
+)
+
+// ObjectWithConditions is an object that has status conditions.
+type ObjectWithConditions interface {
+	client.Object
+	// GetConditions returns a pointer to the conditions of the status.
+	GetConditions() *[]metav1.Condition
+}
+
+// ObjectWithPhase is an object that has a status phase.
+type ObjectWithPhase interface {
+	client.Object
+	// GetPhase returns the phase of the status.
+	GetPhase() string
+	// SetPhase sets the phase of the status.
+	SetPhase(phase string)
+}
+
+// SetCondition sets the condition on the object with its generation as observedGeneration,
+// if the object has conditions. It returns true if the condition has changed.
+func SetCondition(obj client.Object, condition metav1.Condition) bool {
+	o, ok := obj.(ObjectWithConditions)
+	if !ok {
+		return false
+	}
+
+	condition.ObservedGeneration = obj.GetGeneration()
+
+	return meta.SetStatusCondition(o.GetConditions(), condition)
+}
+
+// IsReady returns true if the Ready condition of the object is true for its current generation.
+func IsReady(obj client.Object) bool {
+	o, ok := obj.(ObjectWithConditions)
+	if !ok {
+		return false
+	}
+
+	c := meta.FindStatusCondition(*o.GetConditions(), ConditionReady)
+
+	return c != nil && c.Status == metav1.ConditionTrue && c.ObservedGeneration == obj.GetGeneration()
+}
+
+// SetPhase sets the phase of the object, if it has a phase.
+func SetPhase(obj client.Object, phase string) {
+	if o, ok := obj.(ObjectWithPhase); ok {
+		o.SetPhase(phase)
+	}
+}
+
+func setReady(obj client.Object) {
+	SetPhase(obj, PhaseReady)
+	SetCondition(obj, metav1.Condition{Type: ConditionReady, Status: metav1.ConditionTrue, Reason: ReasonReconciled})
+	SetCondition(obj, metav1.Condition{Type: ConditionProgressing, Status: metav1.ConditionFalse, Reason: ReasonReconciled})
+	SetCondition(obj, metav1.Condition{Type: ConditionDegraded, Status: metav1.ConditionFalse, Reason: ReasonReconciled})
+}
+
+func setProgressing(obj client.Object, phase string) {
+	SetPhase(obj, phase)
+	SetCondition(obj, metav1.Condition{Type: ConditionReady, Status: metav1.ConditionFalse, Reason: ReasonProgressing, Message: "waiting for phase " + phase})
+	SetCondition(obj, metav1.Condition{Type: ConditionProgressing, Status: metav1.ConditionTrue, Reason: ReasonProgressing, Message: "waiting for phase " + phase})
+	SetCondition(obj, metav1.Condition{Type: ConditionDegraded, Status: metav1.ConditionFalse, Reason: ReasonProgressing})
+}
+
+func setFailed(obj client.Object, reason string, err error) {
+	SetPhase(obj, PhaseFailed)
+	SetCondition(obj, metav1.Condition{Type: ConditionReady, Status: metav1.ConditionFalse, Reason: reason, Message: err.Error()})
+	SetCondition(obj, metav1.Condition{Type: ConditionProgressing, Status: metav1.ConditionFalse, Reason: reason})
+	SetCondition(obj, metav1.Condition{Type: ConditionDegraded, Status: metav1.ConditionTrue, Reason: reason, Message: err.Error()})
+}
@@ -0,0 +1,196 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/zeiss/pkg/k8s/finalizers"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// SubReconcilerFunc reconciles a part of the object.
+// A non-zero result stops the following sub-reconcilers until the object is requeued.
+type SubReconcilerFunc[T client.Object] func(ctx context.Context, obj T) (reconcile.Result, error)
+
+// SubReconciler is a sub-reconciler that is run in order while the object is in its phase.
+type SubReconciler[T client.Object] struct {
+	// Phase is the phase of the object while the sub-reconciler has not completed.
+	Phase string
+	// Reconcile reconciles the object.
+	Reconcile SubReconcilerFunc[T]
+}
+
+// FinalizeFunc cleans up the object before it is deleted.
+type FinalizeFunc[T client.Object] func(ctx context.Context, obj T) error
+
+// Opts are the options of a Reconciler.
+type Opts[T client.Object] struct {
+	// Finalizer is the finalizer that is added to the object.
+	Finalizer string
+	// Finalize is called before the finalizer is removed from a deleted object.
+	Finalize FinalizeFunc[T]
+	// SubReconcilers are run in order.
+	SubReconcilers []SubReconciler[T]
+}
+
+// Configure configures the options.
+func (o *Opts[T]) Configure(opts ...Opt[T]) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultOpts returns the default options.
+func DefaultOpts[T client.Object]() *Opts[T] {
+	return &Opts[T]{}
+}
+
+// Opt is an option of a Reconciler.
+type Opt[T client.Object] func(*Opts[T])
+
+// WithFinalizer adds the finalizer to the object and calls fn before it is removed.
+func WithFinalizer[T client.Object](finalizer string, fn FinalizeFunc[T]) Opt[T] {
+	return func(o *Opts[T]) {
+		o.Finalizer = finalizer
+		o.Finalize = fn
+	}
+}
+
+// WithSubReconciler appends a sub-reconciler for the phase.
+func WithSubReconciler[T client.Object](phase string, fn SubReconcilerFunc[T]) Opt[T] {
+	return func(o *Opts[T]) {
+		o.SubReconcilers = append(o.SubReconcilers, SubReconciler[T]{Phase: phase, Reconcile: fn})
+	}
+}
+
+// Reconciler is a generic reconciler that fetches the object, handles its finalizer,
+// runs the sub-reconcilers in order and patches the status conditions and phase if they have changed.
+// Every Event returned by a sub-reconciler or the finalizer is recorded,
+// Normal events are not treated as errors.
+//
+//	r := reconciler.NewReconciler[*v1alpha1.Widget](mgr.GetClient(), mgr.GetEventRecorderFor("widget"),
+//		reconciler.WithFinalizer("widgets.example.com/finalizer", cleanup),
+//		reconciler.WithSubReconciler("Provisioning", provision),
+//	)
+type Reconciler[T client.Object] struct {
+	client   client.Client
+	recorder record.EventRecorder
+	opts     *Opts[T]
+}
+
+var _ reconcile.Reconciler = (*Reconciler[client.Object])(nil)
+
+// NewReconciler returns a new Reconciler, T must be a pointer to a struct.
+func NewReconciler[T client.Object](c client.Client, recorder record.EventRecorder, opts ...Opt[T]) *Reconciler[T] {
+	options := DefaultOpts[T]()
+	options.Configure(opts...)
+
+	return &Reconciler[T]{c, recorder, options}
+}
+
+// Reconcile reconciles the object of the request.
+func (r *Reconciler[T]) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	obj := newObject[T]()
+	if err := r.client.Get(ctx, req.NamespacedName, obj); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, r.finalize(ctx, obj)
+	}
+
+	if r.opts.Finalizer != "" && !finalizers.HasFinalizer(obj, r.opts.Finalizer) {
+		obj.SetFinalizers(finalizers.AddFinalizer(obj, r.opts.Finalizer))
+
+		if err := r.client.Update(ctx, obj); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	orig, ok := obj.DeepCopyObject().(T)
+	if !ok {
+		return reconcile.Result{}, errors.New("reconciler: deep copy returned a different type")
+	}
+
+	res, err := r.reconcile(ctx, obj)
+
+	if !equality.Semantic.DeepEqual(orig, obj) {
+		if perr := r.client.Status().Patch(ctx, obj, client.MergeFrom(orig)); perr != nil {
+			err = errors.Join(err, perr)
+		}
+	}
+
+	return res, err
+}
+
+func (r *Reconciler[T]) reconcile(ctx context.Context, obj T) (reconcile.Result, error) {
+	for _, sub := range r.opts.SubReconcilers {
+		res, err := sub.Reconcile(ctx, obj)
+		if err = r.record(obj, err); err != nil {
+			reason := ReasonFailed
+
+			var event *ReconcilerEvent
+			if EventAs(err, &event) {
+				reason = event.Reason
+			}
+
+			setFailed(obj, reason, err)
+
+			return res, err
+		}
+
+		if !res.IsZero() {
+			setProgressing(obj, sub.Phase)
+
+			return res, nil
+		}
+	}
+
+	setReady(obj)
+
+	return reconcile.Result{}, nil
+}
+
+func (r *Reconciler[T]) finalize(ctx context.Context, obj T) error {
+	if r.opts.Finalizer == "" || !finalizers.HasFinalizer(obj, r.opts.Finalizer) {
+		return nil
+	}
+
+	if r.opts.Finalize != nil {
+		if err := r.record(obj, r.opts.Finalize(ctx, obj)); err != nil {
+			return err
+		}
+	}
+
+	obj.SetFinalizers(finalizers.RemoveFinalizer(obj, r.opts.Finalizer))
+
+	return r.client.Update(ctx, obj)
+}
+
+// record records the Event of the error and returns nil for Normal events.
+func (r *Reconciler[T]) record(obj T, err error) error {
+	var event *ReconcilerEvent
+	if err == nil || !EventAs(err, &event) {
+		return err
+	}
+
+	if r.recorder != nil {
+		event.Record(obj, r.recorder)
+	}
+
+	if event.EventType == corev1.EventTypeNormal {
+		return nil
+	}
+
+	return err
+}
+
+func newObject[T client.Object]() T {
+	return reflect.New(reflect.TypeFor[T]().Elem()).Interface().(T) //nolint:forcetypeassert
+}
@@ -0,0 +1,227 @@
+package reconciler_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zeiss/pkg/k8s/reconciler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const finalizer = "widgets.example.com/finalizer"
+
+type widgetStatus struct {
+	Phase      string             `json:"phase,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type widget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Status            widgetStatus `json:"status,omitempty"`
+}
+
+func (w *widget) GetConditions() *[]metav1.Condition { return &w.Status.Conditions }
+func (w *widget) GetPhase() string                   { return w.Status.Phase }
+func (w *widget) SetPhase(phase string)              { w.Status.Phase = phase }
+
+func (w *widget) DeepCopyObject() runtime.Object {
+	c := *w
+	w.ObjectMeta.DeepCopyInto(&c.ObjectMeta)
+	c.Status.Conditions = nil
+
+	for _, cond := range w.Status.Conditions {
+		c.Status.Conditions = append(c.Status.Conditions, *cond.DeepCopy())
+	}
+
+	return &c
+}
+
+type widgetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []widget `json:"items"`
+}
+
+func (l *widgetList) DeepCopyObject() runtime.Object {
+	c := *l
+	c.Items = nil
+
+	for i := range l.Items {
+		c.Items = append(c.Items, *l.Items[i].DeepCopyObject().(*widget)) //nolint:forcetypeassert
+	}
+
+	return &c
+}
+
+func newClient(t *testing.T, funcs interceptor.Funcs, objs ...client.Object) client.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(schema.GroupVersion{Group: "example.com", Version: "v1"}, &widget{}, &widgetList{})
+	metav1.AddToGroupVersion(scheme, schema.GroupVersion{Group: "example.com", Version: "v1"})
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&widget{}).
+		WithInterceptorFuncs(funcs).
+		Build()
+}
+
+func newWidget() *widget {
+	return &widget{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Generation: 2}}
+}
+
+var req = reconcile.Request{NamespacedName: types.NamespacedName{Name: "test", Namespace: "default"}}
+
+func get(t *testing.T, c client.Client) *widget {
+	t.Helper()
+
+	w := &widget{}
+	require.NoError(t, c.Get(t.Context(), req.NamespacedName, w))
+
+	return w
+}
+
+func TestReconcilerReady(t *testing.T) {
+	patches := 0
+	c := newClient(t, interceptor.Funcs{
+		SubResourcePatch: func(ctx context.Context, c client.Client, sub string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			patches++
+			return c.SubResource(sub).Patch(ctx, obj, patch, opts...)
+		},
+	}, newWidget())
+
+	var phases []string
+	step := func(phase string) reconciler.SubReconcilerFunc[*widget] {
+		return func(_ context.Context, _ *widget) (reconcile.Result, error) {
+			phases = append(phases, phase)
+			return reconcile.Result{}, nil
+		}
+	}
+
+	r := reconciler.NewReconciler(c, record.NewFakeRecorder(10),
+		reconciler.WithFinalizer[*widget](finalizer, nil),
+		reconciler.WithSubReconciler("Provisioning", step("Provisioning")),
+		reconciler.WithSubReconciler("Configuring", step("Configuring")),
+	)
+
+	res, err := r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.True(t, res.IsZero())
+	assert.Equal(t, []string{"Provisioning", "Configuring"}, phases)
+
+	w := get(t, c)
+	assert.Contains(t, w.Finalizers, finalizer)
+	assert.Equal(t, reconciler.PhaseReady, w.Status.Phase)
+	assert.True(t, reconciler.IsReady(w))
+	assert.True(t, meta.IsStatusConditionFalse(w.Status.Conditions, reconciler.ConditionProgressing))
+	assert.True(t, meta.IsStatusConditionFalse(w.Status.Conditions, reconciler.ConditionDegraded))
+	assert.Equal(t, int64(2), meta.FindStatusCondition(w.Status.Conditions, reconciler.ConditionReady).ObservedGeneration)
+	assert.Equal(t, 1, patches)
+
+	_, err = r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, patches, "unchanged status is not patched")
+}
+
+func TestReconcilerProgressing(t *testing.T) {
+	c := newClient(t, interceptor.Funcs{}, newWidget())
+
+	called := false
+	r := reconciler.NewReconciler(c, nil,
+		reconciler.WithSubReconciler("Provisioning", func(_ context.Context, _ *widget) (reconcile.Result, error) {
+			return reconcile.Result{RequeueAfter: time.Second}, nil
+		}),
+		reconciler.WithSubReconciler("Configuring", func(_ context.Context, _ *widget) (reconcile.Result, error) {
+			called = true
+			return reconcile.Result{}, nil
+		}),
+	)
+
+	res, err := r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, res.RequeueAfter)
+	assert.False(t, called)
+
+	w := get(t, c)
+	assert.Equal(t, "Provisioning", w.Status.Phase)
+	assert.True(t, meta.IsStatusConditionTrue(w.Status.Conditions, reconciler.ConditionProgressing))
+	assert.False(t, reconciler.IsReady(w))
+}
+
+func TestReconcilerEvents(t *testing.T) {
+	c := newClient(t, interceptor.Funcs{}, newWidget())
+	recorder := record.NewFakeRecorder(10)
+
+	r := reconciler.NewReconciler(c, recorder,
+		reconciler.WithSubReconciler("Provisioning", func(_ context.Context, _ *widget) (reconcile.Result, error) {
+			return reconcile.Result{}, reconciler.NewEvent(corev1.EventTypeNormal, "Provisioned", "provisioned %s", "test")
+		}),
+		reconciler.WithSubReconciler("Configuring", func(_ context.Context, _ *widget) (reconcile.Result, error) {
+			return reconcile.Result{}, reconciler.NewEvent(corev1.EventTypeWarning, "ConfigInvalid", "invalid config")
+		}),
+	)
+
+	_, err := r.Reconcile(t.Context(), req)
+	require.Error(t, err)
+	assert.True(t, reconciler.EventIs(err, reconciler.NewEvent(corev1.EventTypeWarning, "ConfigInvalid", "")))
+
+	assert.Equal(t, "Normal Provisioned provisioned test", <-recorder.Events)
+	assert.Equal(t, "Warning ConfigInvalid invalid config", <-recorder.Events)
+
+	w := get(t, c)
+	assert.Equal(t, reconciler.PhaseFailed, w.Status.Phase)
+
+	degraded := meta.FindStatusCondition(w.Status.Conditions, reconciler.ConditionDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+	assert.Equal(t, "ConfigInvalid", degraded.Reason)
+	assert.Equal(t, "invalid config", degraded.Message)
+}
+
+func TestReconcilerFinalize(t *testing.T) {
+	w := newWidget()
+	w.Finalizers = []string{finalizer}
+	c := newClient(t, interceptor.Funcs{}, w)
+	require.NoError(t, c.Delete(t.Context(), w))
+
+	fail := errors.New("cleanup failed")
+	calls := 0
+	r := reconciler.NewReconciler(c, nil,
+		reconciler.WithFinalizer(finalizer, func(_ context.Context, _ *widget) error {
+			calls++
+			if calls == 1 {
+				return fail
+			}
+			return nil
+		}),
+	)
+
+	_, err := r.Reconcile(t.Context(), req)
+	require.ErrorIs(t, err, fail)
+	assert.Contains(t, get(t, c).Finalizers, finalizer)
+
+	_, err = r.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	_, err = r.Reconcile(t.Context(), req)
+	require.NoError(t, err, "deleted objects are ignored")
+}
@@ -3,8 +3,12 @@ package server
 import (
 	"context"
 	"maps"
+	"net"
 	"net/http"
 	"net/http/pprof"
+	"sync"
+
+	"github.com/zeiss/pkg/envx"
 )
 
 var _ Listener = (*debug)(nil)
@@ -21,7 +25,13 @@ var DefaultRoues = map[string]http.Handler{
 type debug struct {
 	opts    *DebugOpts
 	mux     *http.ServeMux
-	handler *http.Server
+	handler *httpListener
+
+	healthz *health
+	readyz  *health
+	livez   *health
+
+	serverOnce sync.Once
 }
 
 // DebugOpts are the options for the debug listener.
@@ -30,6 +40,12 @@ type DebugOpts struct {
 	Addr string
 	// Routes configures the routes for the debug listener.
 	Routes map[string]http.Handler
+	// HealthChecks are the checks of the health endpoint.
+	HealthChecks []NamedCheck
+	// ReadyChecks are the checks of the readiness endpoint.
+	ReadyChecks []NamedCheck
+	// LiveChecks are the checks of the liveness endpoint.
+	LiveChecks []NamedCheck
 }
 
 // Configure is a method that configures the debug options.
@@ -58,14 +74,16 @@ func NewDebug(opts ...DebugOpt) *debug {
 	d := new(debug)
 	d.opts = options
 
+	d.healthz = newHealth(HealthzPath, options.HealthChecks...)
+	d.readyz = newHealth(ReadyzPath, options.ReadyChecks...)
+	d.livez = newHealth(LivezPath, options.LiveChecks...)
+
 	// create the mux
 	d.mux = http.NewServeMux()
 
 	configureMux(d)
 
-	d.handler = new(http.Server)
-	d.handler.Addr = d.opts.Addr
-	d.handler.Handler = d.mux
+	d.handler = NewHTTPListener(d.mux, WithHTTPAddr(d.opts.Addr))
 
 	return d
 }
@@ -73,17 +91,23 @@ func NewDebug(opts ...DebugOpt) *debug {
 // Start is a method that starts the debug listener.
 func (d *debug) Start(ctx context.Context, ready ReadyFunc, run RunFunc) func() error {
 	return func() error {
-		// noop, call to be ready
-		ready()
-
-		if err := d.handler.ListenAndServe(); err != nil {
-			return err
+		// the readiness follows the server that started the listener,
+		// the check is only added once as the listener can be restarted
+		if srv, ok := fromContext(ctx); ok {
+			d.serverOnce.Do(func() {
+				d.readyz.add(NamedCheck{Name: ServerCheck, Check: srv.Ready})
+			})
 		}
 
-		return nil
+		return d.handler.Start(ctx, ready, run)()
 	}
 }
 
+// Addr returns the address the debug listener is bound to, or nil before it has been started.
+func (d *debug) Addr() net.Addr {
+	return d.handler.Addr()
+}
+
 // WithAddr is adding this status addr as an option.
 func WithAddr(addr string) DebugOpt {
 	return func(opts *DebugOpts) {
@@ -107,8 +131,40 @@ func WithPrometheusHandler(handler http.Handler) DebugOpt {
 	}
 }
 
+// WithHealthCheck is adding a named check to the health endpoint.
+func WithHealthCheck(name string, check envx.Check) DebugOpt {
+	return func(opts *DebugOpts) {
+		opts.HealthChecks = append(opts.HealthChecks, NamedCheck{Name: name, Check: check})
+	}
+}
+
+// WithReadyCheck is adding a named check to the readiness endpoint.
+func WithReadyCheck(name string, check envx.Check) DebugOpt {
+	return func(opts *DebugOpts) {
+		opts.ReadyChecks = append(opts.ReadyChecks, NamedCheck{Name: name, Check: check})
+	}
+}
+
+// WithLiveCheck is adding a named check to the liveness endpoint.
+func WithLiveCheck(name string, check envx.Check) DebugOpt {
+	return func(opts *DebugOpts) {
+		opts.LiveChecks = append(opts.LiveChecks, NamedCheck{Name: name, Check: check})
+	}
+}
+
 func configureMux(d *debug) {
 	for route, handler := range d.opts.Routes {
 		d.mux.Handle(route, handler)
 	}
+
+	// the health endpoints can be replaced by routes
+	for route, handler := range map[string]http.Handler{
+		HealthzPath: d.healthz,
+		ReadyzPath:  d.readyz,
+		LivezPath:   d.livez,
+	} {
+		if _, ok := d.opts.Routes[route]; !ok {
+			d.mux.Handle(route, handler)
+		}
+	}
 }
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeiss/pkg/envx"
+)
+
+const (
+	// HealthzPath is the path of the health endpoint.
+	HealthzPath = "/healthz"
+	// ReadyzPath is the path of the readiness endpoint.
+	ReadyzPath = "/readyz"
+	// LivezPath is the path of the liveness endpoint.
+	LivezPath = "/livez"
+)
+
+// PingCheck is the name of the check that always succeeds.
+const PingCheck = "ping"
+
+// ServerCheck is the name of the check that succeeds once all listeners of the server are ready.
+const ServerCheck = "server"
+
+var (
+	// ErrNotReady is returned when a listener of the server is not ready.
+	ErrNotReady = errors.New("not ready")
+	// ErrShuttingDown is returned when the server is shutting down.
+	ErrShuttingDown = errors.New("shutting down")
+)
+
+// NamedCheck is a check with a name.
+type NamedCheck struct {
+	// Name is the name of the check.
+	Name string
+	// Check is the check to run.
+	Check envx.Check
+}
+
+// CheckResult is the result of a check.
+type CheckResult struct {
+	// Name is the name of the check.
+	Name string `json:"name"`
+	// Status is either "ok" or "failed".
+	Status string `json:"status"`
+	// Error is the error of a failed check.
+	Error string `json:"error,omitempty"`
+	// Latency is the time the check took.
+	Latency string `json:"latency"`
+}
+
+// HealthResult is the verbose result of a health endpoint.
+type HealthResult struct {
+	// Status is either "ok" or "failed".
+	Status string `json:"status"`
+	// Checks are the results of the checks.
+	Checks []CheckResult `json:"checks"`
+}
+
+const (
+	statusOK     = "ok"
+	statusFailed = "failed"
+)
+
+type health struct {
+	name   string
+	mu     sync.RWMutex
+	checks []NamedCheck
+}
+
+func newHealth(name string, checks ...NamedCheck) *health {
+	h := new(health)
+	h.name = name
+	h.checks = append([]NamedCheck{{Name: PingCheck, Check: ping}}, checks...)
+
+	return h
+}
+
+func ping(context.Context) error {
+	return nil
+}
+
+func (h *health) add(checks ...NamedCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks = append(h.checks, checks...)
+}
+
+func (h *health) run(ctx context.Context, exclude ...string) HealthResult {
+	h.mu.RLock()
+	checks := slices.Clone(h.checks)
+	h.mu.RUnlock()
+
+	res := HealthResult{Status: statusOK, Checks: make([]CheckResult, 0, len(checks))}
+
+	for _, c := range checks {
+		if slices.Contains(exclude, c.Name) {
+			continue
+		}
+
+		start := time.Now()
+		err := c.Check(ctx)
+
+		r := CheckResult{Name: c.Name, Status: statusOK, Latency: time.Since(start).String()}
+		if err != nil {
+			r.Status = statusFailed
+			r.Error = err.Error()
+			res.Status = statusFailed
+		}
+
+		res.Checks = append(res.Checks, r)
+	}
+
+	return res
+}
+
+// ServeHTTP runs the checks. The query parameter "verbose" returns the result of every check as JSON,
+// "exclude" skips checks by their name.
+func (h *health) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	res := h.run(r.Context(), query["exclude"]...)
+
+	status := http.StatusOK
+	if res.Status != statusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if query.Has("verbose") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(res)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+
+	if res.Status == statusOK {
+		_, _ = fmt.Fprint(w, statusOK)
+		return
+	}
+
+	failed := make([]string, 0, len(res.Checks))
+	for _, c := range res.Checks {
+		if c.Status != statusOK {
+			failed = append(failed, c.Name)
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "%s check failed: %s", strings.TrimPrefix(h.name, "/"), strings.Join(failed, ", "))
+}
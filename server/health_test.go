@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	h := newHealth(ReadyzPath, NamedCheck{Name: "db", Check: func(context.Context) error { return nil }})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, ReadyzPath, nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "ok", rr.Body.String())
+}
+
+func TestHealthFailed(t *testing.T) {
+	h := newHealth(ReadyzPath, NamedCheck{Name: "db", Check: func(context.Context) error { return errors.New("connection refused") }})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, ReadyzPath, nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "readyz check failed: db", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, ReadyzPath+"?exclude=db", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestHealthVerbose(t *testing.T) {
+	h := newHealth(LivezPath, NamedCheck{Name: "db", Check: func(context.Context) error { return errors.New("connection refused") }})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, LivezPath+"?verbose", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	res := HealthResult{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&res))
+
+	assert.Equal(t, "failed", res.Status)
+	require.Len(t, res.Checks, 2)
+	assert.Equal(t, PingCheck, res.Checks[0].Name)
+	assert.Equal(t, "ok", res.Checks[0].Status)
+	assert.Equal(t, "db", res.Checks[1].Name)
+	assert.Equal(t, "failed", res.Checks[1].Status)
+	assert.Equal(t, "connection refused", res.Checks[1].Error)
+	assert.NotEmpty(t, res.Checks[1].Latency)
+}
+
+func TestDebugHealthRoutes(t *testing.T) {
+	d := NewDebug(WithLiveCheck("goroutines", func(context.Context) error { return nil }))
+
+	for _, path := range []string{HealthzPath, ReadyzPath, LivezPath} {
+		rr := httptest.NewRecorder()
+		d.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+	}
+}
+
+func TestDebugServerCheckOnce(t *testing.T) {
+	srv, _ := WithContext(t.Context())
+	ctx := context.WithValue(t.Context(), serverKey{}, srv)
+
+	// the listener fails to bind and is started again, like a restarted listener
+	d := NewDebug(WithAddr("256.0.0.1:0"))
+	for range 3 {
+		require.Error(t, d.Start(ctx, func() {}, nil)())
+	}
+
+	count := func(h *health) int {
+		n := 0
+		for _, c := range h.checks {
+			if c.Name == ServerCheck {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	assert.Equal(t, 1, count(d.readyz))
+	assert.Equal(t, 0, count(d.healthz), "the health does not fail while the server shuts down")
+}
+
+func TestServerReady(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	srv, _ := WithContext(ctx)
+	rec := &recorder{}
+
+	release := make(chan struct{})
+
+	srv.ListenWith(&testListener{name: "a", rec: rec}, WithReady())
+	srv.ListenWith(newFuncListener(func(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+		return func() error {
+			<-release
+			ready()
+
+			<-ctx.Done()
+
+			return nil
+		}
+	}))
+
+	require.ErrorIs(t, srv.Ready(t.Context()), ErrNotReady)
+
+	done := make(chan error)
+	go func() { done <- srv.Wait() }()
+
+	assert.Eventually(t, func() bool { return len(rec.get()) == 1 }, time.Second, time.Millisecond)
+	require.ErrorIs(t, srv.Ready(t.Context()), ErrNotReady)
+
+	close(release)
+	assert.Eventually(t, func() bool { return srv.Ready(t.Context()) == nil }, time.Second, time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, srv.Ready(t.Context()), ErrShuttingDown)
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultGracefulTimeout is the default time to wait for open connections when shutting down.
+const DefaultGracefulTimeout = 10 * time.Second
+
+var _ Listener = (*httpListener)(nil)
+
+// HTTPOpts are the options for the HTTP listener.
+type HTTPOpts struct {
+	// Addr is the address to listen on.
+	Addr string
+	// GracefulTimeout is the time to wait for open connections
+	// when the context of the listener is canceled.
+	GracefulTimeout time.Duration
+	// CertFile is the path to the TLS certificate, it is reloaded when it changes.
+	CertFile string
+	// KeyFile is the path to the TLS key, it is reloaded when it changes.
+	KeyFile string
+	// TLSConfig is the TLS configuration. The certificate is set
+	// when CertFile and KeyFile are configured.
+	TLSConfig *tls.Config
+	// Server configures the servers that serve the handler,
+	// a new server is created with its settings each time the listener is started.
+	Server *http.Server
+}
+
+// Configure is a method that configures the HTTP options.
+func (o *HTTPOpts) Configure(opts ...HTTPOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultHTTPOpts returns the default options for the HTTP listener.
+func DefaultHTTPOpts() *HTTPOpts {
+	return &HTTPOpts{
+		Addr:            ":8080",
+		GracefulTimeout: DefaultGracefulTimeout,
+		Server: &http.Server{
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// HTTPOpt is a function that configures the HTTP options.
+type HTTPOpt func(*HTTPOpts)
+
+// WithHTTPAddr is setting the address the HTTP listener listens on.
+func WithHTTPAddr(addr string) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.Addr = addr
+	}
+}
+
+// WithGracefulTimeout is setting the time to wait for open connections when shutting down.
+func WithGracefulTimeout(timeout time.Duration) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.GracefulTimeout = timeout
+	}
+}
+
+// WithTLS is serving TLS with the certificate and key files, which are reloaded when they change.
+func WithTLS(certFile, keyFile string) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.CertFile = certFile
+		opts.KeyFile = keyFile
+	}
+}
+
+// WithTLSConfig is serving TLS with the configuration.
+func WithTLSConfig(cfg *tls.Config) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.TLSConfig = cfg
+	}
+}
+
+// WithHTTPServer is using the settings of the server to serve the handler, e.g. to configure timeouts.
+func WithHTTPServer(srv *http.Server) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.Server = srv
+	}
+}
+
+type httpListener struct {
+	opts    *HTTPOpts
+	handler http.Handler
+
+	mu   sync.Mutex
+	addr net.Addr
+}
+
+// NewHTTPListener returns a new listener that serves the handler.
+// It binds the address before it signals to be ready and shuts down
+// gracefully when its context is canceled.
+func NewHTTPListener(handler http.Handler, opts ...HTTPOpt) *httpListener {
+	options := DefaultHTTPOpts()
+	options.Configure(opts...)
+
+	l := new(httpListener)
+	l.opts = options
+	l.handler = handler
+
+	return l
+}
+
+// newServer returns a new server with the configured settings,
+// as a server cannot serve again once it has been shut down.
+func (l *httpListener) newServer() *http.Server {
+	cfg := l.opts.Server
+	if cfg == nil {
+		cfg = new(http.Server)
+	}
+
+	return &http.Server{
+		Addr:                         l.opts.Addr,
+		Handler:                      l.handler,
+		DisableGeneralOptionsHandler: cfg.DisableGeneralOptionsHandler,
+		TLSConfig:                    cfg.TLSConfig,
+		ReadTimeout:                  cfg.ReadTimeout,
+		ReadHeaderTimeout:            cfg.ReadHeaderTimeout,
+		WriteTimeout:                 cfg.WriteTimeout,
+		IdleTimeout:                  cfg.IdleTimeout,
+		MaxHeaderBytes:               cfg.MaxHeaderBytes,
+		TLSNextProto:                 cfg.TLSNextProto,
+		ConnState:                    cfg.ConnState,
+		ErrorLog:                     cfg.ErrorLog,
+		BaseContext:                  cfg.BaseContext,
+		ConnContext:                  cfg.ConnContext,
+		HTTP2:                        cfg.HTTP2,
+		Protocols:                    cfg.Protocols,
+	}
+}
+
+// Addr returns the address the listener is bound to, or nil before it has been started.
+func (l *httpListener) Addr() net.Addr {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.addr
+}
+
+// Start is a method that starts the HTTP listener.
+func (l *httpListener) Start(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+	return func() error {
+		ln, err := Bind(ctx, l.opts.Addr, l.opts.TLSConfig, l.opts.CertFile, l.opts.KeyFile)
+		if err != nil {
+			return err
+		}
+
+		l.mu.Lock()
+		l.addr = ln.Addr()
+		l.mu.Unlock()
+
+		srv := l.newServer()
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- srv.Serve(ln) }()
+
+		ready()
+
+		select {
+		case err := <-errCh:
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+
+			return err
+		case <-ctx.Done():
+		}
+
+		return GracefulShutdown(ctx, l.opts.GracefulTimeout, srv.Shutdown, srv.Close)
+	}
+}
+
+// Bind binds the address and wraps the listener with TLS, when a configuration
+// or the certificate and key files are provided. The certificate files are reloaded when they change.
+func Bind(ctx context.Context, addr string, cfg *tls.Config, certFile, keyFile string) (net.Listener, error) {
+	if certFile != "" && keyFile != "" {
+		r, err := NewCertReloader(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg == nil {
+			cfg = r.TLSConfig()
+		} else {
+			cfg = cfg.Clone()
+			cfg.GetCertificate = r.GetCertificate
+		}
+	}
+
+	var lc net.ListenConfig
+
+	ln, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg != nil {
+		ln = tls.NewListener(ln, cfg)
+	}
+
+	return ln, nil
+}
+
+// GracefulShutdown is calling the shutdown function with a deadline of the graceful timeout,
+// and calls close when the deadline is exceeded.
+func GracefulShutdown(ctx context.Context, timeout time.Duration, shutdown func(context.Context) error, closeFn func() error) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	if err := shutdown(ctx); err != nil {
+		return errors.Join(err, closeFn())
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCert(t *testing.T, dir string, serial int64) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+
+	// make sure the modification time changes between writes
+	mod := time.Now().Add(time.Duration(serial) * time.Second)
+	require.NoError(t, os.Chtimes(certFile, mod, mod))
+	require.NoError(t, os.Chtimes(keyFile, mod, mod))
+
+	return certFile, keyFile
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, 1)
+
+	r, err := NewCertReloader(certFile, keyFile, 0)
+	require.NoError(t, err)
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), leaf.SerialNumber.Int64())
+
+	writeCert(t, dir, 2)
+
+	cert, err = r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), leaf.SerialNumber.Int64())
+}
+
+func TestCertReloaderMissing(t *testing.T) {
+	_, err := NewCertReloader(filepath.Join(t.TempDir(), "tls.crt"), filepath.Join(t.TempDir(), "tls.key"))
+	require.Error(t, err)
+}
+
+func TestHTTPListener(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	srv, _ := WithContext(ctx)
+
+	l := NewHTTPListener(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	}), WithHTTPAddr("127.0.0.1:0"), WithGracefulTimeout(time.Second))
+	srv.Listen(l, true)
+
+	done := make(chan error)
+	go func() { done <- srv.Wait() }()
+
+	require.Eventually(t, func() bool { return srv.Ready(ctx) == nil }, time.Second, time.Millisecond)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+l.Addr().String(), nil)
+	require.NoError(t, err)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, "hello", string(body))
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestHTTPListenerRestart(t *testing.T) {
+	cfg := &http.Server{ReadHeaderTimeout: time.Second}
+
+	l := NewHTTPListener(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	}), WithHTTPAddr("127.0.0.1:0"), WithHTTPServer(cfg))
+
+	for range 2 {
+		ctx, cancel := context.WithCancel(t.Context())
+
+		ready := make(chan struct{})
+		done := make(chan error)
+		go func() { done <- l.Start(ctx, func() { close(ready) }, nil)() }()
+		<-ready
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+l.Addr().String(), nil)
+		require.NoError(t, err)
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, "hello", string(body))
+
+		cancel()
+		require.NoError(t, <-done)
+	}
+
+	assert.Empty(t, cfg.Addr, "the configured server is not changed")
+	assert.Nil(t, cfg.Handler)
+}
+
+func TestHTTPListenerTLS(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	certFile, keyFile := writeCert(t, t.TempDir(), 1)
+
+	srv, _ := WithContext(ctx)
+
+	l := NewHTTPListener(http.NotFoundHandler(), WithHTTPAddr("127.0.0.1:0"), WithTLS(certFile, keyFile))
+	srv.Listen(l, true)
+
+	done := make(chan error)
+	go func() { done <- srv.Wait() }()
+
+	require.Eventually(t, func() bool { return srv.Ready(ctx) == nil }, time.Second, time.Millisecond)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}} //nolint:gosec
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+l.Addr().String(), nil)
+	require.NoError(t, err)
+
+	res, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	require.NotNil(t, res.TLS)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestHTTPListenerBindError(t *testing.T) {
+	srv, _ := WithContext(t.Context())
+	srv.Listen(NewHTTPListener(http.NotFoundHandler(), WithHTTPAddr("256.0.0.1:0")), true)
+
+	lerr := &ListenerError{}
+	require.ErrorAs(t, srv.Wait(), &lerr)
+	assert.Equal(t, PhaseStart, lerr.Phase)
+}
+
+func TestDebugShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	srv, _ := WithContext(ctx)
+	srv.ListenWith(NewDebug(WithAddr("127.0.0.1:0")), WithReady(), WithDrainTimeout(time.Second))
+
+	done := make(chan error)
+	go func() { done <- srv.Wait() }()
+
+	require.Eventually(t, func() bool { return srv.Ready(ctx) == nil }, time.Second, time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,313 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrReadyTimeout is returned when a listener does not signal to be ready in time.
+	ErrReadyTimeout = errors.New("ready timeout exceeded")
+	// ErrDrainTimeout is returned when a listener does not stop in time.
+	ErrDrainTimeout = errors.New("drain timeout exceeded")
+	// ErrUnknownDependency is returned when a listener depends on a listener that is not registered.
+	ErrUnknownDependency = errors.New("unknown dependency")
+	// ErrDependencyCycle is returned when the dependencies of the listeners form a cycle.
+	ErrDependencyCycle = errors.New("dependency cycle")
+)
+
+// DefaultDrainTimeout is the default time a listener has to stop after its context is canceled.
+const DefaultDrainTimeout = 30 * time.Second
+
+// Phase is the phase in the lifecycle of a listener.
+type Phase int32
+
+const (
+	// PhaseStart is the phase after a listener has been started and before it signaled to be ready.
+	PhaseStart Phase = iota
+	// PhaseReady is the phase in which the server waits for a listener to signal to be ready.
+	PhaseReady
+	// PhaseRun is the phase after a listener signaled to be ready.
+	PhaseRun
+	// PhaseShutdown is the phase after the context of a listener has been canceled.
+	PhaseShutdown
+)
+
+// String returns the name of the phase.
+func (p Phase) String() string {
+	switch p {
+	case PhaseStart:
+		return "start"
+	case PhaseReady:
+		return "ready"
+	case PhaseRun:
+		return "run"
+	case PhaseShutdown:
+		return "shutdown"
+	default:
+		return fmt.Sprintf("phase(%d)", int32(p))
+	}
+}
+
+// ListenerError is the error of a listener in a phase.
+type ListenerError struct {
+	// Name is the name of the listener.
+	Name string
+	// Phase is the phase in which the listener failed.
+	Phase Phase
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ListenerError) Error() string {
+	return fmt.Sprintf("listener %s failed in %s phase: %s", e.Name, e.Phase, e.Err)
+}
+
+// Unwrap implements the Unwrap method.
+func (e *ListenerError) Unwrap() error { return e.Err }
+
+// NewListenerError returns a new error for a listener in a phase.
+func NewListenerError(name string, phase Phase, err error) *ListenerError {
+	return &ListenerError{Name: name, Phase: phase, Err: err}
+}
+
+// ListenerOpts are the options for a listener.
+type ListenerOpts struct {
+	// Name is the name of the listener that is used in errors.
+	Name string
+	// Ready configures the server to wait for the listener
+	// to be ready before the next listener is started.
+	Ready bool
+	// DependsOn are the listeners that have to be ready
+	// before the listener is started.
+	DependsOn []Listener
+	// ReadyTimeout is the time the listener has to signal to be ready.
+	// Zero means no timeout.
+	ReadyTimeout time.Duration
+	// DrainTimeout is the time the listener has to stop
+	// after its context has been canceled.
+	DrainTimeout time.Duration
+	// Restart is the policy to restart the listener when it returns.
+	Restart RestartPolicy
+	// Backoff is the backoff between restarts, it defaults to DefaultBackoff.
+	Backoff Backoff
+	// MaxRestarts is the maximum number of restarts within the RestartWindow.
+	// Zero means no limit.
+	MaxRestarts int
+	// RestartWindow is the window in which the restarts are counted, the backoff starts over
+	// once no restart happened within it. It defaults to DefaultRestartWindow without MaxRestarts.
+	RestartWindow time.Duration
+}
+
+// Configure is a method that configures the listener options.
+func (o *ListenerOpts) Configure(opts ...ListenerOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// ListenerOpt is a function that configures the listener options.
+type ListenerOpt func(*ListenerOpts)
+
+// WithName is setting the name of the listener.
+func WithName(name string) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.Name = name
+	}
+}
+
+// WithReady is waiting for the listener to be ready before the next listener is started.
+func WithReady(ready ...bool) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.Ready = len(ready) == 0 || ready[0]
+	}
+}
+
+// WithDependsOn is adding listeners that have to be ready before the listener is started.
+func WithDependsOn(listeners ...Listener) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.DependsOn = append(opts.DependsOn, listeners...)
+	}
+}
+
+// WithReadyTimeout is setting the time the listener has to signal to be ready.
+func WithReadyTimeout(timeout time.Duration) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.ReadyTimeout = timeout
+	}
+}
+
+// WithDrainTimeout is setting the time the listener has to stop after its context has been canceled.
+func WithDrainTimeout(timeout time.Duration) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.DrainTimeout = timeout
+	}
+}
+
+type entry struct {
+	listener Listener
+	opts     *ListenerOpts
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	wg      sync.WaitGroup
+	phase   atomic.Int32
+	started time.Time
+
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	mu           sync.Mutex
+	current      *attempt
+	restarts     int
+	restartTimes []time.Time
+	lastRestart  time.Time
+	lastErr      error
+}
+
+func newEntry(listener Listener, opts *ListenerOpts) *entry {
+	if opts.Name == "" {
+		opts.Name = fmt.Sprintf("%T", listener)
+	}
+
+	e := new(entry)
+	e.listener = listener
+	e.opts = opts
+	e.ready = make(chan struct{})
+
+	return e
+}
+
+func (e *entry) name() string {
+	return e.opts.Name
+}
+
+// newAttempt returns a new attempt to run the listener.
+func (e *entry) newAttempt() *attempt {
+	a := new(attempt)
+	a.ctx, a.cancel = context.WithCancel(e.ctx)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.current = a
+
+	return a
+}
+
+// setReady is called when the attempt signaled to be ready,
+// signals of previous attempts are ignored.
+func (e *entry) setReady(a *attempt) {
+	e.mu.Lock()
+	current := e.current == a
+	e.mu.Unlock()
+
+	if !current {
+		return
+	}
+
+	e.phase.CompareAndSwap(int32(PhaseStart), int32(PhaseRun))
+	e.readyOnce.Do(func() {
+		close(e.ready)
+	})
+}
+
+func (e *entry) setError(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastErr = err
+}
+
+func (e *entry) isReady() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *entry) drainTimeout() time.Duration {
+	if e.opts.DrainTimeout > 0 {
+		return e.opts.DrainTimeout
+	}
+
+	return DefaultDrainTimeout
+}
+
+// stopped returns a channel that is closed when all routines of the listener returned.
+func (e *entry) stopped() <-chan struct{} {
+	ch := make(chan struct{})
+
+	go func() {
+		e.wg.Wait()
+		close(ch)
+	}()
+
+	return ch
+}
+
+// sortListeners is sorting the listeners topological by their dependencies.
+// Listeners without dependencies between each other keep the order in which they have been added.
+func sortListeners(entries []*entry) ([]*entry, error) {
+	index := make(map[Listener]*entry, len(entries))
+	for _, e := range entries {
+		index[e.listener] = e
+	}
+
+	for _, e := range entries {
+		for _, dep := range e.opts.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, NewListenerError(e.name(), PhaseStart, fmt.Errorf("%w: %T", ErrUnknownDependency, dep))
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[*entry]int, len(entries))
+	sorted := make([]*entry, 0, len(entries))
+
+	var visit func(e *entry, path []string) error
+	visit = func(e *entry, path []string) error {
+		switch state[e] {
+		case visited:
+			return nil
+		case visiting:
+			return NewListenerError(e.name(), PhaseStart, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(append(path, e.name()), " -> ")))
+		}
+
+		state[e] = visiting
+
+		for _, dep := range e.opts.DependsOn {
+			if err := visit(index[dep], append(path, e.name())); err != nil {
+				return err
+			}
+		}
+
+		state[e] = visited
+		sorted = append(sorted, e)
+
+		return nil
+	}
+
+	for _, e := range entries {
+		if err := visit(e, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrMaxRestarts is returned when a listener has been restarted too often within the restart window.
+var ErrMaxRestarts = errors.New("max restarts exceeded")
+
+// RestartPolicy is the policy to restart a listener when it returns.
+type RestartPolicy int
+
+const (
+	// RestartNever never restarts the listener, an error cancels the server.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the listener when it returns an error.
+	RestartOnFailure
+	// RestartAlways restarts the listener whenever it returns.
+	RestartAlways
+)
+
+// String returns the name of the restart policy.
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartAlways:
+		return "always"
+	default:
+		return fmt.Sprintf("policy(%d)", int(p))
+	}
+}
+
+// Backoff is an exponential backoff with jitter.
+type Backoff struct {
+	// Initial is the delay before the first restart.
+	Initial time.Duration
+	// Max is the maximum delay between restarts.
+	Max time.Duration
+	// Multiplier is the factor the delay grows with every restart.
+	Multiplier float64
+	// Jitter is the fraction of the delay that is randomized, e.g. 0.2 for +/- 20%.
+	Jitter float64
+}
+
+// DefaultBackoff is the default backoff between restarts.
+var DefaultBackoff = Backoff{
+	Initial:    100 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+// DefaultRestartWindow is the window in which the restarts are counted for the backoff
+// if neither a window nor a maximum number of restarts is set.
+const DefaultRestartWindow = 5 * time.Minute
+
+// Delay returns the delay before the restart with the number of the attempt, starting at zero.
+func (b Backoff) Delay(attempt int) time.Duration {
+	if b.Initial <= 0 {
+		return 0
+	}
+
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(attempt))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1) //nolint:gosec
+	}
+
+	return time.Duration(delay)
+}
+
+// WithRestart is setting the policy to restart the listener when it returns.
+func WithRestart(policy RestartPolicy) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.Restart = policy
+	}
+}
+
+// WithBackoff is setting the backoff between restarts of the listener.
+func WithBackoff(backoff Backoff) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.Backoff = backoff
+	}
+}
+
+// WithMaxRestarts is failing the listener when it has been restarted
+// more than n times within the window.
+func WithMaxRestarts(n int, window time.Duration) ListenerOpt {
+	return func(opts *ListenerOpts) {
+		opts.MaxRestarts = n
+		opts.RestartWindow = window
+	}
+}
+
+// ListenerStatus is a snapshot of the status of a listener.
+type ListenerStatus struct {
+	// Name is the name of the listener.
+	Name string
+	// Started is true when the listener has been started.
+	Started bool
+	// Phase is the current phase of the listener.
+	Phase Phase
+	// Restarts is the number of times the listener has been restarted.
+	Restarts int
+	// LastError is the last error that the listener returned.
+	LastError error
+	// LastRestart is the time of the last restart.
+	LastRestart time.Time
+	// Children are the status of the listeners of a supervisor.
+	Children []ListenerStatus
+}
+
+// attempt is a single run of a listener, it is canceled before the listener is restarted.
+type attempt struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+}
+
+func (a *attempt) fail(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.err == nil {
+		a.err = err
+	}
+
+	a.cancel()
+}
+
+func (a *attempt) failure() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.err
+}
+
+func (e *entry) restartable() bool {
+	return e.opts.Restart != RestartNever
+}
+
+func (e *entry) shouldRestart(err error) bool {
+	switch e.opts.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// restart is recording the restart and returns the delay before the restart,
+// or false when the maximum number of restarts within the window is exceeded.
+func (e *entry) restart(err error) (time.Duration, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+
+	// restarts outside of the window are forgotten, so that the backoff
+	// starts over after a stable run and the restart times do not grow without a limit
+	window := e.opts.RestartWindow
+	if window <= 0 && e.opts.MaxRestarts <= 0 {
+		window = DefaultRestartWindow
+	}
+
+	recent := e.restartTimes[:0]
+	for _, t := range e.restartTimes {
+		if window <= 0 || now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+
+	e.restartTimes = recent
+
+	if e.opts.MaxRestarts > 0 && len(e.restartTimes) >= e.opts.MaxRestarts {
+		return 0, false
+	}
+
+	delay := e.backoff().Delay(len(e.restartTimes))
+
+	e.restartTimes = append(e.restartTimes, now)
+	e.restarts++
+	e.lastRestart = now
+
+	if err != nil {
+		e.lastErr = err
+	}
+
+	return delay, true
+}
+
+func (e *entry) backoff() Backoff {
+	if e.opts.Backoff == (Backoff{}) {
+		return DefaultBackoff
+	}
+
+	return e.opts.Backoff
+}
+
+func (e *entry) status() ListenerStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	st := ListenerStatus{
+		Name:        e.name(),
+		Started:     e.ctx != nil,
+		Phase:       Phase(e.phase.Load()),
+		Restarts:    e.restarts,
+		LastError:   e.lastErr,
+		LastRestart: e.lastRestart,
+	}
+
+	if sup, ok := e.listener.(interface{ Status() []ListenerStatus }); ok {
+		st.Children = sup.Status()
+	}
+
+	return st
+}
+
+// supervise is running the listener and restarts it according to its restart policy.
+func (s *server) supervise(e *entry) error {
+	for {
+		a := e.newAttempt()
+
+		goFn := func(f func() error) {
+			a.wg.Add(1)
+
+			s.run(e, func() error {
+				defer a.wg.Done()
+
+				err := f()
+				if err != nil && e.restartable() && Phase(e.phase.Load()) != PhaseShutdown {
+					// the listener is restarted instead of failing the server
+					a.fail(err)
+					return nil
+				}
+
+				return err
+			})
+		}
+
+		err := e.listener.Start(a.ctx, func() { e.setReady(a) }, goFn)()
+		if err == nil {
+			err = a.failure()
+		}
+
+		if Phase(e.phase.Load()) == PhaseShutdown || !e.shouldRestart(err) {
+			if err == nil {
+				// a listener that returns successfully is considered ready,
+				// e.g. a migration that other listeners depend on
+				e.setReady(a)
+			}
+
+			return err
+		}
+
+		a.cancel()
+		a.wg.Wait()
+
+		delay, ok := e.restart(err)
+		if !ok && err == nil {
+			return ErrMaxRestarts
+		}
+
+		if !ok {
+			return fmt.Errorf("%w: %w", ErrMaxRestarts, err)
+		}
+
+		// the listener is not ready until the next attempt signals to be ready
+		for p := e.phase.Load(); p != int32(PhaseShutdown); p = e.phase.Load() {
+			if e.phase.CompareAndSwap(p, int32(PhaseStart)) {
+				break
+			}
+		}
+
+		t := time.NewTimer(delay)
+
+		select {
+		case <-t.C:
+		case <-e.ctx.Done():
+			t.Stop()
+			return nil
+		}
+	}
+}
+
+// Status returns a snapshot of the status of the listeners.
+func (s *server) Status() []ListenerStatus {
+	status := make([]ListenerStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		status = append(status, e.status())
+	}
+
+	return status
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastBackoff = Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Multiplier: 2}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+
+	assert.Equal(t, 100*time.Millisecond, b.Delay(0))
+	assert.Equal(t, 200*time.Millisecond, b.Delay(1))
+	assert.Equal(t, 400*time.Millisecond, b.Delay(2))
+	assert.Equal(t, time.Second, b.Delay(10))
+
+	b.Jitter = 0.5
+	for range 100 {
+		d := b.Delay(0)
+		assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+		assert.LessOrEqual(t, d, 150*time.Millisecond)
+	}
+
+	assert.Equal(t, time.Duration(0), Backoff{}.Delay(3))
+}
+
+func TestRestartPolicyString(t *testing.T) {
+	assert.Equal(t, "never", RestartNever.String())
+	assert.Equal(t, "on-failure", RestartOnFailure.String())
+	assert.Equal(t, "always", RestartAlways.String())
+}
+
+func TestRestartOnFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	srv, _ := WithContext(ctx)
+
+	var calls atomic.Int32
+
+	worker := newFuncListener(func(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+		return func() error {
+			if calls.Add(1) < 3 {
+				return errors.New("connection lost")
+			}
+
+			ready()
+			<-ctx.Done()
+
+			return nil
+		}
+	})
+	srv.ListenWith(worker, WithName("worker"), WithRestart(RestartOnFailure), WithBackoff(fastBackoff))
+
+	done := make(chan error)
+	go func() { done <- srv.Wait() }()
+
+	require.Eventually(t, func() bool { return srv.Ready(ctx) == nil }, time.Second, time.Millisecond)
+
+	status := srv.Status()
+	require.Len(t, status, 1)
+	assert.Equal(t, "worker", status[0].Name)
+	assert.True(t, status[0].Started)
+	assert.Equal(t, PhaseRun, status[0].Phase)
+	assert.Equal(t, 2, status[0].Restarts)
+	require.EqualError(t, status[0].LastError, "connection lost")
+	assert.False(t, status[0].LastRestart.IsZero())
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestRestartRunFunc(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	srv, _ := WithContext(ctx)
+
+	var calls atomic.Int32
+
+	worker := newFuncListener(func(ctx context.Context, ready ReadyFunc, run RunFunc) func() error {
+		return func() error {
+			n := calls.Add(1)
+
+			run(func() error {
+				if n == 1 {
+					return errors.New("consumer failed")
+				}
+
+				return nil
+			})
+
+			ready()
+			<-ctx.Done()
+
+			return ctx.Err()
+		}
+	})
+	srv.ListenWith(worker, WithRestart(RestartOnFailure), WithBackoff(fastBackoff))
+
+	done := make(chan error)
+	go func() { done <- srv.Wait() }()
+
+	require.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return srv.Ready(ctx) == nil }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, srv.Status()[0].Restarts)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestRestartMaxRestarts(t *testing.T) {
+	srv, _ := WithContext(t.Context())
+
+	var calls atomic.Int32
+
+	worker := newFuncListener(func(context.Context, ReadyFunc, RunFunc) func() error {
+		return func() error {
+			calls.Add(1)
+			return errors.New("crash")
+		}
+	})
+	srv.ListenWith(worker, WithName("worker"), WithRestart(RestartAlways), WithBackoff(fastBackoff), WithMaxRestarts(3, time.Minute))
+
+	err := srv.Wait()
+	require.ErrorIs(t, err, ErrMaxRestarts)
+	assert.Equal(t, int32(4), calls.Load())
+
+	lerr := &ListenerError{}
+	require.ErrorAs(t, err, &lerr)
+	assert.Equal(t, "worker", lerr.Name)
+	assert.Equal(t, 3, srv.Status()[0].Restarts)
+}
+
+func TestRestartForgetsOldRestarts(t *testing.T) {
+	e := newEntry(newFuncListener(nil), &ListenerOpts{Backoff: Backoff{Initial: time.Second, Max: time.Minute, Multiplier: 2}})
+
+	for range 100 {
+		e.restartTimes = append(e.restartTimes, time.Now().Add(-time.Hour))
+	}
+
+	delay, ok := e.restart(nil)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, delay, "the backoff starts over after a stable run")
+	assert.Len(t, e.restartTimes, 1)
+
+	delay, ok = e.restart(nil)
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Second, delay)
+}
+
+func TestSupervisorOneForOne(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var failing, stable atomic.Int32
+
+	sup := NewSupervisor(OneForOne)
+	sup.ListenWith(newFuncListener(func(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+		return func() error {
+			stable.Add(1)
+			ready()
+			<-ctx.Done()
+
+			return nil
+		}
+	}), WithName("stable"))
+	sup.ListenWith(newFuncListener(func(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+		return func() error {
+			if failing.Add(1) == 1 {
+				return errors.New("crash")
+			}
+
+			ready()
+			<-ctx.Done()
+
+			return nil
+		}
+	}), WithName("failing"), WithRestart(RestartOnFailure), WithBackoff(fastBackoff))
+
+	srv, _ := WithContext(ctx)
+	srv.ListenWith(sup, WithName("workers"))
+
+	done := make(chan error)
+	go func() { done <- srv.Wait() }()
+
+	require.Eventually(t, func() bool { return srv.Ready(ctx) == nil }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), stable.Load())
+	assert.Equal(t, int32(2), failing.Load())
+
+	status := srv.Status()
+	require.Len(t, status, 1)
+	require.Len(t, status[0].Children, 2)
+	assert.Equal(t, 0, status[0].Children[0].Restarts)
+	assert.Equal(t, 1, status[0].Children[1].Restarts)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestSupervisorOneForAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var failing, stable atomic.Int32
+
+	sup := NewSupervisor(OneForAll)
+	sup.ListenWith(newFuncListener(func(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+		return func() error {
+			stable.Add(1)
+			ready()
+			<-ctx.Done()
+
+			return nil
+		}
+	}), WithName("stable"), WithReady())
+	sup.ListenWith(newFuncListener(func(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+		return func() error {
+			if failing.Add(1) == 1 {
+				return errors.New("crash")
+			}
+
+			ready()
+			<-ctx.Done()
+
+			return nil
+		}
+	}), WithName("failing"), WithRestart(RestartOnFailure))
+
+	srv, _ := WithContext(ctx)
+	srv.ListenWith(sup, WithName("workers"), WithRestart(RestartOnFailure), WithBackoff(fastBackoff))
+
+	done := make(chan error)
+	go func() { done <- srv.Wait() }()
+
+	require.Eventually(t, func() bool { return srv.Ready(ctx) == nil }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), stable.Load())
+	assert.Equal(t, int32(2), failing.Load())
+
+	status := srv.Status()
+	require.Len(t, status, 1)
+	assert.Equal(t, 1, status[0].Restarts)
+	assert.ErrorContains(t, status[0].LastError, "listener failing failed in start phase: crash")
+
+	cancel()
+	require.NoError(t, <-done)
+}
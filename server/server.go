@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -59,6 +60,20 @@ type Server interface {
 	SetLimit(n int)
 }
 
+// ListenerServer is a Server that adds listeners with options
+// and reports their readiness and status.
+type ListenerServer interface {
+	Server
+	// ListenWith is adding a listener with options,
+	// e.g. the listeners it depends on.
+	ListenWith(listener Listener, opts ...ListenerOpt)
+	// Ready returns an error until all listeners signaled to be ready,
+	// and as soon as the server begins to shutdown.
+	Ready(ctx context.Context) error
+	// Status returns a snapshot of the status of the listeners.
+	Status() []ListenerStatus
+}
+
 // Unimplemented is the default implementation.
 type Unimplemented struct{}
 
@@ -77,122 +92,126 @@ type Listener interface {
 	Start(context.Context, ReadyFunc, RunFunc) func() error
 }
 
-var _ Server = (*server)(nil)
+var (
+	_ Server         = (*server)(nil)
+	_ ListenerServer = (*server)(nil)
+)
+
+type serverKey struct{}
 
-type listeners map[Listener]bool
+// fromContext returns the server that started the listener with the context.
+func fromContext(ctx context.Context) (*server, bool) {
+	s, ok := ctx.Value(serverKey{}).(*server)
+	return s, ok
+}
 
 type server struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	wg      sync.WaitGroup
-	errOnce sync.Once
-	err     error
+	// base is the context the listeners are derived from,
+	// it is not canceled with the server to shutdown the listeners in order.
+	base context.Context
+
+	mu   sync.Mutex
+	errs []error
 
 	sem chan token
 
-	listeners map[Listener]bool
+	entries []*entry
+	index   map[Listener]*entry
+	started []*entry
 
-	ready chan bool
-	sys   chan os.Signal
+	sys     chan os.Signal
+	signals bool
+
+	// allReady is closed once all listeners signaled to be ready.
+	allReady chan struct{}
 }
 
 // WithContext is creating a new server with a context.
+// The returned context is canceled when the server begins to shutdown.
 func WithContext(ctx context.Context) (*server, context.Context) {
-	ctx, cancel := context.WithCancel(ctx)
-
-	// new server
 	s := newServer(ctx)
-	s.cancel = cancel
-	s.ctx = ctx
 
-	return s, ctx
+	return s, s.ctx
 }
 
 func newServer(ctx context.Context) *server {
 	s := new(server)
 
-	ctx, cancel := context.WithCancel(ctx)
-	s.cancel = cancel
-	s.ctx = ctx
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.base = context.WithoutCancel(ctx)
 
-	s.listeners = make(listeners)
-	s.ready = make(chan bool, 1)
+	s.index = make(map[Listener]*entry)
 	s.sys = make(chan os.Signal, 1)
+	s.signals = true
+	s.allReady = make(chan struct{})
 
 	return s
 }
 
 // Listen is adding a listener to the server.
 func (s *server) Listen(listener Listener, wait ...bool) {
-	waiting := false
+	s.ListenWith(listener, WithReady(len(wait) > 0 && wait[0]))
+}
+
+// ListenWith is adding a listener with options to the server.
+// Adding the same listener again replaces its options.
+func (s *server) ListenWith(listener Listener, opts ...ListenerOpt) {
+	options := new(ListenerOpts)
+	options.Configure(opts...)
+
+	e := newEntry(listener, options)
 
-	if len(wait) > 0 {
-		waiting = wait[0]
+	if old, ok := s.index[listener]; ok {
+		for i := range s.entries {
+			if s.entries[i] == old {
+				s.entries[i] = e
+			}
+		}
+	} else {
+		s.entries = append(s.entries, e)
 	}
 
-	s.listeners[listener] = waiting
+	s.index[listener] = e
 }
 
 // Wait is waiting for the server to shutdown or fail.
-// The returned error is the first error that occurred from the listeners.
-//
-//nolint:gocyclo
+// The listeners are started in the order of their dependencies
+// and are stopped in the reverse order once the server context is canceled.
+// The returned error joins the errors of all listeners as *ListenerError.
 func (s *server) Wait() error {
-	// create ticker for interrupt signals
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	signal.Notify(s.sys, syscall.SIGTERM, syscall.SIGINT)
-	defer signal.Reset(syscall.SIGINT, syscall.SIGTERM)
-
-OUTTER:
-	for l, ready := range s.listeners {
-		readyFunc := func() {
-			r := ready
-
-			var readyOnce sync.Once
-			readyOnce.Do(func() {
-				if r {
-					s.ready <- true
-				}
-			})
-		}
-
-		goFn := func(f func() error) { s.run(f) }
-
-		// schedule to routines
-		s.run(l.Start(s.ctx, readyFunc, goFn))
-
-		// this blocks until ready is called
-		if ready {
-			select {
-			case <-s.ready:
-				continue OUTTER
-			case <-s.sys:
-				s.cancel()
-				break OUTTER
-			case <-s.ctx.Done():
-				break OUTTER
-			}
-		}
+	if s.signals {
+		signal.Notify(s.sys, syscall.SIGTERM, syscall.SIGINT)
+		defer signal.Reset(syscall.SIGINT, syscall.SIGTERM)
 	}
 
-	for {
+	go func() {
 		select {
-		case <-ticker.C:
 		case <-s.sys:
 			// if there is sys interrupt
 			// cancel the context of the routines
 			s.cancel()
 		case <-s.ctx.Done():
-			if s.err != nil {
-				return s.err
-			}
-
-			return nil
 		}
+	}()
+
+	sorted, err := sortListeners(s.entries)
+	if err != nil {
+		s.fail(err)
+	} else {
+		s.startAll(sorted)
 	}
+
+	<-s.ctx.Done()
+
+	s.shutdown()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return errors.Join(s.errs...)
 }
 
 // SetLimit limits the number of active listeners in this server.
@@ -209,33 +228,169 @@ func (s *server) SetLimit(n int) {
 	s.sem = make(chan token, n)
 }
 
-func (s *server) run(f func() error) {
+// Ready returns an error until all listeners signaled to be ready,
+// and as soon as the server begins to shutdown.
+func (s *server) Ready(context.Context) error {
+	if s.ctx.Err() != nil {
+		return ErrShuttingDown
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.started) < len(s.entries) {
+		return fmt.Errorf("%w: %d of %d listeners started", ErrNotReady, len(s.started), len(s.entries))
+	}
+
+	for _, e := range s.started {
+		if Phase(e.phase.Load()) != PhaseRun {
+			return fmt.Errorf("%w: %s", ErrNotReady, e.name())
+		}
+	}
+
+	return nil
+}
+
+func (s *server) startAll(sorted []*entry) {
+	for _, e := range sorted {
+		for _, dep := range e.opts.DependsOn {
+			if !s.awaitReady(s.index[dep]) {
+				return
+			}
+		}
+
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		s.start(e)
+
+		if e.opts.Ready && !s.awaitReady(e) {
+			return
+		}
+	}
+
+	go func() {
+		for _, e := range sorted {
+			select {
+			case <-e.ready:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+
+		close(s.allReady)
+	}()
+}
+
+func (s *server) start(e *entry) {
+	e.mu.Lock()
+	e.ctx, e.cancel = context.WithCancel(context.WithValue(s.base, serverKey{}, s))
+	e.started = time.Now()
+	e.mu.Unlock()
+
+	s.mu.Lock()
+	s.started = append(s.started, e)
+	s.mu.Unlock()
+
+	// schedule to routines
+	s.run(e, func() error { return s.supervise(e) })
+}
+
+// awaitReady blocks until the listener is ready, or the server is canceled.
+func (s *server) awaitReady(e *entry) bool {
+	var timeout <-chan time.Time
+
+	if e.opts.ReadyTimeout > 0 {
+		t := time.NewTimer(time.Until(e.started.Add(e.opts.ReadyTimeout)))
+		defer t.Stop()
+
+		timeout = t.C
+	}
+
+	select {
+	case <-e.ready:
+		return true
+	case <-timeout:
+		if !e.isReady() && s.ctx.Err() == nil {
+			s.fail(NewListenerError(e.name(), PhaseReady, ErrReadyTimeout))
+		}
+
+		return e.isReady()
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+// shutdown is canceling the started listeners in reverse order
+// and waits for each of them to stop.
+func (s *server) shutdown() {
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+
+	for i := len(started) - 1; i >= 0; i-- {
+		e := started[i]
+
+		e.phase.Store(int32(PhaseShutdown))
+		e.cancel()
+
+		t := time.NewTimer(e.drainTimeout())
+
+		select {
+		case <-e.stopped():
+		case <-t.C:
+			s.record(NewListenerError(e.name(), PhaseShutdown, ErrDrainTimeout))
+		}
+
+		t.Stop()
+	}
+}
+
+func (s *server) run(e *entry, f func() error) {
 	if s.sem != nil {
 		s.sem <- token{}
 	}
 
-	s.wg.Add(1)
+	e.wg.Add(1)
 
 	runFunc := func() {
-		defer s.done()
-
-		if err := f(); err != nil {
-			s.errOnce.Do(func() {
-				s.err = err
-				if s.cancel != nil {
-					s.cancel()
-				}
-			})
+		defer s.done(e)
+
+		err := f()
+		if err == nil {
+			return
 		}
+
+		phase := Phase(e.phase.Load())
+		if phase == PhaseShutdown && (errors.Is(err, context.Canceled) || errors.Is(err, http.ErrServerClosed)) {
+			return
+		}
+
+		e.setError(err)
+		s.fail(NewListenerError(e.name(), phase, err))
 	}
 
 	go runFunc()
 }
 
-func (s *server) done() {
+// fail is recording the error and cancels the server.
+func (s *server) fail(err error) {
+	s.record(err)
+	s.cancel()
+}
+
+func (s *server) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errs = append(s.errs, err)
+}
+
+func (s *server) done(e *entry) {
 	if s.sem != nil {
 		<-s.sem
 	}
 
-	s.wg.Done()
+	e.wg.Done()
 }
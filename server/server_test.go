@@ -1,7 +1,11 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -69,3 +73,218 @@ func TestNewError(t *testing.T) {
 	require.NotNil(t, err.Unwrap())
 	require.Equal(t, ErrUnimplemented, err.Unwrap())
 }
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.events...)
+}
+
+type testListener struct {
+	name string
+	rec  *recorder
+	err  error
+	stop time.Duration
+}
+
+func (l *testListener) Start(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+	return func() error {
+		l.rec.add("start " + l.name)
+
+		if l.err != nil {
+			return l.err
+		}
+
+		ready()
+
+		<-ctx.Done()
+		time.Sleep(l.stop)
+
+		l.rec.add("stop " + l.name)
+
+		return ctx.Err()
+	}
+}
+
+type funcListener struct {
+	start func(context.Context, ReadyFunc, RunFunc) func() error
+}
+
+func newFuncListener(start func(context.Context, ReadyFunc, RunFunc) func() error) *funcListener {
+	return &funcListener{start: start}
+}
+
+func (l *funcListener) Start(ctx context.Context, ready ReadyFunc, run RunFunc) func() error {
+	return l.start(ctx, ready, run)
+}
+
+func TestListenWithDependencies(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	srv, _ := WithContext(ctx)
+	rec := &recorder{}
+
+	debug := &testListener{name: "debug", rec: rec}
+	migrator := &testListener{name: "migrator", rec: rec}
+	api := &testListener{name: "api", rec: rec}
+
+	srv.ListenWith(api, WithName("api"), WithDependsOn(migrator))
+	srv.ListenWith(migrator, WithName("migrator"), WithDependsOn(debug))
+	srv.ListenWith(debug, WithName("debug"), WithReady())
+
+	go func() {
+		assert.Eventually(t, func() bool { return len(rec.get()) == 3 }, time.Second, time.Millisecond)
+		cancel()
+	}()
+
+	require.NoError(t, srv.Wait())
+	assert.Equal(t, []string{
+		"start debug", "start migrator", "start api",
+		"stop api", "stop migrator", "stop debug",
+	}, rec.get())
+}
+
+func TestListenWithCycle(t *testing.T) {
+	srv, _ := WithContext(t.Context())
+	rec := &recorder{}
+
+	a := &testListener{name: "a", rec: rec}
+	b := &testListener{name: "b", rec: rec}
+
+	srv.ListenWith(a, WithName("a"), WithDependsOn(b))
+	srv.ListenWith(b, WithName("b"), WithDependsOn(a))
+
+	err := srv.Wait()
+	require.ErrorIs(t, err, ErrDependencyCycle)
+	assert.Contains(t, err.Error(), "a -> b -> a")
+	assert.Empty(t, rec.get())
+}
+
+func TestListenWithUnknownDependency(t *testing.T) {
+	srv, _ := WithContext(t.Context())
+	rec := &recorder{}
+
+	srv.ListenWith(&testListener{name: "a", rec: rec}, WithDependsOn(&Unimplemented{}))
+
+	err := srv.Wait()
+	require.ErrorIs(t, err, ErrUnknownDependency)
+	assert.Empty(t, rec.get())
+}
+
+func TestListenerErrorPhase(t *testing.T) {
+	srv, _ := WithContext(t.Context())
+	rec := &recorder{}
+
+	failing := &testListener{name: "failing", rec: rec, err: errors.New("boom")}
+	srv.ListenWith(failing, WithName("failing"), WithReady())
+
+	err := srv.Wait()
+	require.Error(t, err)
+
+	lerr := &ListenerError{}
+	require.ErrorAs(t, err, &lerr)
+	assert.Equal(t, "failing", lerr.Name)
+	assert.Equal(t, PhaseStart, lerr.Phase)
+	assert.Equal(t, "listener failing failed in start phase: boom", lerr.Error())
+}
+
+func TestListenerRunError(t *testing.T) {
+	srv, _ := WithContext(t.Context())
+	rec := &recorder{}
+
+	srv.ListenWith(&testListener{name: "ok", rec: rec}, WithName("ok"), WithReady())
+	srv.ListenWith(newFuncListener(func(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+		return func() error {
+			ready()
+			return errors.New("crashed")
+		}
+	}), WithName("crashing"))
+
+	err := srv.Wait()
+
+	lerr := &ListenerError{}
+	require.ErrorAs(t, err, &lerr)
+	assert.Equal(t, "crashing", lerr.Name)
+	assert.Equal(t, PhaseRun, lerr.Phase)
+	assert.Contains(t, rec.get(), "stop ok")
+}
+
+func TestListenerReadyTimeout(t *testing.T) {
+	srv, _ := WithContext(t.Context())
+
+	srv.ListenWith(newFuncListener(func(ctx context.Context, _ ReadyFunc, _ RunFunc) func() error {
+		return func() error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+	}), WithName("slow"), WithReady(), WithReadyTimeout(10*time.Millisecond))
+
+	err := srv.Wait()
+	require.ErrorIs(t, err, ErrReadyTimeout)
+
+	lerr := &ListenerError{}
+	require.ErrorAs(t, err, &lerr)
+	assert.Equal(t, PhaseReady, lerr.Phase)
+}
+
+func TestListenerDrainTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	srv, _ := WithContext(ctx)
+	rec := &recorder{}
+
+	srv.ListenWith(&testListener{name: "stuck", rec: rec, stop: 200 * time.Millisecond}, WithName("stuck"), WithReady(), WithDrainTimeout(10*time.Millisecond))
+
+	go func() {
+		assert.Eventually(t, func() bool { return len(rec.get()) == 1 }, time.Second, time.Millisecond)
+		cancel()
+	}()
+
+	err := srv.Wait()
+	require.ErrorIs(t, err, ErrDrainTimeout)
+
+	lerr := &ListenerError{}
+	require.ErrorAs(t, err, &lerr)
+	assert.Equal(t, PhaseShutdown, lerr.Phase)
+}
+
+func TestListenerDependsOnCompleted(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	srv, _ := WithContext(ctx)
+	rec := &recorder{}
+
+	migrate := newFuncListener(func(context.Context, ReadyFunc, RunFunc) func() error {
+		return func() error {
+			rec.add("migrate")
+			return nil
+		}
+	})
+
+	srv.ListenWith(&testListener{name: "api", rec: rec}, WithDependsOn(migrate))
+	srv.ListenWith(migrate)
+
+	go func() {
+		assert.Eventually(t, func() bool { return len(rec.get()) == 2 }, time.Second, time.Millisecond)
+		cancel()
+	}()
+
+	require.NoError(t, srv.Wait())
+	assert.Equal(t, []string{"migrate", "start api", "stop api"}, rec.get())
+}
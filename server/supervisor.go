@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"sync"
+)
+
+// Strategy is the strategy of a supervisor to restart its listeners.
+type Strategy int
+
+const (
+	// OneForOne restarts only the listener that returned,
+	// according to its restart policy.
+	OneForOne Strategy = iota
+	// OneForAll stops all listeners of the supervisor when one of them fails.
+	// The supervisor itself returns the error and is restarted
+	// according to the restart policy it has been added with.
+	OneForAll
+)
+
+var _ Listener = (*supervisor)(nil)
+
+type child struct {
+	listener Listener
+	opts     []ListenerOpt
+}
+
+type supervisor struct {
+	strategy Strategy
+	children []child
+
+	mu      sync.Mutex
+	current *server
+}
+
+// NewSupervisor returns a new listener that supervises other listeners.
+// Supervisors can be added to supervisors to build a supervision tree.
+//
+//	workers := server.NewSupervisor(server.OneForAll)
+//	workers.ListenWith(consumer, server.WithName("consumer"))
+//	workers.ListenWith(warmer, server.WithName("warmer"))
+//
+//	s.ListenWith(workers, server.WithName("workers"), server.WithRestart(server.RestartOnFailure))
+func NewSupervisor(strategy Strategy) *supervisor {
+	s := new(supervisor)
+	s.strategy = strategy
+
+	return s
+}
+
+// Listen is adding a listener to the supervisor.
+func (s *supervisor) Listen(listener Listener, ready ...bool) {
+	s.ListenWith(listener, WithReady(len(ready) > 0 && ready[0]))
+}
+
+// ListenWith is adding a listener with options to the supervisor.
+func (s *supervisor) ListenWith(listener Listener, opts ...ListenerOpt) {
+	s.children = append(s.children, child{listener: listener, opts: opts})
+}
+
+// Status returns a snapshot of the status of the supervised listeners.
+func (s *supervisor) Status() []ListenerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		return nil
+	}
+
+	return s.current.Status()
+}
+
+// Start is a method that starts the supervised listeners.
+// The supervisor is ready once all of its listeners are ready.
+func (s *supervisor) Start(ctx context.Context, ready ReadyFunc, _ RunFunc) func() error {
+	return func() error {
+		srv := newServer(ctx)
+		srv.signals = false
+
+		for _, c := range s.children {
+			opts := c.opts
+			if s.strategy == OneForAll {
+				opts = append(append([]ListenerOpt(nil), opts...), WithRestart(RestartNever))
+			}
+
+			srv.ListenWith(c.listener, opts...)
+		}
+
+		s.mu.Lock()
+		s.current = srv
+		s.mu.Unlock()
+
+		go func() {
+			select {
+			case <-srv.allReady:
+				ready()
+			case <-srv.ctx.Done():
+			}
+		}()
+
+		return srv.Wait()
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCertCheckInterval is the default interval in which the certificate files are checked for changes.
+const DefaultCertCheckInterval = 10 * time.Second
+
+// CertReloader is loading a certificate and its key from disk,
+// and reloads them when the files have been changed.
+//
+//	r, err := server.NewCertReloader("tls.crt", "tls.key")
+//	if err != nil {
+//		panic(err)
+//	}
+//
+//	srv := &http.Server{TLSConfig: r.TLSConfig()}
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// NewCertReloader returns a new reloader for the certificate and key files.
+// The files are checked for changes once in the interval, which defaults to DefaultCertCheckInterval.
+func NewCertReloader(certFile, keyFile string, interval ...time.Duration) (*CertReloader, error) {
+	r := new(CertReloader)
+	r.certFile = certFile
+	r.keyFile = keyFile
+	r.interval = DefaultCertCheckInterval
+
+	if len(interval) > 0 {
+		r.interval = interval[0]
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload is loading the certificate and key from disk.
+func (r *CertReloader) Reload() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.modTime = modTime
+	r.checked = time.Now()
+
+	return nil
+}
+
+// GetCertificate returns the current certificate, it implements tls.Config.GetCertificate.
+// A certificate that fails to reload is ignored and the previous certificate is kept.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if r.expired() {
+		if modTime, err := r.lastModified(); err == nil && modTime.After(r.loaded()) {
+			_ = r.Reload()
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// TLSConfig returns a TLS configuration that uses the reloaded certificate.
+func (r *CertReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+func (r *CertReloader) expired() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < r.interval {
+		return false
+	}
+
+	r.checked = time.Now()
+
+	return true
+}
+
+func (r *CertReloader) loaded() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.modTime
+}
+
+func (r *CertReloader) lastModified() (time.Time, error) {
+	var modTime time.Time
+
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTime, err
+		}
+
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	return modTime, nil
+}
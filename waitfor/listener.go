@@ -0,0 +1,43 @@
+package waitfor
+
+import (
+	"context"
+
+	"github.com/zeiss/pkg/server"
+)
+
+var _ server.Listener = (*listener)(nil)
+
+type listener struct {
+	waiter  *Waiter
+	targets []string
+}
+
+// NewListener returns a listener that is ready once all targets are available,
+// and fails when waiting for them fails. Listeners that depend on it
+// are only started after the targets are available.
+//
+//	db := waitfor.NewListener(waitfor.New(), "postgres://localhost:5432")
+//	s.ListenWith(db, server.WithName("db"), server.WithReady())
+//	s.ListenWith(api, server.WithName("api"), server.WithDependsOn(db))
+func NewListener(waiter *Waiter, targets ...string) *listener {
+	l := new(listener)
+	l.waiter = waiter
+	l.targets = targets
+
+	return l
+}
+
+// Start is waiting for the targets and blocks until the context is done.
+func (l *listener) Start(ctx context.Context, ready server.ReadyFunc, _ server.RunFunc) func() error {
+	return func() error {
+		if _, err := l.waiter.Wait(ctx, l.targets...); err != nil {
+			return err
+		}
+		ready()
+
+		<-ctx.Done()
+
+		return nil
+	}
+}
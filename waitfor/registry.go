@@ -0,0 +1,77 @@
+package waitfor
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/zeiss/pkg/waitfor/schemes"
+)
+
+// WaitFunc checks once if the target is available.
+type WaitFunc = schemes.WaitFunc
+
+// Registry maps the schemes of targets to the functions to wait for them.
+type Registry struct {
+	mu    sync.RWMutex
+	funcs map[string]WaitFunc
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry() *Registry {
+	r := new(Registry)
+	r.funcs = make(map[string]WaitFunc)
+
+	return r
+}
+
+// DefaultRegistry returns a new registry with all built-in schemes.
+// HTTP targets are checked with the default options of schemes.HTTP.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(schemes.HTTP(), "http", "https")
+	r.Register(schemes.TCP(), "tcp")
+	r.Register(schemes.Postgres(), "postgres", "postgresql")
+	r.Register(schemes.MySQL(), "mysql")
+	r.Register(schemes.Redis(), "redis", "rediss")
+	r.Register(schemes.AMQP(), "amqp", "amqps")
+	r.Register(schemes.GRPC(), "grpc", "grpcs")
+	r.Register(schemes.DNS(), "dns")
+	r.Register(schemes.File(), "file")
+	r.Register(schemes.Unix(), "unix")
+
+	return r
+}
+
+// Register is registering the function for the schemes, replacing existing ones.
+func (r *Registry) Register(fn WaitFunc, scheme ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range scheme {
+		r.funcs[s] = fn
+	}
+}
+
+// Lookup returns the function for the scheme.
+func (r *Registry) Lookup(scheme string) (WaitFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fn, ok := r.funcs[scheme]
+
+	return fn, ok
+}
+
+// Schemes returns the sorted registered schemes.
+func (r *Registry) Schemes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s := make([]string, 0, len(r.funcs))
+	for scheme := range r.funcs {
+		s = append(s, scheme)
+	}
+	slices.Sort(s)
+
+	return s
+}
@@ -0,0 +1,63 @@
+package schemes
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+var amqpHeader = []byte("AMQP\x00\x00\x09\x01")
+
+// AMQP returns a wait function that waits for an AMQP 0-9-1 server, e.g. RabbitMQ,
+// to start the connection handshake. amqps:// connects with TLS.
+func AMQP() WaitFunc {
+	return func(ctx context.Context, urlStr string) error {
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return err
+		}
+
+		port := "5672"
+		if u.Scheme == "amqps" {
+			port = "5671"
+		}
+
+		conn, err := dial(ctx, "tcp", hostPort(u.Host, port), u.Scheme == "amqps")
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if _, err := conn.Write(amqpHeader); err != nil {
+			return err
+		}
+
+		// a method frame: type, channel, size and the class and method id
+		var frame [11]byte
+		if _, err := io.ReadFull(conn, frame[:8]); err != nil {
+			return fmt.Errorf("handshake: %w", err)
+		}
+
+		if bytes.HasPrefix(frame[:], []byte("AMQP")) {
+			// the server responds with the protocol version it supports
+			return fmt.Errorf("handshake: unsupported protocol version %d-%d-%d", frame[5], frame[6], frame[7])
+		}
+
+		if _, err := io.ReadFull(conn, frame[8:]); err != nil {
+			return fmt.Errorf("handshake: %w", err)
+		}
+
+		class := binary.BigEndian.Uint16(frame[7:9])
+		method := binary.BigEndian.Uint16(frame[9:11])
+
+		// connection.start
+		if frame[0] != 1 || class != 10 || method != 10 {
+			return fmt.Errorf("handshake: unexpected frame type %d method %d.%d", frame[0], class, method)
+		}
+
+		return nil
+	}
+}
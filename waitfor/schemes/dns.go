@@ -0,0 +1,51 @@
+package schemes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// DNS returns a wait function that waits for a name to resolve, e.g. dns://db.local.
+// The name can be resolved with a specific server with dns://10.0.0.10:53/db.local.
+func DNS() WaitFunc {
+	return func(ctx context.Context, urlStr string) error {
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return err
+		}
+
+		name := u.Hostname()
+		resolver := net.DefaultResolver
+
+		if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+			name = path
+			server := hostPort(u.Host, "53")
+
+			resolver = &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, network, server)
+				},
+			}
+		}
+
+		if name == "" {
+			return fmt.Errorf("no name to resolve in %q", urlStr)
+		}
+
+		addrs, err := resolver.LookupHost(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if len(addrs) == 0 {
+			return fmt.Errorf("no addresses for %s", name)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,21 @@
+package schemes
+
+import (
+	"context"
+	"net/url"
+	"os"
+)
+
+// File returns a wait function that waits for a file to exist, e.g. file:///tmp/ready.
+func File() WaitFunc {
+	return func(_ context.Context, urlStr string) error {
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stat(u.Host + u.Path)
+
+		return err
+	}
+}
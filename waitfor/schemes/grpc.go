@@ -0,0 +1,50 @@
+package schemes
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// GRPC returns a wait function that waits for a gRPC server to report to be serving
+// with the grpc.health.v1.Health/Check method. The path of the URL is the name of the
+// service to check, e.g. grpc://localhost:50051/my.Service. grpcs:// connects with TLS.
+func GRPC() WaitFunc {
+	return func(ctx context.Context, urlStr string) error {
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return err
+		}
+
+		creds := insecure.NewCredentials()
+		if u.Scheme == "grpcs" {
+			creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+		}
+
+		conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+			Service: strings.TrimPrefix(u.Path, "/"),
+		})
+		if err != nil {
+			return fmt.Errorf("health check: %w", err)
+		}
+
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("health check: status %s", resp.GetStatus())
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,477 @@
+package schemes
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HTTPParamPrefix is the prefix of the query parameters that configure the assertions of a URL.
+// They are removed from the URL before the request is sent, e.g.
+// https://example.com/health?wait.status=2xx&wait.jsonpath=$.status=UP.
+const HTTPParamPrefix = "wait."
+
+// maxBodySize is the maximum size of a body that is read for the assertions.
+const maxBodySize = 1 << 20
+
+// StatusRange is a range of accepted status codes.
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// Contains returns true if the status code is within the range.
+func (r StatusRange) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+// ParseStatusRanges parses a comma separated list of status codes and ranges, e.g. "200-299,301" or "2xx,3xx".
+func ParseStatusRanges(s string) ([]StatusRange, error) {
+	ranges := make([]StatusRange, 0)
+
+	for part := range strings.SplitSeq(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if len(part) == 3 && strings.HasSuffix(strings.ToLower(part), "xx") {
+			c, err := strconv.Atoi(part[:1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid status range %q", part)
+			}
+
+			ranges = append(ranges, StatusRange{Min: c * 100, Max: c*100 + 99})
+			continue
+		}
+
+		lo, hi, ok := strings.Cut(part, "-")
+		if !ok {
+			hi = lo
+		}
+
+		minCode, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status range %q", part)
+		}
+
+		maxCode, err := strconv.Atoi(hi)
+		if err != nil || maxCode < minCode {
+			return nil, fmt.Errorf("invalid status range %q", part)
+		}
+
+		ranges = append(ranges, StatusRange{Min: minCode, Max: maxCode})
+	}
+
+	return ranges, nil
+}
+
+// HTTPOpts are the options for the HTTP wait function.
+type HTTPOpts struct {
+	// Method is the method of the request, it defaults to HEAD,
+	// or GET if the body is asserted.
+	Method string
+	// Status are the accepted status codes, it defaults to 200.
+	Status []StatusRange
+	// Headers are the required response headers. An empty value only requires the header to be present.
+	Headers map[string]string
+	// Body is a substring that the body has to contain.
+	Body string
+	// BodyRegex is a regular expression that the body has to match.
+	BodyRegex *regexp.Regexp
+	// JSONPath is a path in the JSON body that has to exist, e.g. $.checks[0].status,
+	// or that has to be equal to a value, e.g. $.status=UP.
+	JSONPath string
+	// CAFile is the path to the CA certificates to verify the server.
+	CAFile string
+	// Insecure skips the verification of the server certificate.
+	Insecure bool
+	// BearerToken is the token that is sent in the Authorization header.
+	// The user and password of the URL are sent as basic auth.
+	BearerToken string
+}
+
+// Configure is a method that configures the HTTP options.
+func (o *HTTPOpts) Configure(opts ...HTTPOpt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// HTTPOpt is a function that configures the HTTP options.
+type HTTPOpt func(*HTTPOpts)
+
+// WithMethod is setting the method of the request.
+func WithMethod(method string) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.Method = method
+	}
+}
+
+// WithStatus is setting the accepted status codes.
+func WithStatus(ranges ...StatusRange) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.Status = ranges
+	}
+}
+
+// WithHeader is requiring a response header, an empty value only requires the header to be present.
+func WithHeader(name, value string) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		if opts.Headers == nil {
+			opts.Headers = make(map[string]string)
+		}
+
+		opts.Headers[name] = value
+	}
+}
+
+// WithBody is requiring the body to contain the substring.
+func WithBody(substr string) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.Body = substr
+	}
+}
+
+// WithBodyRegex is requiring the body to match the regular expression.
+func WithBodyRegex(re *regexp.Regexp) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.BodyRegex = re
+	}
+}
+
+// WithJSONPath is requiring the path to exist in the JSON body, or to be equal to a value with PATH=VALUE.
+func WithJSONPath(path string) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.JSONPath = path
+	}
+}
+
+// WithCAFile is verifying the server with the CA certificates of the file.
+func WithCAFile(name string) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.CAFile = name
+	}
+}
+
+// WithInsecure is skipping the verification of the server certificate.
+func WithInsecure(insecure ...bool) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.Insecure = len(insecure) == 0 || insecure[0]
+	}
+}
+
+// WithBearerToken is sending the token in the Authorization header.
+func WithBearerToken(token string) HTTPOpt {
+	return func(opts *HTTPOpts) {
+		opts.BearerToken = token
+	}
+}
+
+// HTTP returns a wait function that waits for a HTTP server to respond with an accepted status.
+// The options can be overridden per URL with query parameters prefixed with HTTPParamPrefix:
+// method, status, header (Name or Name:Value, repeatable), body, regex, jsonpath, ca, insecure and bearer.
+func HTTP(opts ...HTTPOpt) WaitFunc {
+	defaults := new(HTTPOpts)
+	defaults.Configure(opts...)
+
+	clients := new(httpClients)
+
+	return func(ctx context.Context, urlStr string) error {
+		o := *defaults
+		o.Headers = maps.Clone(defaults.Headers)
+		if o.Headers == nil {
+			o.Headers = make(map[string]string)
+		}
+
+		u, err := parseHTTPParams(urlStr, &o)
+		if err != nil {
+			return err
+		}
+
+		client, err := clients.get(&o)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, o.method(), u.String(), nil)
+		if err != nil {
+			return err
+		}
+
+		if o.BearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+o.BearerToken)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		return o.check(resp)
+	}
+}
+
+// parseHTTPParams configures the options from the query parameters and returns the URL without them.
+func parseHTTPParams(urlStr string, o *HTTPOpts) (*url.URL, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+	found := false
+
+	for key, values := range q {
+		name, ok := strings.CutPrefix(key, HTTPParamPrefix)
+		if !ok {
+			continue
+		}
+
+		q.Del(key)
+		found = true
+		value := values[len(values)-1]
+
+		switch name {
+		case "method":
+			o.Method = strings.ToUpper(value)
+		case "status":
+			if o.Status, err = ParseStatusRanges(value); err != nil {
+				return nil, err
+			}
+		case "header":
+			for _, v := range values {
+				n, val, _ := strings.Cut(v, ":")
+				o.Headers[strings.TrimSpace(n)] = strings.TrimSpace(val)
+			}
+		case "body":
+			o.Body = value
+		case "regex":
+			if o.BodyRegex, err = regexp.Compile(value); err != nil {
+				return nil, err
+			}
+		case "jsonpath":
+			o.JSONPath = value
+		case "ca":
+			o.CAFile = value
+		case "insecure":
+			o.Insecure = value == "" || value == "true" || value == "1"
+		case "bearer":
+			o.BearerToken = value
+		default:
+			return nil, fmt.Errorf("unknown parameter %q", key)
+		}
+	}
+
+	if found {
+		u.RawQuery = q.Encode()
+	}
+
+	return u, nil
+}
+
+func (o *HTTPOpts) method() string {
+	if o.Method != "" {
+		return o.Method
+	}
+
+	if o.Body != "" || o.BodyRegex != nil || o.JSONPath != "" {
+		return http.MethodGet
+	}
+
+	return http.MethodHead
+}
+
+// httpClients are the clients by their TLS options, so that the connections
+// and the CA certificates are reused by the attempts to reach a target.
+type httpClients struct {
+	mu      sync.Mutex
+	clients map[httpClientKey]*http.Client
+}
+
+type httpClientKey struct {
+	caFile   string
+	insecure bool
+}
+
+func (c *httpClients) get(o *HTTPOpts) (*http.Client, error) {
+	key := httpClientKey{o.CAFile, o.Insecure}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, ok := c.clients[key]; ok {
+		return client, nil
+	}
+
+	client, err := o.client()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.clients == nil {
+		c.clients = make(map[httpClientKey]*http.Client)
+	}
+	c.clients[key] = client
+
+	return client, nil
+}
+
+func (o *HTTPOpts) client() (*http.Client, error) {
+	if o.CAFile == "" && !o.Insecure {
+		return http.DefaultClient, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.Insecure, //nolint:gosec
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", o.CAFile)
+		}
+	}
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = cfg
+
+	return &http.Client{Transport: tr}, nil
+}
+
+func (o *HTTPOpts) check(resp *http.Response) error {
+	status := o.Status
+	if len(status) == 0 {
+		status = []StatusRange{{Min: http.StatusOK, Max: http.StatusOK}}
+	}
+
+	accepted := false
+	for _, r := range status {
+		accepted = accepted || r.Contains(resp.StatusCode)
+	}
+
+	if !accepted {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	for name, value := range o.Headers {
+		got, ok := resp.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return fmt.Errorf("header %s is missing", name)
+		}
+
+		if value != "" && !strings.EqualFold(strings.Join(got, ", "), value) {
+			return fmt.Errorf("header %s is %q, expected %q", name, strings.Join(got, ", "), value)
+		}
+	}
+
+	if o.Body == "" && o.BodyRegex == nil && o.JSONPath == "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+
+	if o.Body != "" && !strings.Contains(string(body), o.Body) {
+		return fmt.Errorf("body does not contain %q", o.Body)
+	}
+
+	if o.BodyRegex != nil && !o.BodyRegex.Match(body) {
+		return fmt.Errorf("body does not match %q", o.BodyRegex)
+	}
+
+	if o.JSONPath != "" {
+		return checkJSONPath(body, o.JSONPath)
+	}
+
+	return nil
+}
+
+// checkJSONPath checks that the path exists in the JSON body, or is equal to the value of PATH=VALUE.
+func checkJSONPath(body []byte, expr string) error {
+	path, want, compare := strings.Cut(expr, "=")
+
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return fmt.Errorf("body is not JSON: %w", err)
+	}
+
+	got, err := JSONPath(doc, path)
+	if err != nil {
+		return err
+	}
+
+	if !compare {
+		return nil
+	}
+
+	s, ok := got.(string)
+	if !ok {
+		b, _ := json.Marshal(got)
+		s = string(b)
+	}
+
+	if s != want {
+		return fmt.Errorf("%s is %s, expected %s", path, s, want)
+	}
+
+	return nil
+}
+
+// ErrJSONPath is returned when a JSON path does not exist in a document.
+var ErrJSONPath = errors.New("json path not found")
+
+// JSONPath returns the value of a path in a decoded JSON document. The path supports
+// fields and array indices, e.g. $.checks[0].status or checks.0.status.
+func JSONPath(doc any, path string) (any, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+
+	curr := doc
+
+	for segment := range strings.SplitSeq(path, ".") {
+		if segment == "" {
+			continue
+		}
+
+		switch v := curr.(type) {
+		case map[string]any:
+			next, ok := v[segment]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrJSONPath, path)
+			}
+			curr = next
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("%w: %s", ErrJSONPath, path)
+			}
+			curr = v[i]
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrJSONPath, path)
+		}
+	}
+
+	return curr, nil
+}
@@ -0,0 +1,157 @@
+package schemes
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatusRanges(t *testing.T) {
+	ranges, err := ParseStatusRanges("200-299, 301,4xx")
+	require.NoError(t, err)
+	assert.Equal(t, []StatusRange{{200, 299}, {301, 301}, {400, 499}}, ranges)
+
+	for _, s := range []string{"abc", "300-200", "x00", "2xx-3xx"} {
+		_, err := ParseStatusRanges(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Version", "1.2.3")
+			_, _ = w.Write([]byte(`{"status":"UP","checks":[{"name":"db","status":"DOWN"}],"count":2}`))
+		case "/auth":
+			user, pass, ok := r.BasicAuth()
+			if (ok && user == "admin" && pass == "secret") || r.Header.Get("Authorization") == "Bearer token" {
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/method":
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		opts []HTTPOpt
+		err  string
+	}{
+		{name: "ok", url: "/health"},
+		{name: "not found", url: "/missing", err: "status code 404"},
+		{name: "exact status", url: "/created", err: "status code 201"},
+		{name: "status range", url: "/created?wait.status=2xx"},
+		{name: "status option", url: "/missing", opts: []HTTPOpt{WithStatus(StatusRange{400, 404})}},
+		{name: "method", url: "/method?wait.method=post"},
+		{name: "wrong method", url: "/method", err: "status code 405"},
+		{name: "header present", url: "/health?wait.header=X-Version"},
+		{name: "header value", url: "/health?wait.header=X-Version:1.2.3&wait.header=content-type:application/json"},
+		{name: "header missing", url: "/health?wait.header=X-Missing", err: "header X-Missing is missing"},
+		{name: "header mismatch", url: "/health", opts: []HTTPOpt{WithHeader("X-Version", "2.0.0")}, err: `expected "2.0.0"`},
+		{name: "body", url: "/health?wait.body=UP"},
+		{name: "body mismatch", url: "/health?wait.body=STARTING", err: "body does not contain"},
+		{name: "regex", url: "/health", opts: []HTTPOpt{WithBodyRegex(regexp.MustCompile(`"count":\d+`))}},
+		{name: "regex mismatch", url: "/health?wait.regex=^DOWN", err: "body does not match"},
+		{name: "jsonpath exists", url: "/health?wait.jsonpath=$.checks[0].name"},
+		{name: "jsonpath value", url: "/health?wait.jsonpath=$.status=UP"},
+		{name: "jsonpath number", url: "/health?wait.jsonpath=count=2"},
+		{name: "jsonpath mismatch", url: "/health?wait.jsonpath=$.checks[0].status=UP", err: "is DOWN, expected UP"},
+		{name: "jsonpath missing", url: "/health?wait.jsonpath=$.checks[1]", err: "json path not found"},
+		{name: "unknown parameter", url: "/health?wait.foo=bar", err: "unknown parameter"},
+		{name: "unauthorized", url: "/auth", err: "status code 401"},
+		{name: "basic auth", url: "http://admin:secret@" + srv.Listener.Addr().String() + "/auth"},
+		{name: "bearer", url: "/auth?wait.bearer=token"},
+		{name: "bearer option", url: "/auth", opts: []HTTPOpt{WithBearerToken("token")}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u := tc.url
+			if !strings.HasPrefix(u, "http") {
+				u = srv.URL + u
+			}
+
+			err := HTTP(tc.opts...)(testContext(t), u)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestHTTPTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	require.ErrorContains(t, HTTP()(testContext(t), srv.URL), "certificate")
+	require.NoError(t, HTTP()(testContext(t), srv.URL+"?wait.insecure"))
+	require.NoError(t, HTTP(WithInsecure())(testContext(t), srv.URL))
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+
+	require.NoError(t, HTTP(WithCAFile(ca))(testContext(t), srv.URL))
+	require.NoError(t, HTTP()(testContext(t), srv.URL+"?wait.ca="+ca))
+}
+
+func TestHTTPReusesClient(t *testing.T) {
+	var conns atomic.Int32
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	wait := HTTP(WithInsecure())
+	for range 3 {
+		require.NoError(t, wait(testContext(t), srv.URL))
+	}
+
+	assert.Equal(t, int32(1), conns.Load(), "the connection is reused by the attempts")
+}
+
+func TestJSONPath(t *testing.T) {
+	doc := map[string]any{"a": []any{map[string]any{"b": "c"}}}
+
+	v, err := JSONPath(doc, "$.a[0].b")
+	require.NoError(t, err)
+	assert.Equal(t, "c", v)
+
+	v, err = JSONPath(doc, "a.0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"b": "c"}, v)
+
+	_, err = JSONPath(doc, "$.a[1]")
+	require.ErrorIs(t, err, ErrJSONPath)
+
+	_, err = JSONPath(doc, "$.a[0].b.c")
+	require.ErrorIs(t, err, ErrJSONPath)
+}
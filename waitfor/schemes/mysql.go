@@ -0,0 +1,65 @@
+package schemes
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// MySQL returns a wait function that waits for a MySQL server to accept connections.
+// It reads the initial handshake of the server, which is an error
+// when the server is not able to accept connections, e.g. too many connections.
+func MySQL() WaitFunc {
+	return func(ctx context.Context, urlStr string) error {
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return err
+		}
+
+		conn, err := dial(ctx, "tcp", hostPort(u.Host, "3306"), false)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		var header [4]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return fmt.Errorf("handshake: %w", err)
+		}
+
+		n := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return fmt.Errorf("handshake: %w", err)
+		}
+
+		if len(payload) == 0 {
+			return errors.New("handshake: empty packet")
+		}
+
+		switch payload[0] {
+		case 0x0a:
+			// protocol version 10
+			return nil
+		case 0xff:
+			if len(payload) < 3 {
+				return errors.New("handshake: error packet")
+			}
+
+			msg := payload[3:]
+			if len(msg) > 0 && msg[0] == '#' && len(msg) >= 6 {
+				// skip the sql state marker and state
+				msg = msg[6:]
+			}
+
+			return fmt.Errorf("handshake: error %d: %s", binary.LittleEndian.Uint16(payload[1:3]), bytes.TrimSpace(msg))
+		default:
+			return fmt.Errorf("handshake: unsupported protocol version %d", payload[0])
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package schemes
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Redis returns a wait function that waits for a Redis server to answer a PING.
+// The password and user of the URL are used to authenticate, rediss:// connects with TLS.
+func Redis() WaitFunc {
+	return func(ctx context.Context, urlStr string) error {
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return err
+		}
+
+		conn, err := dial(ctx, "tcp", hostPort(u.Host, "6379"), u.Scheme == "rediss")
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		if pass, ok := u.User.Password(); ok {
+			args := []string{"AUTH", pass}
+			if user := u.User.Username(); user != "" {
+				args = []string{"AUTH", user, pass}
+			}
+
+			if _, err := redisCommand(conn, r, args...); err != nil {
+				return fmt.Errorf("auth: %w", err)
+			}
+		}
+
+		reply, err := redisCommand(conn, r, "PING")
+		if err != nil {
+			return fmt.Errorf("ping: %w", err)
+		}
+
+		if reply != "PONG" {
+			return fmt.Errorf("ping: unexpected reply %q", reply)
+		}
+
+		return nil
+	}
+}
+
+// redisCommand sends the command and returns a simple string reply.
+func redisCommand(conn net.Conn, r *bufio.Reader, args ...string) (string, error) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return "", err
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return "", errors.New(line[1:])
+	default:
+		return "", fmt.Errorf("unexpected reply %q", line)
+	}
+}
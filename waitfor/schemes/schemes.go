@@ -0,0 +1,47 @@
+package schemes
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+)
+
+type WaitFunc func(context.Context, string) error
+
+// dial connects to the address and closes the connection when the context is done.
+func dial(ctx context.Context, network, addr string, useTLS bool) (net.Conn, error) {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if !useTLS {
+		return conn, nil
+	}
+
+	host, _, _ := net.SplitHostPort(addr)
+
+	tc := tls.Client(conn, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+	if err := tc.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return tc, nil
+}
+
+// hostPort adds the default port to the host if it has none.
+func hostPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+
+	return net.JoinHostPort(strings.Trim(host, "[]"), port)
+}
@@ -0,0 +1,202 @@
+package schemes
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// fakeServer serves the connections with the handler and returns its address.
+func fakeServer(t *testing.T, network, addr string, handler func(net.Conn)) string {
+	t.Helper()
+
+	ln, err := net.Listen(network, addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				handler(conn)
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	return ctx
+}
+
+func fakeRedis(password string) func(net.Conn) {
+	return func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		authed := password == ""
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			n, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+
+			args := make([]string, 0, n)
+			for range n {
+				// skip the length of the bulk string
+				if _, err := r.ReadString('\n'); err != nil {
+					return
+				}
+
+				arg, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				args = append(args, strings.TrimSpace(arg))
+			}
+
+			switch {
+			case args[0] == "AUTH" && args[len(args)-1] == password:
+				authed = true
+				_, _ = conn.Write([]byte("+OK\r\n"))
+			case args[0] == "AUTH":
+				_, _ = conn.Write([]byte("-WRONGPASS invalid password\r\n"))
+			case !authed:
+				_, _ = conn.Write([]byte("-NOAUTH Authentication required.\r\n"))
+			default:
+				_, _ = conn.Write([]byte("+PONG\r\n"))
+			}
+		}
+	}
+}
+
+func TestRedis(t *testing.T) {
+	addr := fakeServer(t, "tcp", "127.0.0.1:0", fakeRedis("secret"))
+	fn := Redis()
+
+	require.NoError(t, fn(testContext(t), "redis://:secret@"+addr))
+	require.NoError(t, fn(testContext(t), "redis://default:secret@"+addr+"/0"))
+	require.ErrorContains(t, fn(testContext(t), "redis://"+addr), "NOAUTH")
+	require.ErrorContains(t, fn(testContext(t), "redis://:wrong@"+addr), "WRONGPASS")
+}
+
+func mysqlPacket(payload []byte) []byte {
+	n := len(payload)
+	return append([]byte{byte(n), byte(n >> 8), byte(n >> 16), 0}, payload...)
+}
+
+func TestMySQL(t *testing.T) {
+	fn := MySQL()
+
+	ok := fakeServer(t, "tcp", "127.0.0.1:0", func(conn net.Conn) {
+		_, _ = conn.Write(mysqlPacket(append([]byte{0x0a}, "8.0.36\x00"...)))
+	})
+	require.NoError(t, fn(testContext(t), "mysql://root@"+ok+"/app"))
+
+	busy := fakeServer(t, "tcp", "127.0.0.1:0", func(conn net.Conn) {
+		payload := []byte{0xff}
+		payload = binary.LittleEndian.AppendUint16(payload, 1040)
+		payload = append(payload, "#08004Too many connections"...)
+		_, _ = conn.Write(mysqlPacket(payload))
+	})
+	require.ErrorContains(t, fn(testContext(t), "mysql://"+busy), "error 1040: Too many connections")
+}
+
+func TestAMQP(t *testing.T) {
+	fn := AMQP()
+
+	ok := fakeServer(t, "tcp", "127.0.0.1:0", func(conn net.Conn) {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(conn, header); err != nil || string(header) != string(amqpHeader) {
+			return
+		}
+
+		// connection.start with an empty payload
+		frame := []byte{1, 0, 0, 0, 0, 0, 4, 0, 10, 0, 10, 0xce}
+		_, _ = conn.Write(frame)
+	})
+	require.NoError(t, fn(testContext(t), "amqp://guest:guest@"+ok+"/"))
+
+	old := fakeServer(t, "tcp", "127.0.0.1:0", func(conn net.Conn) {
+		_, _ = conn.Write([]byte("AMQP\x00\x00\x08\x00"))
+	})
+	require.ErrorContains(t, fn(testContext(t), "amqp://"+old), "unsupported protocol version 0-8-0")
+}
+
+func TestGRPC(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	hs := health.NewServer()
+	hs.SetServingStatus("app.Service", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	fn := GRPC()
+	addr := ln.Addr().String()
+
+	require.NoError(t, fn(testContext(t), "grpc://"+addr))
+	require.ErrorContains(t, fn(testContext(t), "grpc://"+addr+"/app.Service"), "NOT_SERVING")
+	require.ErrorContains(t, fn(testContext(t), "grpc://"+addr+"/unknown.Service"), "NotFound")
+
+	hs.SetServingStatus("app.Service", healthpb.HealthCheckResponse_SERVING)
+	require.NoError(t, fn(testContext(t), "grpc://"+addr+"/app.Service"))
+}
+
+func TestDNS(t *testing.T) {
+	fn := DNS()
+
+	require.NoError(t, fn(testContext(t), "dns://localhost"))
+	require.Error(t, fn(testContext(t), "dns://does-not-exist.invalid"))
+	require.Error(t, fn(testContext(t), "dns://"))
+}
+
+func TestFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "ready")
+	fn := File()
+
+	require.Error(t, fn(testContext(t), "file://"+name))
+	require.NoError(t, os.WriteFile(name, nil, 0o600))
+	require.NoError(t, fn(testContext(t), "file://"+name))
+}
+
+func TestUnix(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.sock")
+	fn := Unix()
+
+	require.Error(t, fn(testContext(t), "unix://"+name))
+
+	fakeServer(t, "unix", name, func(net.Conn) {})
+	assert.NoError(t, fn(testContext(t), "unix://"+name))
+}
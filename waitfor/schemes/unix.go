@@ -0,0 +1,24 @@
+package schemes
+
+import (
+	"context"
+	"net/url"
+)
+
+// Unix returns a wait function that waits for a connection
+// to a Unix socket to be established, e.g. unix:///var/run/app.sock.
+func Unix() WaitFunc {
+	return func(ctx context.Context, urlStr string) error {
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return err
+		}
+
+		conn, err := dial(ctx, "unix", u.Host+u.Path, false)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	}
+}
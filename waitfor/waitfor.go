@@ -0,0 +1,292 @@
+// Package waitfor waits for services to become available.
+//
+//	w := waitfor.New(waitfor.WithTimeout(time.Minute))
+//	if _, err := w.Wait(ctx, "postgres://localhost:5432", "http://localhost:8080/healthz"); err != nil {
+//		return err
+//	}
+package waitfor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/zeiss/pkg/server"
+)
+
+// ErrUnsupportedScheme is returned for targets with a scheme that is not registered.
+var ErrUnsupportedScheme = errors.New("unsupported scheme")
+
+// DefaultBackoff is the default backoff between the attempts.
+var DefaultBackoff = server.Backoff{
+	Initial:    500 * time.Millisecond,
+	Max:        5 * time.Second,
+	Multiplier: 1.5,
+	Jitter:     0.1,
+}
+
+// EventType is the type of an event.
+type EventType string
+
+const (
+	// EventRetry is emitted when an attempt failed and the target is checked again.
+	EventRetry EventType = "retry"
+	// EventReady is emitted when the target is available.
+	EventReady EventType = "ready"
+	// EventFailed is emitted when waiting for the target failed.
+	EventFailed EventType = "failed"
+)
+
+// Event is emitted while waiting for a target.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType
+	// Target is the target that is waited for.
+	Target string
+	// Attempt is the number of the attempt, starting at one.
+	Attempt int
+	// Elapsed is the time since waiting for the target started.
+	Elapsed time.Duration
+	// Delay is the delay before the next attempt of a retry.
+	Delay time.Duration
+	// Err is the error of the attempt.
+	Err error
+}
+
+// Result is the result of waiting for a target.
+type Result struct {
+	// Target is the target that has been waited for.
+	Target string
+	// Attempts is the number of attempts.
+	Attempts int
+	// Duration is the time it took until the target was available or waiting failed.
+	Duration time.Duration
+	// Err is nil if the target is available.
+	Err error
+}
+
+// Ready returns true if the target is available.
+func (r Result) Ready() bool {
+	return r.Err == nil
+}
+
+// Opts are the options for a waiter.
+type Opts struct {
+	// Registry is the registry of the wait functions.
+	Registry *Registry
+	// Timeout is the time to wait for all targets, zero waits until the context is done.
+	Timeout time.Duration
+	// AttemptTimeout is the time a single attempt may take.
+	AttemptTimeout time.Duration
+	// Backoff is the backoff between the attempts.
+	Backoff server.Backoff
+	// Parallel waits for all targets concurrently.
+	Parallel bool
+	// OnEvent is called for every event, concurrently when waiting in parallel.
+	OnEvent func(Event)
+}
+
+// Configure is configuring the options.
+func (o *Opts) Configure(opts ...Opt) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// DefaultOpts returns the default options.
+func DefaultOpts() *Opts {
+	return &Opts{
+		AttemptTimeout: 5 * time.Second,
+		Backoff:        DefaultBackoff,
+	}
+}
+
+// Opt is a function that configures the options.
+type Opt func(*Opts)
+
+// WithRegistry is setting the registry of the wait functions.
+func WithRegistry(registry *Registry) Opt {
+	return func(opts *Opts) {
+		opts.Registry = registry
+	}
+}
+
+// WithTimeout is setting the time to wait for all targets.
+func WithTimeout(timeout time.Duration) Opt {
+	return func(opts *Opts) {
+		opts.Timeout = timeout
+	}
+}
+
+// WithAttemptTimeout is setting the time a single attempt may take.
+func WithAttemptTimeout(timeout time.Duration) Opt {
+	return func(opts *Opts) {
+		opts.AttemptTimeout = timeout
+	}
+}
+
+// WithBackoff is setting the backoff between the attempts.
+func WithBackoff(backoff server.Backoff) Opt {
+	return func(opts *Opts) {
+		opts.Backoff = backoff
+	}
+}
+
+// WithInterval is setting a constant interval between the attempts.
+func WithInterval(interval time.Duration) Opt {
+	return func(opts *Opts) {
+		opts.Backoff = server.Backoff{Initial: interval, Multiplier: 1}
+	}
+}
+
+// WithParallel is waiting for all targets concurrently.
+func WithParallel(parallel ...bool) Opt {
+	return func(opts *Opts) {
+		opts.Parallel = len(parallel) == 0 || parallel[0]
+	}
+}
+
+// WithOnEvent is setting the function that is called for every event.
+func WithOnEvent(fn func(Event)) Opt {
+	return func(opts *Opts) {
+		opts.OnEvent = fn
+	}
+}
+
+// Waiter waits for targets to become available.
+type Waiter struct {
+	opts *Opts
+}
+
+// New returns a new waiter. Without a registry, the default registry is used.
+func New(opts ...Opt) *Waiter {
+	options := DefaultOpts()
+	options.Configure(opts...)
+
+	if options.Registry == nil {
+		options.Registry = DefaultRegistry()
+	}
+
+	w := new(Waiter)
+	w.opts = options
+
+	return w
+}
+
+// Wait waits for the targets and returns the results and the joined errors of the failed targets.
+// Sequentially, waiting stops at the first failed target and only the results
+// of the targets that have been waited for are returned.
+func (w *Waiter) Wait(ctx context.Context, targets ...string) ([]Result, error) {
+	if w.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.opts.Timeout)
+		defer cancel()
+	}
+
+	if w.opts.Parallel {
+		results := make([]Result, len(targets))
+
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Go(func() {
+				results[i] = w.wait(ctx, target)
+			})
+		}
+		wg.Wait()
+
+		errs := make([]error, 0, len(results))
+		for _, res := range results {
+			errs = append(errs, res.Err)
+		}
+
+		return results, errors.Join(errs...)
+	}
+
+	results := make([]Result, 0, len(targets))
+	for _, target := range targets {
+		res := w.wait(ctx, target)
+		results = append(results, res)
+
+		if res.Err != nil {
+			return results, res.Err
+		}
+	}
+
+	return results, nil
+}
+
+// WaitFor waits for a single target.
+func (w *Waiter) WaitFor(ctx context.Context, target string) Result {
+	if w.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.opts.Timeout)
+		defer cancel()
+	}
+
+	return w.wait(ctx, target)
+}
+
+func (w *Waiter) wait(ctx context.Context, target string) Result {
+	start := time.Now()
+	res := Result{Target: target}
+
+	fail := func(err error) Result {
+		res.Duration = time.Since(start)
+		res.Err = err
+		w.emit(Event{Type: EventFailed, Target: target, Attempt: res.Attempts, Elapsed: res.Duration, Err: err})
+
+		return res
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return fail(fmt.Errorf("url parse '%s': %w", target, err))
+	}
+
+	fn, ok := w.opts.Registry.Lookup(u.Scheme)
+	if !ok {
+		return fail(fmt.Errorf("%w %q", ErrUnsupportedScheme, u.Scheme))
+	}
+
+	for {
+		res.Attempts++
+
+		actx, cancel := ctx, context.CancelFunc(func() {})
+		if w.opts.AttemptTimeout > 0 {
+			actx, cancel = context.WithTimeout(ctx, w.opts.AttemptTimeout)
+		}
+		err = fn(actx, target)
+		cancel()
+
+		if err == nil {
+			res.Duration = time.Since(start)
+			w.emit(Event{Type: EventReady, Target: target, Attempt: res.Attempts, Elapsed: res.Duration})
+
+			return res
+		}
+
+		if ctx.Err() != nil {
+			return fail(fmt.Errorf("timeout waiting for %s: %w", target, err))
+		}
+
+		delay := w.opts.Backoff.Delay(res.Attempts - 1)
+		w.emit(Event{Type: EventRetry, Target: target, Attempt: res.Attempts, Elapsed: time.Since(start), Delay: delay, Err: err})
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fail(fmt.Errorf("timeout waiting for %s: %w", target, err))
+		case <-t.C:
+		}
+	}
+}
+
+func (w *Waiter) emit(e Event) {
+	if w.opts.OnEvent != nil {
+		w.opts.OnEvent(e)
+	}
+}
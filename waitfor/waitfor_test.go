@@ -0,0 +1,195 @@
+package waitfor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zeiss/pkg/server"
+)
+
+var errNotReady = errors.New("not ready")
+
+// readyAfter returns a wait function that fails n times before it succeeds.
+func readyAfter(n int32) WaitFunc {
+	var calls atomic.Int32
+
+	return func(context.Context, string) error {
+		if calls.Add(1) <= n {
+			return errNotReady
+		}
+
+		return nil
+	}
+}
+
+func testRegistry(fns map[string]WaitFunc) *Registry {
+	r := NewRegistry()
+	for scheme, fn := range fns {
+		r.Register(fn, scheme)
+	}
+
+	return r
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	r := DefaultRegistry()
+
+	for _, scheme := range []string{"http", "https", "tcp", "postgres", "mysql", "redis", "amqp", "grpc", "dns", "file", "unix"} {
+		_, ok := r.Lookup(scheme)
+		assert.True(t, ok, scheme)
+	}
+
+	_, ok := r.Lookup("ftp")
+	assert.False(t, ok)
+}
+
+func TestRegistrySchemes(t *testing.T) {
+	r := testRegistry(map[string]WaitFunc{"b": readyAfter(0), "a": readyAfter(0)})
+	assert.Equal(t, []string{"a", "b"}, r.Schemes())
+}
+
+func TestWaitRetries(t *testing.T) {
+	var events []Event
+
+	w := New(
+		WithRegistry(testRegistry(map[string]WaitFunc{"fake": readyAfter(2)})),
+		WithInterval(time.Millisecond),
+		WithOnEvent(func(e Event) { events = append(events, e) }),
+	)
+
+	results, err := w.Wait(t.Context(), "fake://a")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Ready())
+	assert.Equal(t, 3, results[0].Attempts)
+
+	require.Len(t, events, 3)
+	assert.Equal(t, EventRetry, events[0].Type)
+	assert.ErrorIs(t, events[0].Err, errNotReady)
+	assert.Equal(t, time.Millisecond, events[0].Delay)
+	assert.Equal(t, EventRetry, events[1].Type)
+	assert.Equal(t, EventReady, events[2].Type)
+	assert.Equal(t, 3, events[2].Attempt)
+}
+
+func TestWaitTimeout(t *testing.T) {
+	var failed []Event
+
+	w := New(
+		WithRegistry(testRegistry(map[string]WaitFunc{"fake": readyAfter(1 << 30)})),
+		WithInterval(time.Millisecond),
+		WithTimeout(20*time.Millisecond),
+		WithOnEvent(func(e Event) {
+			if e.Type == EventFailed {
+				failed = append(failed, e)
+			}
+		}),
+	)
+
+	results, err := w.Wait(t.Context(), "fake://a", "fake://b")
+	require.ErrorIs(t, err, errNotReady)
+	assert.ErrorContains(t, err, "timeout waiting for fake://a")
+	require.Len(t, results, 1, "waiting sequentially stops at the first failure")
+	assert.False(t, results[0].Ready())
+	assert.Greater(t, results[0].Attempts, 1)
+	require.Len(t, failed, 1)
+	assert.Equal(t, "fake://a", failed[0].Target)
+}
+
+func TestWaitUnsupportedScheme(t *testing.T) {
+	w := New(WithRegistry(NewRegistry()))
+
+	res := w.WaitFor(t.Context(), "ftp://localhost")
+	require.ErrorIs(t, res.Err, ErrUnsupportedScheme)
+	assert.Equal(t, 0, res.Attempts)
+}
+
+func TestWaitParallel(t *testing.T) {
+	var mu sync.Mutex
+	ready := map[string]bool{}
+
+	w := New(
+		WithRegistry(testRegistry(map[string]WaitFunc{
+			"ok":   readyAfter(1),
+			"fail": readyAfter(1 << 30),
+		})),
+		WithInterval(time.Millisecond),
+		WithTimeout(50*time.Millisecond),
+		WithParallel(),
+		WithOnEvent(func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if e.Type == EventReady {
+				ready[e.Target] = true
+			}
+		}),
+	)
+
+	results, err := w.Wait(t.Context(), "fail://a", "ok://b")
+	require.Error(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "fail://a", results[0].Target)
+	assert.False(t, results[0].Ready())
+	assert.Equal(t, "ok://b", results[1].Target)
+	assert.True(t, results[1].Ready())
+	assert.Equal(t, map[string]bool{"ok://b": true}, ready)
+}
+
+func TestWaitBackoff(t *testing.T) {
+	var delays []time.Duration
+
+	w := New(
+		WithRegistry(testRegistry(map[string]WaitFunc{"fake": readyAfter(3)})),
+		WithBackoff(server.Backoff{Initial: time.Millisecond, Multiplier: 2}),
+		WithOnEvent(func(e Event) {
+			if e.Type == EventRetry {
+				delays = append(delays, e.Delay)
+			}
+		}),
+	)
+
+	res := w.WaitFor(t.Context(), "fake://a")
+	require.NoError(t, res.Err)
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}, delays)
+}
+
+func TestListener(t *testing.T) {
+	w := New(
+		WithRegistry(testRegistry(map[string]WaitFunc{"fake": readyAfter(2)})),
+		WithInterval(time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	readyCh := make(chan struct{})
+	errCh := make(chan error, 1)
+
+	l := NewListener(w, "fake://a")
+	go func() {
+		errCh <- l.Start(ctx, func() { close(readyCh) }, nil)()
+	}()
+
+	select {
+	case <-readyCh:
+	case <-time.After(time.Second):
+		t.Fatal("listener did not signal to be ready")
+	}
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
+func TestListenerFails(t *testing.T) {
+	w := New(WithRegistry(NewRegistry()))
+
+	err := NewListener(w, "fake://a").Start(t.Context(), func() { t.Error("listener must not be ready") }, nil)()
+	require.ErrorIs(t, err, ErrUnsupportedScheme)
+}